@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/bot"
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+)
+
+func init() {
+	commands["battle"] = command{
+		usage: "play HTTP or process bots against each other under go-snake's rules",
+		run:   runBattle,
+	}
+}
+
+// runBattle acts as a rules engine: it runs a headless game whose snakes
+// are all steered by bots and reports the result
+func runBattle(args []string) error {
+	fs := flag.NewFlagSet("battle", flag.ExitOnError)
+	seed := fs.Int64("seed", 0, "seed for food placement (default: random)")
+	timeout := fs.Duration("timeout", bot.DefaultTimeout, "time each bot has to answer")
+	maxTicks := fs.Int("max-ticks", 10000, "stop the game after this many ticks")
+	out := fs.String("replay", "", "save a replay of the game to this file")
+	mode := fs.String("mode", "", "game mode, classic or royale (default: from the config); royale fills empty seats with built-in bots")
+	level := fs.String("level", "", "wall layout, "+strings.Join(game.Levels, ", ")+" (default: from the config)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-snake battle [flags] [name=]bot...\n\nEach bot is an http(s) URL of a Battlesnake-compatible bot, a command\nline of a bot speaking the stdin/stdout protocol, or \"builtin\".")
+		fs.PrintDefaults()
+	}
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("expected at least one bot")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := setRules(cfg, *mode, *level); err != nil {
+		return err
+	}
+
+	specs := fs.Args()
+	if cfg.Game.Mode == game.ModeRoyale {
+		for len(specs) < royaleSnakes(cfg) {
+			specs = append(specs, "builtin")
+		}
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	g := game.NewMultiplayerGame(cfg, *seed, len(specs))
+	controllers := make(map[int]bot.Controller)
+	for i, arg := range specs {
+		name, spec := botArg(arg, i)
+		g.Snakes[i].Name = name
+		if controllers[i], err = bot.Open(spec); err != nil {
+			return err
+		}
+	}
+
+	pilot := bot.NewPilot(controllers)
+	pilot.Timeout = *timeout
+	pilot.Run(g, *maxTicks)
+
+	if g.IsGameOver() {
+		fmt.Printf("Game %d finished after %d ticks\n", g.Seed, g.Tick)
+	} else {
+		fmt.Printf("Game %d stopped at the limit of %d ticks\n", g.Seed, g.Tick)
+	}
+	for _, st := range netplay.Standings(g) {
+		line := fmt.Sprintf("  %-20s score %5d  length %3d", st.Name, st.Score, st.Length)
+		if st.Winner {
+			line += "  (winner)"
+		}
+		fmt.Println(line)
+	}
+
+	if *out != "" {
+		if err := replay.FromGame(g, "battle").Save(*out); err != nil {
+			return err
+		}
+		fmt.Printf("Replay saved to %s\n", *out)
+	}
+	return nil
+}
+
+// botArg splits a "name=spec" bot argument, naming unnamed bots by position
+func botArg(arg string, i int) (name, spec string) {
+	if n, s, ok := strings.Cut(arg, "="); ok && !strings.ContainsAny(n, "/ ") {
+		return n, s
+	}
+	return fmt.Sprintf("Bot %d", i+1), arg
+}
+
+// botList collects repeated -bot flags
+type botList []string
+
+func (b *botList) String() string {
+	return strings.Join(*b, ",")
+}
+
+func (b *botList) Set(v string) error {
+	*b = append(*b, v)
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/transfer"
+)
+
+func init() {
+	commands["export-data"] = command{
+		usage: "write high scores, history or profiles as CSV or JSON",
+		run:   runExportData,
+	}
+	commands["import-data"] = command{
+		usage: "merge high scores, history or profiles from a CSV or JSON export",
+		run:   runImportData,
+	}
+}
+
+// runExportData writes one kind of stored data to a file or stdout
+func runExportData(args []string) error {
+	fs := flag.NewFlagSet("export-data", flag.ExitOnError)
+	kind := fs.String("kind", transfer.Scores, "data to export: "+strings.Join(transfer.Kinds, ", "))
+	format := fs.String("format", "", "csv or json (default: from the -o extension, else json)")
+	out := fs.String("o", "", "file to write (default: stdout)")
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	if *format == "" {
+		*format = transfer.FormatOf(*out)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return transfer.Export(os.Stdout, store, *kind, *format)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := transfer.Export(f, store, *kind, *format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runImportData merges an export into the stored data
+func runImportData(args []string) error {
+	fs := flag.NewFlagSet("import-data", flag.ExitOnError)
+	kind := fs.String("kind", transfer.Scores, "data to import: "+strings.Join(transfer.Kinds, ", "))
+	format := fs.String("format", "", "csv or json (default: from the file extension)")
+	dryRun := fs.Bool("dry-run", false, "show what would be added without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-snake import-data [-kind scores|history|profiles] [-format csv|json] [-dry-run] <file>")
+		fs.PrintDefaults()
+	}
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one file to import")
+	}
+	if *format == "" {
+		*format = transfer.FormatOf(fs.Arg(0))
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := transfer.Read(f, *kind, *format)
+	if err != nil {
+		return fmt.Errorf("reading %s: %v", fs.Arg(0), err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	store.TopN = cfg.Scores.Top
+
+	res, err := transfer.Import(store, data, *dryRun)
+	if res != nil {
+		verb := "Added"
+		if *dryRun {
+			verb = "Would add"
+		}
+		noun := map[string]string{transfer.Scores: "scores", transfer.History: "games", transfer.Profiles: "profiles"}[*kind]
+		fmt.Printf("%s %d %s, %d already present", verb, len(res.Added), noun, res.Duplicates)
+		if len(res.Skipped) > 0 {
+			fmt.Printf(", %d skipped", len(res.Skipped))
+		}
+		fmt.Println()
+		for _, line := range res.Added {
+			fmt.Println("  + " + line)
+		}
+		for _, line := range res.Skipped {
+			fmt.Println("  ! " + line)
+		}
+	}
+	return err
+}
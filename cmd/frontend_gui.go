@@ -0,0 +1,70 @@
+//go:build !nogui
+
+package main
+
+import (
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/gui"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+func init() {
+	frontends["gui"] = frontend{
+		play:   runGUI,
+		replay: runGUIReplay,
+		remote: runGUIRemote,
+		lan:    runGUILAN,
+	}
+}
+
+// runGUI runs the game in an Ebiten window
+func runGUI(g *game.Game, cfg *config.Config, s *storage.Storage) error {
+	// Create Ebiten GUI wrapper
+	ebitenGUI, err := gui.NewEbitenGUI(g, cfg, s)
+	if err != nil {
+		return err
+	}
+	if leaderboardSync != nil {
+		ebitenGUI.SetLeaderboard(leaderboardSync)
+	}
+	ebitenGUI.SetAchievements(achievementList)
+	if pickProfile {
+		ebitenGUI.ShowProfiles()
+	}
+
+	// Run the game using Ebiten's RunGame function
+	return ebitenGUI.Run()
+}
+
+// runGUIReplay plays back a replay in an Ebiten window
+func runGUIReplay(p *replay.Player, cfg *config.Config, s *storage.Storage) error {
+	ebitenGUI, err := gui.NewReplayGUI(p, cfg, s)
+	if err != nil {
+		return err
+	}
+
+	return ebitenGUI.Run()
+}
+
+// runGUIRemote plays a hosted game in an Ebiten window
+func runGUIRemote(c *netplay.Client, cfg *config.Config, s *storage.Storage) error {
+	ebitenGUI, err := gui.NewRemoteGUI(c, cfg, s)
+	if err != nil {
+		return err
+	}
+
+	return ebitenGUI.Run()
+}
+
+// runGUILAN browses LAN games in an Ebiten window
+func runGUILAN(b *netplay.Browser, name string, cfg *config.Config, s *storage.Storage) error {
+	ebitenGUI, err := gui.NewLANGUI(b, name, cfg, s)
+	if err != nil {
+		return err
+	}
+
+	return ebitenGUI.Run()
+}
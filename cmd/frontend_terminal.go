@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/paths"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+	"github.com/C0d3-5t3w/go-snake/internal/tui"
+)
+
+// terminalLog is where log output goes while the terminal front-end owns
+// the screen, inside paths.CacheDir
+const terminalLog = "terminal.log"
+
+var asciiFlag = flag.Bool("ascii", false, "use plain ASCII characters in the terminal front-end")
+
+func init() {
+	frontends["terminal"] = frontend{
+		play:   runTerminal,
+		remote: runTerminalRemote,
+	}
+}
+
+// runTerminal runs the game in the current terminal using ANSI escape codes
+func runTerminal(g *game.Game, cfg *config.Config, s *storage.Storage) error {
+	terminalUI, err := tui.NewTerminalUI(g, cfg, s, *asciiFlag)
+	if err != nil {
+		return err
+	}
+	if leaderboardSync != nil {
+		terminalUI.SetLeaderboard(leaderboardSync)
+	}
+	terminalUI.SetAchievements(achievementList)
+	if pickProfile {
+		terminalUI.ShowProfiles()
+	}
+
+	restore := logToFile()
+	defer restore()
+	return terminalUI.Run()
+}
+
+// runTerminalRemote plays a hosted game in the current terminal
+func runTerminalRemote(c *netplay.Client, cfg *config.Config, s *storage.Storage) error {
+	terminalUI, err := tui.NewRemoteTerminalUI(c, cfg, s, *asciiFlag)
+	if err != nil {
+		return err
+	}
+
+	restore := logToFile()
+	defer restore()
+	return terminalUI.Run()
+}
+
+// logToFile sends the standard logger, which bots, their stderr and the
+// leaderboard sync write to, to a file so it cannot garble the screen. The
+// returned function puts it back and says where any messages went. If the
+// file cannot be opened, logging is discarded instead.
+func logToFile() func() {
+	path := filepath.Join(paths.CacheDir(), terminalLog)
+	var f *os.File
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		f, _ = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	}
+	if f == nil {
+		log.SetOutput(ioutil.Discard)
+		return func() { log.SetOutput(os.Stderr) }
+	}
+
+	log.SetOutput(f)
+	return func() {
+		log.SetOutput(os.Stderr)
+		if info, err := f.Stat(); err == nil && info.Size() > 0 {
+			fmt.Fprintf(os.Stderr, "Log messages were written to %s\n", path)
+		}
+		f.Close()
+	}
+}
@@ -1,15 +1,102 @@
 package main
 
 import (
+	"bytes"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/C0d3-5t3w/go-snake/internal/achievements"
+	"github.com/C0d3-5t3w/go-snake/internal/bot"
 	"github.com/C0d3-5t3w/go-snake/internal/config"
 	"github.com/C0d3-5t3w/go-snake/internal/game"
-	"github.com/C0d3-5t3w/go-snake/internal/gui"
+	"github.com/C0d3-5t3w/go-snake/internal/leaderboard"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/paths"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
 	"github.com/C0d3-5t3w/go-snake/internal/storage"
 )
 
+// frontend is a way of presenting the game. Modes a front-end does not
+// support are left nil.
+type frontend struct {
+	// play runs a local game until the player quits
+	play func(g *game.Game, cfg *config.Config, s *storage.Storage) error
+	// replay plays back a recorded game
+	replay func(p *replay.Player, cfg *config.Config, s *storage.Storage) error
+	// remote plays a game hosted by a server
+	remote func(c *netplay.Client, cfg *config.Config, s *storage.Storage) error
+	// lan browses the games hosted on the local network and joins one
+	lan func(b *netplay.Browser, name string, cfg *config.Config, s *storage.Storage) error
+}
+
+// frontends holds the available front-ends keyed by their -ui name.
+// Optional front-ends register themselves from build-tagged files.
+var frontends = map[string]frontend{}
+
+// pickProfile is set when the front-end should let the player choose a
+// profile before a local game
+var pickProfile bool
+
+// leaderboardSync submits finished local games to the configured shared
+// leaderboard, nil when none is configured
+var leaderboardSync *leaderboard.Syncer
+
+// achievementList holds the achievements tracked in local games
+var achievementList []achievements.Achievement
+
+// command is a go-snake subcommand such as "replays"
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+// commands holds the subcommands keyed by name
+var commands = map[string]command{}
+
 func main() {
+	// Dispatch subcommands before parsing the game flags
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", os.Args[1], err)
+			}
+			return
+		}
+	}
+
+	ui := flag.String("ui", defaultFrontend(), "front-end to use ("+strings.Join(frontendNames(), ", ")+")")
+	replayFile := flag.String("replay", "", "play back a replay file, or a stored replay by name, instead of starting a game")
+	connect := flag.String("connect", "", "join a hosted game at host:port")
+	name := flag.String("name", "", "player name shown to other players (default: the profile's name)")
+	profile := flag.String("profile", "", "play as this profile, creating it if needed, instead of picking one at startup")
+	spectate := flag.String("spectate", "", "watch a game hosted or shared at host:port")
+	shareAddr := flag.String("share", "", "let spectators watch this local game on the given address")
+	shareDelay := flag.Int("share-delay", 10, "ticks spectators of a shared game lag behind")
+	lan := flag.Bool("lan", false, "browse and join games hosted on the local network")
+	mode := flag.String("mode", "", "game mode, classic or royale (default: from the config)")
+	level := flag.String("level", "", "wall layout, "+strings.Join(game.Levels, ", ")+" (default: from the config)")
+	latency := flag.Duration("latency", 0, "delay added to each direction of a hosted game's connection, for testing")
+	jitter := flag.Duration("jitter", 0, "random extra delay of up to this much on a hosted game's connection")
+	colorName := flag.String("color", "", "snake color to ask for in a hosted game's lobby: "+strings.Join(paletteNames(), ", "))
+	noPredict := flag.Bool("no-predict", false, "show only the server's state in hosted games instead of predicting your snake")
+	var bots botList
+	flag.Var(&bots, "bot", "add an opponent steered by a bot: [name=]url of a Battlesnake-compatible bot or [name=]command of a process bot (repeatable)")
+	addDirFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+
+	fe, ok := frontends[*ui]
+	if !ok {
+		log.Fatalf("Unknown front-end %q (available: %s)", *ui, strings.Join(frontendNames(), ", "))
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -17,22 +104,322 @@ func main() {
 	}
 
 	// Initialize storage
-	store, err := storage.NewStorage()
+	store, err := openStorage(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
 
-	// Create game instance
-	gameInstance := game.NewGame(cfg)
+	store.TopN = cfg.Scores.Top
+
+	// Play as the requested profile, or let the front-end ask
+	if *profile != "" {
+		if err := useProfile(store, *profile); err != nil {
+			log.Fatalf("Failed to select profile: %v", err)
+		}
+	}
+	pickProfile = *profile == ""
+
+	// Sync finished games with the shared leaderboard if one is configured.
+	// Without a configured player, runs are submitted under the -name
+	// given or else the profile that played them.
+	if cfg.Leaderboard.URL != "" {
+		player := cfg.Leaderboard.Player
+		if player == "" {
+			player = *name
+		}
+		leaderboardSync = leaderboard.NewSyncer(leaderboard.NewClient(cfg.Leaderboard.URL), store, player)
+		leaderboardSync.Cache = filepath.Join(paths.CacheDir(), "leaderboard.json")
+		leaderboardSync.Start()
+		defer leaderboardSync.Stop()
+	}
 
-	// Create Ebiten GUI wrapper
-	ebitenGUI, err := gui.NewEbitenGUI(gameInstance, cfg, store)
+	if *name == "" {
+		*name = store.ActiveProfile().Name
+	}
+
+	// Play back a replay if one was requested
+	if *replayFile != "" {
+		if fe.replay == nil {
+			log.Fatalf("Front-end %q does not support replay playback", *ui)
+		}
+
+		r, err := loadReplay(store, *replayFile)
+		if err != nil {
+			log.Fatalf("Failed to load replay: %v", err)
+		}
+
+		if err := fe.replay(replay.NewPlayer(r), cfg, store); err != nil {
+			log.Fatalf("Front-end %q error: %v", *ui, err)
+		}
+		return
+	}
+
+	// Browse the local network for a hosted game if requested
+	if *lan {
+		if fe.lan == nil {
+			log.Fatalf("Front-end %q does not support browsing LAN games", *ui)
+		}
+
+		browser, err := netplay.Browse(fmt.Sprintf(":%d", netplay.DiscoveryPort))
+		if err != nil {
+			log.Fatalf("Failed to listen for LAN games: %v", err)
+		}
+		defer browser.Close()
+
+		if err := fe.lan(browser, *name, cfg, store); err != nil {
+			log.Fatalf("Front-end %q error: %v", *ui, err)
+		}
+		return
+	}
+
+	// Join or watch a hosted game if one was requested
+	if *connect != "" || *spectate != "" {
+		if fe.remote == nil {
+			log.Fatalf("Front-end %q does not support hosted games", *ui)
+		}
+
+		color, err := paletteColor(*colorName)
+		if err != nil {
+			log.Fatalf("Invalid color: %v", err)
+		}
+		dialer := &netplay.Dialer{Latency: *latency, Jitter: *jitter, NoPredict: *noPredict, Color: color}
+		var client *netplay.Client
+		if *spectate != "" {
+			client, err = dialer.Spectate(*spectate, *name, cfg)
+		} else {
+			client, err = dialer.Dial(*connect, *name, cfg)
+		}
+		if err != nil {
+			log.Fatalf("Failed to connect: %v", err)
+		}
+		defer client.Close()
+
+		if err := fe.remote(client, cfg, store); err != nil {
+			log.Fatalf("Front-end %q error: %v", *ui, err)
+		}
+		return
+	}
+
+	if err := setRules(cfg, *mode, *level); err != nil {
+		log.Fatalf("Invalid rules: %v", err)
+	}
+	if !pickProfile {
+		// The menu checks unlocks when a profile is picked; without it, check here
+		if need := store.ActiveProfile().Locked("level:" + cfg.Game.Level); need > 0 {
+			log.Printf("Level %s unlocks at a best score of %d, playing %s", cfg.Game.Level, need, game.LevelOpen)
+			cfg.Game.Level = game.LevelOpen
+		}
+	}
+
+	if achievementList, err = achievements.Load(); err != nil {
+		log.Fatalf("Failed to load achievements: %v", err)
+	}
+
+	// Create game instance, with a snake for every bot opponent. Royale
+	// games fill the remaining seats with built-in bots.
+	snakes := 1 + len(bots)
+	if cfg.Game.Mode == game.ModeRoyale && snakes < royaleSnakes(cfg) {
+		snakes = royaleSnakes(cfg)
+	}
+	// The game runs at the profile's difficulty, on a copy of the config
+	difficulty := store.GetSettings().Difficulty
+	gameInstance := game.NewMultiplayerGame(game.WithDifficulty(cfg, difficulty), time.Now().UnixNano(), snakes)
+	gameInstance.Difficulty = difficulty
+	if snakes > 1 {
+		gameInstance.Snakes[0].Name = *name
+		controllers := make(map[int]bot.Controller)
+		for i := 1; i < snakes; i++ {
+			spec := "builtin"
+			botName := fmt.Sprintf("Bot %d", i)
+			if i <= len(bots) {
+				botName, spec = botArg(bots[i-1], i-1)
+			}
+			gameInstance.Snakes[i].Name = botName
+			if controllers[i], err = bot.Open(spec); err != nil {
+				log.Fatalf("Failed to create bot: %v", err)
+			}
+		}
+		bot.NewPilot(controllers).Attach(gameInstance)
+	}
+
+	// Stream the game to spectators if requested
+	if *shareAddr != "" {
+		broadcaster := netplay.NewBroadcaster(*shareDelay)
+		go func() {
+			if err := broadcaster.ListenAndServe(*shareAddr); err != nil {
+				log.Printf("Spectator listener error: %v", err)
+			}
+		}()
+		defer broadcaster.Close()
+
+		gameInstance.Snakes[0].Name = *name
+		onTick := gameInstance.OnTick
+		gameInstance.OnTick = func(g *game.Game) {
+			if onTick != nil {
+				onTick(g)
+			}
+			broadcaster.Publish(netplay.TakeSnapshot(g))
+		}
+		broadcaster.Publish(netplay.TakeSnapshot(gameInstance))
+	}
+
+	// Run the game on the selected front-end
+	if err := fe.play(gameInstance, cfg, store); err != nil {
+		log.Fatalf("Front-end %q error: %v", *ui, err)
+	}
+}
+
+// usage prints the game flags followed by the available subcommands
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags]\n       %s <command> [args]\n\nFlags:\n", os.Args[0], os.Args[0])
+	flag.PrintDefaults()
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(out, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-20s %s\n", name, commands[name].usage)
+	}
+}
+
+// loadReplay opens a replay by path, falling back to the replays in storage
+func loadReplay(s *storage.Storage, name string) (*replay.Replay, error) {
+	if _, err := os.Stat(name); err == nil {
+		return replay.Load(name)
+	}
+	data, err := s.ReadReplay(name)
+	if err != nil {
+		return nil, err
+	}
+	return replay.Decode(bytes.NewReader(data))
+}
+
+// addDirFlags adds the flags overriding where config, data and cache
+// files are kept
+func addDirFlags(fs *flag.FlagSet) {
+	fs.Func("config-dir", "directory holding config.yaml, also set by $"+paths.EnvConfigDir+" (default "+paths.ConfigDir()+")", func(dir string) error {
+		paths.SetConfigDir(dir)
+		return nil
+	})
+	fs.Func("data-dir", "directory holding scores and replays, also set by $"+paths.EnvDataDir+" (default "+paths.DataDir()+")", func(dir string) error {
+		paths.SetDataDir(dir)
+		return nil
+	})
+	fs.Func("cache-dir", "directory for cached leaderboard data, also set by $"+paths.EnvCacheDir+" (default "+paths.CacheDir()+")", func(dir string) error {
+		paths.SetCacheDir(dir)
+		return nil
+	})
+}
+
+// openStorage opens the configured storage backend, warning when a
+// corrupt document had to be set aside
+func openStorage(cfg *config.Config) (*storage.Storage, error) {
+	b, err := storage.DefaultBackend(cfg.Storage.Backend)
 	if err != nil {
-		log.Fatalf("Failed to initialize Ebiten GUI: %v", err)
+		return nil, err
+	}
+	s, err := storage.NewStorage(b)
+	if err != nil {
+		return nil, err
+	}
+	if c := s.Recovered(); c != nil {
+		log.Printf("Warning: %v", c)
+	}
+	return s, nil
+}
+
+// useProfile makes the named profile active, creating it if there is none
+// by that name in any case
+func useProfile(s *storage.Storage, name string) error {
+	found := false
+	for _, p := range s.Profiles() {
+		if strings.EqualFold(p.Name, strings.TrimSpace(name)) {
+			name, found = p.Name, true
+		}
+	}
+	if !found {
+		var err error
+		if name, err = s.CreateProfile(name); err != nil {
+			return err
+		}
+	}
+
+	if err := s.SetActiveProfile(name); err != nil {
+		return err
+	}
+	return s.Save()
+}
+
+// setRules overrides the configured mode and level with non-empty flags
+func setRules(cfg *config.Config, mode, level string) error {
+	if mode != "" {
+		cfg.Game.Mode = mode
+	}
+	if level != "" {
+		cfg.Game.Level = level
+	}
+	if cfg.Game.Mode != game.ModeClassic && cfg.Game.Mode != game.ModeRoyale {
+		return fmt.Errorf("unknown mode %q (available: %s, %s)", cfg.Game.Mode, game.ModeClassic, game.ModeRoyale)
+	}
+	if !game.ValidLevel(cfg.Game.Level) {
+		return fmt.Errorf("unknown level %q (available: %s)", cfg.Game.Level, strings.Join(game.Levels, ", "))
+	}
+	return nil
+}
+
+// paletteNames lists the snake colors players can pick
+func paletteNames() []string {
+	names := make([]string, len(render.Palette))
+	for i, c := range render.Palette {
+		names[i] = strings.ToLower(c.Name)
+	}
+	return names
+}
+
+// paletteColor finds a snake color by name; the empty name is the default
+func paletteColor(name string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	for i, c := range render.Palette {
+		if strings.EqualFold(c.Name, name) {
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown color %q (available: %s)", name, strings.Join(paletteNames(), ", "))
+}
+
+// royaleSnakes returns how many snakes a royale match holds, 8 to 16
+func royaleSnakes(cfg *config.Config) int {
+	n := cfg.Royale.Snakes
+	if n < 8 {
+		n = 8
 	}
+	if n > 16 {
+		n = 16
+	}
+	return n
+}
+
+// defaultFrontend prefers the GUI when it was compiled in
+func defaultFrontend() string {
+	if _, ok := frontends["gui"]; ok {
+		return "gui"
+	}
+	return "terminal"
+}
 
-	// Run the game using Ebiten's RunGame function
-	if err := ebitenGUI.Run(); err != nil {
-		log.Fatalf("Ebiten RunGame error: %v", err)
+// frontendNames lists the registered front-ends in a stable order
+func frontendNames() []string {
+	names := make([]string, 0, len(frontends))
+	for name := range frontends {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
 }
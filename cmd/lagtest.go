@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/bot"
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+)
+
+func init() {
+	commands["lagtest"] = command{
+		usage: "play a hosted game over a simulated slow connection and report how prediction held up",
+		run:   runLagTest,
+	}
+}
+
+// runLagTest hosts a match on loopback, joins it through injected latency
+// with a built-in bot steering from what a player would see, and reports
+// how often the shown snake had to be corrected
+func runLagTest(args []string) error {
+	fs := flag.NewFlagSet("lagtest", flag.ExitOnError)
+	latency := fs.Duration("latency", 100*time.Millisecond, "delay added to each direction of the connection")
+	jitter := fs.Duration("jitter", 20*time.Millisecond, "random extra delay of up to this much")
+	tick := fs.Duration("tick", 100*time.Millisecond, "time between server ticks")
+	bots := fs.Int("bots", 1, "built-in bots playing against the test player")
+	noPredict := fs.Bool("no-predict", false, "steer from the server's state only, for comparison")
+	limit := fs.Duration("limit", 60*time.Second, "end the test after this long")
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	server := netplay.NewServer(cfg, 1)
+	server.TickRate = *tick
+	server.Logf = func(string, ...interface{}) {}
+	server.Countdown = 0
+	for i := 0; i < *bots; i++ {
+		server.Bots = append(server.Bots, bot.Builtin{})
+	}
+	go server.Serve(ln)
+	defer server.Close()
+
+	dialer := &netplay.Dialer{Latency: *latency, Jitter: *jitter, NoPredict: *noPredict}
+	client, err := dialer.Dial(ln.Addr().String(), "lagtest", cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.SetReady(true); err != nil {
+		return err
+	}
+
+	// Steer like a player would: look at the shown state every frame and
+	// react once per tick
+	var (
+		player   bot.Builtin
+		lastTick = -1
+		lastHead game.Point2D
+		inputs   int
+		snaps    int // Frames where the shown head jumped instead of moving a cell
+		frames   = time.NewTicker(10 * time.Millisecond)
+		deadline = time.After(*limit)
+	)
+	defer frames.Stop()
+
+loop:
+	for {
+		select {
+		case <-client.Done():
+			break loop
+		case <-deadline:
+			break loop
+		case <-frames.C:
+		}
+
+		g := client.Game()
+		self := client.Self()
+		if g == nil || g.IsGameOver() || self < 0 || self >= len(g.Snakes) || !g.Snakes[self].Alive {
+			continue
+		}
+		head := g.Snakes[self].Body[0]
+		if lastTick >= 0 && head != lastHead && !adjacent(head, lastHead) {
+			snaps++
+		}
+		lastHead = head
+
+		if g.Tick == lastTick {
+			continue
+		}
+		lastTick = g.Tick
+
+		dir, err := player.Move(context.Background(), bot.NewState(g, self, *tick))
+		if err == nil && dir != g.Snakes[self].Direction {
+			if err := client.SendDirection(dir); err != nil {
+				break
+			}
+			inputs++
+		}
+	}
+
+	st := client.Stats()
+	fmt.Printf("Latency %v ± %v, tick %v, prediction %v\n", *latency, *jitter, *tick, !*noPredict)
+	fmt.Printf("  ticks seen    %d\n", lastTick)
+	fmt.Printf("  inputs sent   %d\n", inputs)
+	fmt.Printf("  ping          %v\n", st.Ping.Round(time.Millisecond))
+	fmt.Printf("  corrections   %d\n", st.Corrections)
+	fmt.Printf("  head snaps    %d\n", snaps)
+	for _, s := range client.Standings() {
+		line := fmt.Sprintf("  %-20s score %5d  length %3d", s.Name, s.Score, s.Length)
+		if s.Winner {
+			line += "  (winner)"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// adjacent reports whether two cells are one step apart
+func adjacent(a, b game.Point2D) bool {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	return dx*dx+dy*dy == 1
+}
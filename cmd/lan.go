@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+)
+
+func init() {
+	commands["lan"] = command{
+		usage: "list games hosted on the local network",
+		run:   runLAN,
+	}
+}
+
+// runLAN listens for game announcements and prints the games found
+func runLAN(args []string) error {
+	fs := flag.NewFlagSet("lan", flag.ExitOnError)
+	wait := fs.Duration("wait", 3*time.Second, "how long to listen for announcements")
+	fs.Parse(args)
+
+	browser, err := netplay.Browse(fmt.Sprintf(":%d", netplay.DiscoveryPort))
+	if err != nil {
+		return err
+	}
+	defer browser.Close()
+
+	time.Sleep(*wait)
+
+	games := browser.Games()
+	if len(games) == 0 {
+		fmt.Println("No games found")
+		return nil
+	}
+
+	for _, g := range games {
+		state := fmt.Sprintf("waiting %d/%d", g.Players, g.Needed)
+		if g.Playing {
+			state = "in progress"
+		}
+		fmt.Printf("%-20s %-8s %2dx%-2d  %-14s %s\n", g.Name, g.Mode, g.Board, g.Board, state, g.Addr)
+	}
+	return nil
+}
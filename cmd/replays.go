@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+)
+
+func init() {
+	commands["replays"] = command{
+		usage: "list high scores and the replays that produced them",
+		run:   runReplays,
+	}
+}
+
+// runReplays prints the stored high scores of each ruleset next to their
+// replay files
+func runReplays(args []string) error {
+	fs := flag.NewFlagSet("replays", flag.ExitOnError)
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Scores marked * are verified by their replay
+	for n, rules := range store.Rulesets() {
+		if n > 0 {
+			fmt.Println()
+		}
+		fmt.Println(rules)
+		for i, hs := range store.HighScoresFor(rules) {
+			replayName := "-"
+			if hs.Replay != "" {
+				replayName = hs.Replay
+			}
+			verified := " "
+			if hs.Verified {
+				verified = "*"
+			}
+			fmt.Printf("%2d. %-12s %6d%s %s  %s\n", i+1, hs.Player, hs.Score, verified, hs.Date.Format("2006-01-02 15:04"), replayName)
+		}
+	}
+
+	return nil
+}
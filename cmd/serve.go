@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/bot"
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+)
+
+func init() {
+	commands["serve"] = command{
+		usage: "host multi-snake games on a TCP port",
+		run:   runServe,
+	}
+}
+
+// runServe hosts matches until interrupted
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", fmt.Sprintf(":%d", netplay.DefaultPort), "address to listen on")
+	players := fs.Int("players", 2, "players needed to start a match")
+	maxPlayers := fs.Int("max-players", 8, "most players the lobby takes")
+	countdown := fs.Duration("countdown", netplay.DefaultCountdown, "countdown once everyone in the lobby is ready")
+	tick := fs.Duration("tick", 0, "time between ticks until the host picks a speed (default: from the config's initial speed)")
+	delay := fs.Int("spectator-delay", 10, "ticks spectators lag behind the players")
+	name := fs.String("name", defaultServerName(), "game name shown to players on the local network")
+	announce := fs.Bool("announce", true, "announce the game on the local network")
+	mode := fs.String("mode", "", "game mode, classic or royale (default: from the config)")
+	level := fs.String("level", "", "wall layout, "+strings.Join(game.Levels, ", ")+" (default: from the config)")
+	bots := fs.Int("bots", 0, "built-in bots joining every match after the players")
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := setRules(cfg, *mode, *level); err != nil {
+		return err
+	}
+
+	server := netplay.NewServer(cfg, *players)
+	for i := 0; i < *bots; i++ {
+		server.Bots = append(server.Bots, bot.Builtin{})
+	}
+	if *tick > 0 {
+		server.TickRate = *tick
+	}
+	if *maxPlayers >= server.Players {
+		server.MaxPlayers = *maxPlayers
+	}
+	server.Countdown = *countdown
+	server.Spectators.Delay = *delay
+	server.Name = *name
+
+	if *announce {
+		announcer := netplay.NewAnnouncer(server.Status)
+		go func() {
+			if err := announcer.Run(); err != nil {
+				log.Printf("LAN announcements stopped: %v", err)
+			}
+		}()
+		defer announcer.Close()
+	}
+
+	log.Printf("Hosting games for %d to %d players on %s (tick %v)", server.Players, server.MaxPlayers, *addr, server.TickRate.Round(time.Millisecond))
+	return server.ListenAndServe(*addr)
+}
+
+// defaultServerName names a hosted game after the machine it runs on
+func defaultServerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "go-snake"
+	}
+	return host
+}
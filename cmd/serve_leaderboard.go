@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/leaderboard"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+func init() {
+	commands["serve-leaderboard"] = command{
+		usage: "run a shared HTTP/JSON leaderboard server",
+		run:   runServeLeaderboard,
+	}
+}
+
+// runServeLeaderboard serves the leaderboard API until interrupted
+func runServeLeaderboard(args []string) error {
+	fs := flag.NewFlagSet("serve-leaderboard", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	data := fs.String("data", "leaderboard.json", "file the leaderboard is persisted to, with replays next to it")
+	backend := fs.String("backend", "", "storage backend, json, journal or memory (default: from the config)")
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	if *backend == "" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		*backend = cfg.Storage.Backend
+	}
+
+	// Older servers kept replays in a directory named after the data file
+	replays := filepath.Join(filepath.Dir(*data), "replays")
+	if _, err := os.Stat(replays); os.IsNotExist(err) {
+		if _, err := os.Stat(*data + ".replays"); err == nil {
+			log.Printf("Moving replays from %s to %s", *data+".replays", replays)
+			if err := os.Rename(*data+".replays", replays); err != nil {
+				return err
+			}
+		}
+	}
+
+	b, err := storage.OpenBackend(*backend, *data)
+	if err != nil {
+		return err
+	}
+	server, err := leaderboard.NewServer(b)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Leaderboard listening on %s (data in %s)", *addr, b)
+	return http.ListenAndServe(*addr, server.Handler())
+}
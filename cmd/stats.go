@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/stats"
+)
+
+func init() {
+	commands["stats"] = command{
+		usage: "report averages, streaks and trends from the game history",
+		run:   runStats,
+	}
+}
+
+// dateLayout is how -from and -to dates are written
+const dateLayout = "2006-01-02"
+
+// runStats summarizes the games in the history log
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	profile := fs.String("profile", "", "only count games played by this profile")
+	mode := fs.String("mode", "", "only count games of this mode, classic or royale")
+	from := fs.String("from", "", "only count games on or after this date (YYYY-MM-DD)")
+	to := fs.String("to", "", "only count games on or before this date (YYYY-MM-DD)")
+	by := fs.String("by", stats.ByWeek, "group the trend by day, week or month")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	filter := stats.Filter{Profile: *profile, Mode: *mode}
+	var err error
+	if *from != "" {
+		if filter.From, err = time.ParseInLocation(dateLayout, *from, time.Local); err != nil {
+			return fmt.Errorf("invalid -from date: %v", err)
+		}
+	}
+	if *to != "" {
+		if filter.To, err = time.ParseInLocation(dateLayout, *to, time.Local); err != nil {
+			return fmt.Errorf("invalid -to date: %v", err)
+		}
+		// Include the whole of the last day
+		filter.To = filter.To.AddDate(0, 0, 1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	history, err := store.History()
+	if err != nil {
+		return err
+	}
+
+	report, err := stats.Compute(history, filter, *by)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	printReport(report, filter, *by)
+	return nil
+}
+
+// printReport writes a report as text
+func printReport(r *stats.Report, f stats.Filter, by string) {
+	var scope []string
+	if f.Profile != "" {
+		scope = append(scope, "profile "+f.Profile)
+	}
+	if f.Mode != "" {
+		scope = append(scope, "mode "+f.Mode)
+	}
+	suffix := ""
+	if len(scope) > 0 {
+		suffix = " (" + strings.Join(scope, ", ") + ")"
+	}
+
+	if r.Games == 0 {
+		fmt.Printf("No games recorded%s\n", suffix)
+		return
+	}
+	fmt.Printf("%d games from %s to %s%s\n\n", r.Games, r.First.Format(dateLayout), r.Last.Format(dateLayout), suffix)
+
+	fmt.Printf("%-8s %8s %8s %8s %8s %8s %8s\n", "", "mean", "median", "p25", "p75", "p90", "best")
+	for _, row := range []struct {
+		name string
+		s    stats.Summary
+	}{{"Score", r.Score}, {"Length", r.Length}, {"Ticks", r.Ticks}} {
+		fmt.Printf("%-8s %8.1f %8.1f %8.1f %8.1f %8.1f %8d\n", row.name, row.s.Mean, row.s.Median, row.s.P25, row.s.P75, row.s.P90, row.s.Best)
+	}
+
+	s := r.Streaks
+	fmt.Printf("\nStreaks: %d-day run up to the last game (longest %d days), %d games in a row above average, %d new bests\n",
+		s.CurrentDays, s.LongestDays, s.AboveAverage, s.NewBests)
+
+	t := r.Trend
+	fmt.Printf("Trend:   %+.2f points per game", t.PerGame)
+	if r.Games > 1 {
+		fmt.Printf(", newer half averages %.1f against %.1f before", t.LastHalf, t.FirstHalf)
+	}
+	fmt.Println()
+
+	heading := map[string]string{stats.ByDay: "Day", stats.ByWeek: "Week of", stats.ByMonth: "Month of"}[by]
+	fmt.Printf("\n%-12s %6s %8s %6s\n", heading, "games", "mean", "best")
+	for _, p := range t.Periods {
+		fmt.Printf("%-12s %6d %8.1f %6d\n", p.Start.Format(dateLayout), p.Games, p.Mean, p.Best)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+)
+
+func init() {
+	commands["verify"] = command{
+		usage: "re-simulate a replay file and check its claimed result",
+		run:   runVerify,
+	}
+}
+
+// runVerify verifies a single replay file, or every stored high score with -all
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	strict := fs.Bool("rules", false, "also require the replay to use the current config's game settings at its difficulty")
+	all := fs.Bool("all", false, "re-verify every stored high score and update its verified flag")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-snake verify [-rules] <replay file>\n       go-snake verify -all")
+		fs.PrintDefaults()
+	}
+	addDirFlags(fs)
+	fs.Parse(args)
+
+	if *all {
+		return verifyAll()
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one replay file")
+	}
+
+	r, err := replay.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var rules *config.Config
+	if *strict {
+		if rules, err = config.LoadConfig(); err != nil {
+			return err
+		}
+	}
+
+	if err := replay.Verify(r, rules); err != nil {
+		return err
+	}
+
+	fmt.Printf("OK: %s scored %d with length %d over %d ticks\n", r.Player, r.Result.Score, r.Result.Length, r.Result.Ticks)
+	return nil
+}
+
+// verifyAll re-checks the stored high scores against their replays
+func verifyAll() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+
+	for i, hs := range store.GetHighScores() {
+		err := replay.VerifyHighScore(store, hs)
+		if hs.Replay != "" {
+			store.SetVerified(hs.Replay, err == nil)
+		}
+
+		status := "verified"
+		if err != nil {
+			status = err.Error()
+		}
+		fmt.Printf("%2d. %-12s %6d  %s\n", i+1, hs.Player, hs.Score, status)
+	}
+
+	return store.Save()
+}
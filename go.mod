@@ -5,6 +5,7 @@ go 1.24.2
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.8.7
 	golang.org/x/image v0.20.0
+	golang.org/x/sys v0.25.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -14,5 +15,4 @@ require (
 	github.com/ebitengine/purego v0.8.0 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
 	golang.org/x/sync v0.8.0 // indirect
-	golang.org/x/sys v0.25.0 // indirect
 )
@@ -0,0 +1,122 @@
+package achievements
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/paths"
+)
+
+// FileName is the name of the achievements file inside paths.ConfigDir,
+// which replaces the built-in list when present
+const FileName = "achievements.yaml"
+
+// Builtin is the achievements list shipped with the game
+//
+//go:embed achievements.yaml
+var Builtin []byte
+
+// Measures an achievement can count
+const (
+	MeasureLength = "length" // Longest the snake grew
+	MeasureScore  = "score"  // Points scored
+	MeasureFood   = "food"   // Food eaten, or the most eaten within Ticks
+	MeasureTicks  = "ticks"  // Ticks survived
+	MeasureGames  = "games"  // Games finished
+)
+
+// Measures lists the measures in the order they are described
+var Measures = []string{MeasureLength, MeasureScore, MeasureFood, MeasureTicks, MeasureGames}
+
+// Achievement is a goal players earn by reaching it in their games
+type Achievement struct {
+	ID          string `yaml:"id"` // Key of the progress stored in profiles
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Measure     string `yaml:"measure"`
+	Goal        int    `yaml:"goal"`
+	Ticks       int    `yaml:"ticks"`      // For food, the window it must be eaten within; 0 for the whole game
+	Cumulative  bool   `yaml:"cumulative"` // Add the measure up over all games instead of counting the best
+	Mode        string `yaml:"mode"`       // Only count games of this mode
+	Level       string `yaml:"level"`      // Only count games on this level
+	Forbid      string `yaml:"forbid"`     // "left" or "right": only count the game up to a turn that way
+}
+
+// Load reads the achievements file in the config directory, or the
+// built-in list when there is none
+func Load() ([]Achievement, error) {
+	path := filepath.Join(paths.ConfigDir(), FileName)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Parse(Builtin)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return list, nil
+}
+
+// Parse reads and checks a YAML list of achievements
+func Parse(data []byte) ([]Achievement, error) {
+	var list []Achievement
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for i, a := range list {
+		if a.ID == "" || a.Name == "" {
+			return nil, fmt.Errorf("achievement %d needs an id and a name", i+1)
+		}
+		if seen[a.ID] {
+			return nil, fmt.Errorf("achievement id %q is used twice", a.ID)
+		}
+		seen[a.ID] = true
+
+		if !validMeasure(a.Measure) {
+			return nil, fmt.Errorf("achievement %s: unknown measure %q (available: %s)", a.ID, a.Measure, strings.Join(Measures, ", "))
+		}
+		if a.Goal <= 0 {
+			return nil, fmt.Errorf("achievement %s: goal must be positive", a.ID)
+		}
+		if a.Ticks < 0 || (a.Ticks > 0 && a.Measure != MeasureFood) {
+			return nil, fmt.Errorf("achievement %s: ticks only applies to the food measure", a.ID)
+		}
+		if a.Forbid != "" && a.Forbid != "left" && a.Forbid != "right" {
+			return nil, fmt.Errorf("achievement %s: forbid must be left or right", a.ID)
+		}
+		if a.Level != "" && !game.ValidLevel(a.Level) {
+			return nil, fmt.Errorf("achievement %s: unknown level %q", a.ID, a.Level)
+		}
+		if a.Mode != "" && a.Mode != game.ModeClassic && a.Mode != game.ModeRoyale {
+			return nil, fmt.Errorf("achievement %s: unknown mode %q", a.ID, a.Mode)
+		}
+	}
+	if len(list) == 0 {
+		return nil, errors.New("no achievements defined")
+	}
+	return list, nil
+}
+
+// validMeasure reports whether m is one of Measures
+func validMeasure(m string) bool {
+	for _, known := range Measures {
+		if m == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package achievements
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+func TestMostWithin(t *testing.T) {
+	for _, tc := range []struct {
+		ticks  []int
+		window int
+		want   int
+	}{
+		{nil, 10, 0},
+		{[]int{5}, 1, 1},
+		{[]int{1, 2, 3}, 1, 1},
+		{[]int{1, 2, 3}, 3, 3},
+		{[]int{1, 2, 3}, 2, 2},
+		{[]int{0, 10, 20, 30}, 10, 1},
+		{[]int{0, 10, 20, 30}, 11, 2},
+		{[]int{0, 50, 51, 52, 53, 100, 101}, 10, 4},
+		{[]int{0, 1, 2, 3, 4, 100, 101, 102, 103, 104, 105}, 10, 6},
+	} {
+		if got := mostWithin(tc.ticks, tc.window); got != tc.want {
+			t.Errorf("mostWithin(%v, %d) = %d, want %d", tc.ticks, tc.window, got, tc.want)
+		}
+	}
+}
+
+func TestTurnWay(t *testing.T) {
+	for _, tc := range []struct {
+		from, to game.Direction
+		want     string
+	}{
+		{game.Up, game.Left, "left"},
+		{game.Left, game.Down, "left"},
+		{game.Down, game.Right, "left"},
+		{game.Right, game.Up, "left"},
+		{game.Up, game.Right, "right"},
+		{game.Right, game.Down, "right"},
+		{game.Down, game.Left, "right"},
+		{game.Left, game.Up, "right"},
+		{game.Up, game.Up, ""},
+		{game.Up, game.Down, ""},
+	} {
+		if got := turnWay(tc.from, tc.to); got != tc.want {
+			t.Errorf("turnWay(%v, %v) = %q, want %q", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse(Builtin); err != nil {
+		t.Fatalf("built-in list: %v", err)
+	}
+
+	const valid = "- {id: a, name: A, measure: food, ticks: 10, goal: 5, mode: classic, level: cross, forbid: left}\n"
+	if list, err := Parse([]byte(valid)); err != nil || len(list) != 1 || list[0].Ticks != 10 {
+		t.Errorf("valid list parsed as %+v, %v", list, err)
+	}
+
+	for _, tc := range []struct {
+		name, yaml, err string
+	}{
+		{"empty", "[]", "no achievements"},
+		{"not a list", "id: a", "yaml"},
+		{"no id", "- {name: A, measure: score, goal: 1}", "needs an id"},
+		{"no name", "- {id: a, measure: score, goal: 1}", "needs an id"},
+		{"duplicate id", "- {id: a, name: A, measure: score, goal: 1}\n- {id: a, name: B, measure: score, goal: 2}", "used twice"},
+		{"unknown measure", "- {id: a, name: A, measure: levels, goal: 1}", "unknown measure"},
+		{"zero goal", "- {id: a, name: A, measure: score, goal: 0}", "goal must be positive"},
+		{"negative ticks", "- {id: a, name: A, measure: food, ticks: -1, goal: 1}", "ticks only applies"},
+		{"ticks on another measure", "- {id: a, name: A, measure: score, ticks: 10, goal: 1}", "ticks only applies"},
+		{"bad forbid", "- {id: a, name: A, measure: score, forbid: up, goal: 1}", "forbid must be"},
+		{"unknown level", "- {id: a, name: A, measure: score, level: moon, goal: 1}", "unknown level"},
+		{"unknown mode", "- {id: a, name: A, measure: score, mode: arcade, goal: 1}", "unknown mode"},
+	} {
+		_, err := Parse([]byte(tc.yaml))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got %v, want an error mentioning %q", tc.name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package achievements
+
+import (
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// Tracker follows the events of local games, announces achievements as
+// they are reached and records the progress in the active profile when a
+// game ends. Only the first snake, the player's, is counted.
+type Tracker struct {
+	OnUnlock func(Achievement) // Called once an achievement is reached during a game
+
+	defs  []Achievement
+	store *storage.Storage
+	game  *game.Game
+
+	prior map[string]storage.AchievementProgress // Stored progress when the game began
+
+	// State of the current game
+	values    []int  // Measure of each achievement so far
+	frozen    []bool // Stopped counting after a forbidden turn
+	announced []bool
+	eaten     []int // Ticks food was eaten on
+	dead      bool
+}
+
+// NewTracker creates a tracker of the given achievements for the
+// profiles in s
+func NewTracker(defs []Achievement, s *storage.Storage) *Tracker {
+	return &Tracker{defs: defs, store: s}
+}
+
+// Attach follows g's events, keeping any OnEvent hook already set
+func (t *Tracker) Attach(g *game.Game) {
+	t.game = g
+	t.reset()
+
+	prev := g.OnEvent
+	g.OnEvent = func(e game.Event) {
+		if prev != nil {
+			prev(e)
+		}
+		t.handle(e)
+	}
+}
+
+// reset starts counting a new game
+func (t *Tracker) reset() {
+	t.prior = t.store.ActiveProfile().Achievements
+	t.values = make([]int, len(t.defs))
+	t.frozen = make([]bool, len(t.defs))
+	t.announced = make([]bool, len(t.defs))
+	t.eaten = nil
+	t.dead = false
+}
+
+// handle updates the measures with an event
+func (t *Tracker) handle(e game.Event) {
+	switch e.Kind {
+	case game.EventStart:
+		// The profile may have changed since the last game
+		t.reset()
+		return
+	case game.EventTurn:
+		if e.Snake == 0 {
+			t.freeze(e.From, e.To)
+		}
+	case game.EventEat:
+		if e.Snake == 0 {
+			t.eaten = append(t.eaten, e.Tick)
+		}
+	case game.EventDeath:
+		if e.Snake == 0 && !t.dead {
+			t.update(false)
+			t.dead = true
+		}
+	}
+
+	if e.Kind == game.EventGameOver {
+		t.update(true)
+		t.record()
+		return
+	}
+	if !t.dead {
+		t.update(false)
+	}
+}
+
+// freeze stops the achievements that forbid a turn from from to to
+func (t *Tracker) freeze(from, to game.Direction) {
+	way := turnWay(from, to)
+	for i, a := range t.defs {
+		if a.Forbid != "" && a.Forbid == way {
+			t.frozen[i] = true
+		}
+	}
+}
+
+// turnWay tells whether a change of direction is a left or right turn,
+// from the snake's point of view
+func turnWay(from, to game.Direction) string {
+	left := map[game.Direction]game.Direction{
+		game.Up:    game.Left,
+		game.Left:  game.Down,
+		game.Down:  game.Right,
+		game.Right: game.Up,
+	}
+	switch {
+	case left[from] == to:
+		return "left"
+	case left[to] == from:
+		return "right"
+	}
+	return ""
+}
+
+// update raises each achievement's measure to the game's current state
+// and announces the ones reached. over is set when the game has ended.
+func (t *Tracker) update(over bool) {
+	g := t.game
+	if len(g.Snakes) == 0 {
+		return
+	}
+	s := g.Snakes[0]
+
+	for i, a := range t.defs {
+		if t.frozen[i] || !t.counts(a) {
+			continue
+		}
+
+		v := 0
+		switch a.Measure {
+		case MeasureLength:
+			v = len(s.Body)
+		case MeasureScore:
+			v = s.Score
+		case MeasureFood:
+			v = len(t.eaten)
+			if a.Ticks > 0 {
+				v = mostWithin(t.eaten, a.Ticks)
+			}
+		case MeasureTicks:
+			if !t.dead {
+				v = g.Tick
+			}
+		case MeasureGames:
+			if over {
+				v = 1
+			}
+		}
+		if v > t.values[i] {
+			t.values[i] = v
+		}
+
+		if t.announced[i] || t.prior[a.ID].Done() {
+			continue
+		}
+		total := t.values[i]
+		if a.Cumulative {
+			total += t.prior[a.ID].Progress
+		}
+		if total >= a.Goal {
+			t.announced[i] = true
+			if t.OnUnlock != nil {
+				t.OnUnlock(a)
+			}
+		}
+	}
+}
+
+// counts reports whether the current game's mode and level count toward a
+func (t *Tracker) counts(a Achievement) bool {
+	if a.Mode != "" && a.Mode != t.game.Mode {
+		return false
+	}
+	if a.Level != "" {
+		level := t.game.Level
+		if level == "" {
+			level = game.LevelOpen
+		}
+		if a.Level != level {
+			return false
+		}
+	}
+	return true
+}
+
+// mostWithin returns the most of the sorted ticks that fall within a
+// window of the given number of ticks
+func mostWithin(ticks []int, window int) int {
+	most, start := 0, 0
+	for end := range ticks {
+		for ticks[end]-ticks[start] >= window {
+			start++
+		}
+		if n := end - start + 1; n > most {
+			most = n
+		}
+	}
+	return most
+}
+
+// record adds the finished game to the active profile's progress
+func (t *Tracker) record() {
+	values := append([]int(nil), t.values...)
+	now := time.Now()
+
+	t.store.UpdateAchievements(t.store.ActiveProfile().Name, func(progress map[string]storage.AchievementProgress) {
+		for i, a := range t.defs {
+			if values[i] == 0 {
+				continue
+			}
+			p := progress[a.ID]
+			if a.Cumulative {
+				p.Progress += values[i]
+			} else if values[i] > p.Progress {
+				p.Progress = values[i]
+			}
+			if !p.Done() && p.Progress >= a.Goal {
+				p.Unlocked = now
+			}
+			progress[a.ID] = p
+		}
+	})
+}
+
+// Fraction returns how much of the goal progress p covers, from 0 to 1
+func (a Achievement) Fraction(p storage.AchievementProgress) float64 {
+	if p.Done() || p.Progress >= a.Goal {
+		return 1
+	}
+	return float64(p.Progress) / float64(a.Goal)
+}
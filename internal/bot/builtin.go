@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// Builtin is the bot shipped with go-snake. It heads for the nearest food
+// while avoiding walls, bodies, hazards, dead ends and head-on fights it
+// would lose.
+type Builtin struct{}
+
+// moves lists the candidate moves with their offsets in Battlesnake
+// coordinates, where up increases Y
+var moves = []struct {
+	dir    game.Direction
+	dx, dy int
+}{
+	{game.Up, 0, 1},
+	{game.Right, 1, 0},
+	{game.Down, 0, -1},
+	{game.Left, -1, 0},
+}
+
+// Start implements Controller
+func (Builtin) Start(ctx context.Context, st *State) error {
+	return nil
+}
+
+// End implements Controller
+func (Builtin) End(ctx context.Context, st *State) error {
+	return nil
+}
+
+// Move implements Controller by scoring every move and picking the best
+func (Builtin) Move(ctx context.Context, st *State) (game.Direction, error) {
+	w, h := st.Board.Width, st.Board.Height
+	blocked := make(map[Coord]bool)
+	for _, s := range st.Board.Snakes {
+		for _, c := range s.Body {
+			blocked[c] = true
+		}
+	}
+	for _, c := range st.Board.Walls {
+		blocked[c] = true
+	}
+	hazards := make(map[Coord]bool, len(st.Board.Hazards))
+	for _, c := range st.Board.Hazards {
+		hazards[c] = true
+	}
+
+	// Cells a snake at least as long as us could move into next tick
+	contested := make(map[Coord]bool)
+	for _, s := range st.Board.Snakes {
+		if s.ID == st.You.ID || s.Length < st.You.Length {
+			continue
+		}
+		for _, m := range moves {
+			contested[Coord{X: s.Head.X + m.dx, Y: s.Head.Y + m.dy}] = true
+		}
+	}
+
+	best, bestScore := game.Up, -1<<31
+	for _, m := range moves {
+		next := Coord{X: st.You.Head.X + m.dx, Y: st.You.Head.Y + m.dy}
+		if next.X < 0 || next.X >= w || next.Y < 0 || next.Y >= h || blocked[next] {
+			continue
+		}
+
+		score := -nearest(next, st.Board.Food)
+		if space := flood(next, blocked, w, h, 2*st.You.Length); space < st.You.Length {
+			score -= 10000 - space
+		}
+		if contested[next] {
+			score -= 1000
+		}
+		if hazards[next] {
+			score -= 100
+		}
+
+		if score > bestScore {
+			best, bestScore = m.dir, score
+		}
+	}
+
+	return best, nil
+}
+
+// nearest returns the Manhattan distance to the closest food
+func nearest(from Coord, food []Coord) int {
+	best := 0
+	for i, f := range food {
+		d := abs(f.X-from.X) + abs(f.Y-from.Y)
+		if i == 0 || d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// flood counts the free cells reachable from start, up to limit
+func flood(start Coord, blocked map[Coord]bool, w, h, limit int) int {
+	seen := map[Coord]bool{start: true}
+	queue := []Coord{start}
+	for len(queue) > 0 && len(seen) < limit {
+		c := queue[0]
+		queue = queue[1:]
+		for _, m := range moves {
+			n := Coord{X: c.X + m.dx, Y: c.Y + m.dy}
+			if n.X < 0 || n.X >= w || n.Y < 0 || n.Y >= h || blocked[n] || seen[n] {
+				continue
+			}
+			seen[n] = true
+			queue = append(queue, n)
+		}
+	}
+	return len(seen)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,343 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// DefaultTimeout is how long a bot has to choose a move
+const DefaultTimeout = 500 * time.Millisecond
+
+// Controller steers one snake. Start and End bracket a game; Move is asked
+// once per tick while the snake is alive. A Move error wrapping ErrForfeit
+// removes the snake from the game, any other error falls back to a safe
+// move.
+type Controller interface {
+	Start(ctx context.Context, st *State) error
+	Move(ctx context.Context, st *State) (game.Direction, error)
+	End(ctx context.Context, st *State) error
+}
+
+// Pilot lets controllers steer snakes in a game, falling back to a safe
+// move when a controller errors or runs out of time
+type Pilot struct {
+	Controllers map[int]Controller // Keyed by snake index
+	Timeout     time.Duration      // Time each controller has per call
+	Logf        func(format string, args ...interface{})
+
+	started  bool
+	realTime bool          // Whether the game runs on a clock rather than waiting for moves
+	calls    map[int]*call // Latest call to each controller, in real-time games
+}
+
+// call is a request to a controller running in the background. Calls to
+// one controller run one at a time, in the order they were made.
+type call struct {
+	tick int  // Tick of the state the controller was shown
+	move bool // Whether this asked for a move, rather than starting or ending
+	dir  game.Direction
+	err  error
+	done chan struct{} // Closed once dir and err are set
+}
+
+// NewPilot creates a pilot for the given controllers
+func NewPilot(controllers map[int]Controller) *Pilot {
+	return &Pilot{
+		Controllers: controllers,
+		Timeout:     DefaultTimeout,
+		Logf:        log.Printf,
+	}
+}
+
+// Attach lets the pilot steer g as it runs, starting the controllers on
+// the first tick of every game and ending them when it finishes. The game
+// never waits for a controller: each is asked for its move a tick ahead,
+// and snakes whose controller has not answered in time make a safe move.
+func (p *Pilot) Attach(g *game.Game) {
+	p.realTime = true
+	g.BeforeTick = p.Steer
+
+	onTick := g.OnTick
+	g.OnTick = func(g *game.Game) {
+		if onTick != nil {
+			onTick(g)
+		}
+		if g.IsGameOver() {
+			p.End(g)
+		}
+	}
+}
+
+// Run plays g to the end without a front-end, asking every controller
+// for each move. It stops early after maxTicks when that is positive.
+func (p *Pilot) Run(g *game.Game, maxTicks int) {
+	for !g.IsGameOver() && (maxTicks <= 0 || g.Tick < maxTicks) {
+		p.Steer(g)
+		g.Step()
+	}
+	p.End(g)
+}
+
+// Steer asks the controllers of the living snakes for their next move and
+// applies them. Controllers are asked concurrently. In real-time games the
+// moves applied are the ones asked for on the previous tick.
+func (p *Pilot) Steer(g *game.Game) {
+	if p.realTime {
+		p.steerAhead(g)
+		return
+	}
+	if !p.started {
+		p.Start(g)
+	}
+
+	// Build every request before any controller runs, since they only
+	// see the game through their state
+	timeout := p.timeout(g)
+	states := make(map[int]*State)
+	for i := range p.Controllers {
+		if i < len(g.Snakes) && g.Snakes[i].Alive {
+			states[i] = NewState(g, i, timeout)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	moves := make(map[int]game.Direction)
+	forfeits := make(map[int]bool)
+	for i, st := range states {
+		wg.Add(1)
+		go func(i int, st *State) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			dir, err := p.Controllers[i].Move(ctx, st)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, ErrForfeit):
+				p.Logf("Bot for snake %d forfeits: %v", i, err)
+				forfeits[i] = true
+			case err != nil:
+				p.Logf("Bot for snake %d failed to move, using fallback: %v", i, err)
+				moves[i] = SafeMove(g, i)
+			default:
+				moves[i] = dir
+			}
+		}(i, st)
+	}
+	wg.Wait()
+
+	for i, dir := range moves {
+		g.ChangeSnakeDirection(i, dir)
+	}
+	for i := range forfeits {
+		g.Forfeit(i)
+	}
+}
+
+// steerAhead applies the moves the controllers chose from the previous
+// tick's state, and asks them for the next move without waiting for the
+// answer
+func (p *Pilot) steerAhead(g *game.Game) {
+	if !p.started {
+		p.Start(g)
+	}
+
+	timeout := p.timeout(g)
+	for i, c := range p.Controllers {
+		if i >= len(g.Snakes) || !g.Snakes[i].Alive {
+			continue
+		}
+
+		last := p.calls[i]
+		if last != nil {
+			select {
+			case <-last.done:
+			default:
+				// Still busy with an earlier call, so it cannot be asked again
+				g.ChangeSnakeDirection(i, SafeMove(g, i))
+				continue
+			}
+		}
+
+		switch {
+		case last == nil || !last.move || last.tick != g.Tick-1:
+			// Nothing was asked for this tick, e.g. the game just started
+			g.ChangeSnakeDirection(i, SafeMove(g, i))
+		case errors.Is(last.err, ErrForfeit):
+			p.Logf("Bot for snake %d forfeits: %v", i, last.err)
+			g.Forfeit(i)
+			continue
+		case last.err != nil:
+			p.Logf("Bot for snake %d failed to move, using fallback: %v", i, last.err)
+			g.ChangeSnakeDirection(i, SafeMove(g, i))
+		case !safeStep(g, i, last.dir):
+			// The move was chosen a tick ago and no longer fits the board
+			g.ChangeSnakeDirection(i, SafeMove(g, i))
+		default:
+			g.ChangeSnakeDirection(i, last.dir)
+		}
+
+		c, st := c, NewState(g, i, timeout)
+		p.callAhead(i, g.Tick, timeout, true, func(ctx context.Context) (game.Direction, error) {
+			return c.Move(ctx, st)
+		})
+	}
+}
+
+// callAhead calls controller i in the background once its previous call
+// is over. The deadline counts from now, not from when the call starts.
+func (p *Pilot) callAhead(i, tick int, timeout time.Duration, move bool, fn func(ctx context.Context) (game.Direction, error)) {
+	if p.calls == nil {
+		p.calls = make(map[int]*call)
+	}
+	prev := p.calls[i]
+	c := &call{tick: tick, move: move, done: make(chan struct{})}
+	p.calls[i] = c
+
+	deadline := time.Now().Add(timeout)
+	go func() {
+		defer close(c.done)
+		if prev != nil {
+			<-prev.done
+		}
+
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+		c.dir, c.err = fn(ctx)
+		if !move && c.err != nil {
+			p.Logf("Bot for snake %d: %v", i, c.err)
+		}
+	}()
+}
+
+// Start tells every controller that a game begins. Real-time games do not
+// wait for the controllers to be ready.
+func (p *Pilot) Start(g *game.Game) {
+	p.started = true
+	p.each(g, func(ctx context.Context, c Controller, st *State) error {
+		return c.Start(ctx, st)
+	})
+}
+
+// End tells every controller that the game is over. Real-time games do
+// not wait for the controllers to finish.
+func (p *Pilot) End(g *game.Game) {
+	if !p.started {
+		return
+	}
+	p.started = false
+	p.each(g, func(ctx context.Context, c Controller, st *State) error {
+		return c.End(ctx, st)
+	})
+}
+
+// each calls fn for every controller concurrently and waits for them, or
+// in real-time games queues the calls behind any still running
+func (p *Pilot) each(g *game.Game, fn func(ctx context.Context, c Controller, st *State) error) {
+	timeout := p.timeout(g)
+
+	if p.realTime {
+		for i, c := range p.Controllers {
+			if i >= len(g.Snakes) {
+				continue
+			}
+			c, st := c, NewState(g, i, timeout)
+			p.callAhead(i, g.Tick, timeout, false, func(ctx context.Context) (game.Direction, error) {
+				return 0, fn(ctx, c, st)
+			})
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range p.Controllers {
+		if i >= len(g.Snakes) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, c Controller, st *State) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := fn(ctx, c, st); err != nil {
+				p.Logf("Bot for snake %d: %v", i, err)
+			}
+		}(i, c, NewState(g, i, timeout))
+	}
+	wg.Wait()
+}
+
+// timeout returns the time controllers get per call. Real-time games
+// cannot wait longer than a tick.
+func (p *Pilot) timeout(g *game.Game) time.Duration {
+	if p.realTime && g.TickInterval() < p.Timeout {
+		return g.TickInterval()
+	}
+	return p.Timeout
+}
+
+// SafeMove picks a move for snake i that does not end its run on the next
+// tick, keeping its current direction when that is safe
+func SafeMove(g *game.Game, i int) game.Direction {
+	s := g.Snakes[i]
+	candidates := []game.Direction{s.Direction, game.Up, game.Right, game.Down, game.Left}
+	for _, dir := range candidates {
+		if safeStep(g, i, dir) {
+			return dir
+		}
+	}
+	return s.Direction
+}
+
+// safeStep reports whether snake i can move in dir without ending its run
+// on the next tick
+func safeStep(g *game.Game, i int, dir game.Direction) bool {
+	s := g.Snakes[i]
+	if dir == s.Direction.Opposite() {
+		return false
+	}
+
+	next := s.Head()
+	switch dir {
+	case game.Up:
+		next.Y--
+	case game.Down:
+		next.Y++
+	case game.Left:
+		next.X--
+	case game.Right:
+		next.X++
+	}
+
+	return next.X >= 0 && next.X < g.Grid && next.Y >= 0 && next.Y < g.Grid && !g.Occupied(next) && !g.IsWall(next)
+}
+
+// Open creates a controller from a bot spec: "builtin" for the bot shipped
+// with go-snake, an http(s) URL for a Battlesnake-compatible bot, or else
+// a command line for a process bot
+func Open(spec string) (Controller, error) {
+	if spec == "builtin" {
+		return Builtin{}, nil
+	}
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		return NewHTTPBot(spec), nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, errors.New("empty bot command")
+	}
+	return NewProcessBot(fields[0], fields[1:]...), nil
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// fixedBot always answers the same move, after an optional delay
+type fixedBot struct {
+	dir   game.Direction
+	delay time.Duration
+}
+
+func (b fixedBot) Start(ctx context.Context, st *State) error { return nil }
+func (b fixedBot) End(ctx context.Context, st *State) error   { return nil }
+
+func (b fixedBot) Move(ctx context.Context, st *State) (game.Direction, error) {
+	select {
+	case <-time.After(b.delay):
+		return b.dir, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func TestAttachedPilotNeverWaits(t *testing.T) {
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := game.NewMultiplayerGame(cfg, 1, 3)
+	NewPilot(map[int]Controller{
+		1: fixedBot{dir: game.Up},
+		2: fixedBot{dir: game.Down, delay: time.Hour},
+	}).Attach(g)
+
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		g.Step()
+		if took := time.Since(start); took > g.TickInterval()/2 {
+			t.Fatalf("tick %d took %v waiting for bots", g.Tick, took)
+		}
+		time.Sleep(20 * time.Millisecond) // Give the quick bot time to answer
+	}
+
+	if dir := g.Snakes[1].Direction; dir != game.Up {
+		t.Errorf("quick bot's snake heading %v, want %v", dir, game.Up)
+	}
+	if dir := g.Snakes[2].Direction; dir == game.Down {
+		t.Error("slow bot's move was applied")
+	}
+}
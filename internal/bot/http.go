@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// HTTPBot is a controller that calls a bot speaking the Battlesnake
+// webhook protocol
+type HTTPBot struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPBot creates a controller for the bot served at url
+func NewHTTPBot(url string) *HTTPBot {
+	return &HTTPBot{
+		URL:    strings.TrimRight(url, "/"),
+		Client: http.DefaultClient,
+	}
+}
+
+// Info asks the bot to describe itself
+func (b *HTTPBot) Info(ctx context.Context) (Info, error) {
+	var info Info
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.URL+"/", nil)
+	if err != nil {
+		return info, err
+	}
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return info, fmt.Errorf("bot returned %s", resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	return info, err
+}
+
+// Start implements Controller
+func (b *HTTPBot) Start(ctx context.Context, st *State) error {
+	return b.post(ctx, "/start", st, nil)
+}
+
+// Move implements Controller
+func (b *HTTPBot) Move(ctx context.Context, st *State) (game.Direction, error) {
+	var resp MoveResponse
+	if err := b.post(ctx, "/move", st, &resp); err != nil {
+		return 0, err
+	}
+	return ParseMove(resp.Move)
+}
+
+// End implements Controller
+func (b *HTTPBot) End(ctx context.Context, st *State) error {
+	return b.post(ctx, "/end", st, nil)
+}
+
+// post sends the state to a webhook and decodes the reply into out
+func (b *HTTPBot) post(ctx context.Context, path string, st *State, out interface{}) error {
+	body, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,239 @@
+package bot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// Process bots speak a line-delimited protocol over stdin and stdout.
+// Each line go-snake writes is a JSON object:
+//
+//	{"type":"start","state":{...}}  once, answered by a line "ready"
+//	{"type":"move","state":{...}}   every tick, answered by a move line
+//	{"type":"end","state":{...}}    once, no answer expected
+//
+// The state is the same as the body of a Battlesnake webhook request. A
+// move line is one of up, down, left or right, or a JSON move response
+// such as {"move":"up"}. Anything the bot writes to stderr is logged.
+// A bot that crashes, misses a deadline or answers nonsense forfeits its
+// snake for the rest of the game.
+
+const (
+	// DefaultStartTimeout is how long a process bot has to start and
+	// answer the handshake, which is longer than a move to allow for
+	// interpreters and runtimes
+	DefaultStartTimeout = 2 * time.Second
+
+	exitGrace = time.Second // Time a bot has to exit after the game ends
+)
+
+// ErrForfeit is returned by controllers whose snake should leave the game
+var ErrForfeit = errors.New("bot forfeited")
+
+// ProcessBot is a controller that runs an external executable
+type ProcessBot struct {
+	Path         string
+	Args         []string
+	StartTimeout time.Duration
+	Logf         func(format string, args ...interface{})
+
+	proc *process // Running bot, nil between games or after a forfeit
+}
+
+// process is one run of a bot executable
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string   // Lines read from stdout, closed when it ends
+	done  chan struct{} // Closed when the bot is stopped
+}
+
+// processRequest is a line written to a bot
+type processRequest struct {
+	Type  string `json:"type"`
+	State *State `json:"state"`
+}
+
+// NewProcessBot creates a controller for the executable at path
+func NewProcessBot(path string, args ...string) *ProcessBot {
+	return &ProcessBot{
+		Path:         path,
+		Args:         args,
+		StartTimeout: DefaultStartTimeout,
+		Logf:         log.Printf,
+	}
+}
+
+// Start implements Controller by launching the bot and waiting for its
+// handshake
+func (b *ProcessBot) Start(ctx context.Context, st *State) error {
+	b.stop()
+
+	cmd := exec.Command(b.Path, b.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	p := &process{cmd: cmd, stdin: stdin, lines: make(chan string, 1), done: make(chan struct{})}
+	go p.readLines(stdout)
+	go b.logStderr(stderr)
+	b.proc = p
+
+	// The handshake gets its own, more generous deadline
+	ctx, cancel := context.WithTimeout(context.Background(), b.StartTimeout)
+	defer cancel()
+
+	line, err := b.request(ctx, "start", st)
+	if err != nil {
+		return err
+	}
+	if f := strings.Fields(line); len(f) == 0 || f[0] != "ready" {
+		return b.forfeit(fmt.Errorf("expected ready, got %q", line))
+	}
+	return nil
+}
+
+// Move implements Controller
+func (b *ProcessBot) Move(ctx context.Context, st *State) (game.Direction, error) {
+	line, err := b.request(ctx, "move", st)
+	if err != nil {
+		return 0, err
+	}
+
+	move := line
+	if strings.HasPrefix(line, "{") {
+		var resp MoveResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			return 0, b.forfeit(fmt.Errorf("bad move response: %v", err))
+		}
+		move = resp.Move
+	}
+
+	dir, err := ParseMove(strings.ToLower(strings.TrimSpace(move)))
+	if err != nil {
+		return 0, b.forfeit(err)
+	}
+	return dir, nil
+}
+
+// End implements Controller by telling the bot the result and waiting for
+// it to exit
+func (b *ProcessBot) End(ctx context.Context, st *State) error {
+	p := b.proc
+	if p == nil {
+		return nil
+	}
+
+	err := b.send("end", st)
+	p.stdin.Close()
+
+	// Give the bot a moment to exit on its own
+	done := make(chan struct{})
+	go func() {
+		for range p.lines {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(exitGrace):
+	}
+
+	b.stop()
+	return err
+}
+
+// request sends a request and waits for the answer line
+func (b *ProcessBot) request(ctx context.Context, kind string, st *State) (string, error) {
+	p := b.proc
+	if p == nil {
+		return "", ErrForfeit
+	}
+
+	if err := b.send(kind, st); err != nil {
+		return "", b.forfeit(err)
+	}
+
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			return "", b.forfeit(errors.New("bot exited"))
+		}
+		return strings.TrimSpace(line), nil
+	case <-ctx.Done():
+		return "", b.forfeit(fmt.Errorf("no answer to %s in time", kind))
+	}
+}
+
+// send writes one request line to the bot
+func (b *ProcessBot) send(kind string, st *State) error {
+	data, err := json.Marshal(processRequest{Type: kind, State: st})
+	if err != nil {
+		return err
+	}
+	_, err = b.proc.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// forfeit stops the bot and wraps the reason in ErrForfeit
+func (b *ProcessBot) forfeit(reason error) error {
+	b.stop()
+	return fmt.Errorf("%w: %v", ErrForfeit, reason)
+}
+
+// stop kills the running bot, if any
+func (b *ProcessBot) stop() {
+	p := b.proc
+	if p == nil {
+		return
+	}
+	b.proc = nil
+
+	close(p.done)
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+}
+
+// readLines forwards the bot's stdout line by line
+func (p *process) readLines(r io.Reader) {
+	defer close(p.lines)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		select {
+		case p.lines <- sc.Text():
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// logStderr logs the bot's debug output
+func (b *ProcessBot) logStderr(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		b.Logf("[%s] %s", b.Path, sc.Text())
+	}
+}
@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// botEnv names the behavior the test binary acts out when run as a
+// process bot
+const botEnv = "GO_SNAKE_TEST_BOT"
+
+func TestMain(m *testing.M) {
+	if behavior := os.Getenv(botEnv); behavior != "" {
+		runTestBot(behavior)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestBot speaks the process bot protocol, misbehaving as asked
+func runTestBot(behavior string) {
+	sc := bufio.NewScanner(os.Stdin)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var req processRequest
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+		switch req.Type {
+		case "start":
+			if behavior == "no-handshake" {
+				fmt.Println("hello")
+			} else {
+				fmt.Println("ready")
+			}
+		case "move":
+			switch behavior {
+			case "slow":
+				time.Sleep(time.Hour)
+			case "crash":
+				os.Exit(1)
+			case "garbage":
+				fmt.Println("sideways")
+			default:
+				fmt.Println(`{"move":"up"}`)
+			}
+		case "end":
+			return
+		}
+	}
+}
+
+func TestProcessBot(t *testing.T) {
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		behavior string
+		forfeits bool
+	}{
+		{"good", false},
+		{"no-handshake", true},
+		{"slow", true},
+		{"crash", true},
+		{"garbage", true},
+	} {
+		t.Run(tc.behavior, func(t *testing.T) {
+			t.Setenv(botEnv, tc.behavior)
+
+			g := game.NewMultiplayerGame(cfg, 1, 2)
+			b := NewProcessBot(os.Args[0])
+			b.StartTimeout = 10 * time.Second // Leave room for a loaded machine
+			b.Logf = t.Logf
+			p := NewPilot(map[int]Controller{1: b})
+			p.Timeout = 200 * time.Millisecond
+			p.Logf = t.Logf
+
+			p.Start(g)
+			proc := b.proc
+			p.Steer(g)
+			g.Step()
+
+			if alive := g.Snakes[1].Alive; alive == tc.forfeits {
+				t.Errorf("snake alive = %v after a tick, want %v", alive, !tc.forfeits)
+			}
+			if tc.forfeits {
+				if b.proc != nil {
+					t.Error("bot still attached after forfeiting")
+				}
+				if proc != nil && proc.cmd.ProcessState == nil {
+					t.Error("bot process was not stopped")
+				}
+				return
+			}
+			if dir := g.Snakes[1].Direction; dir != game.Up {
+				t.Errorf("snake heading %v, want %v", dir, game.Up)
+			}
+
+			p.End(g)
+			if b.proc != nil || proc.cmd.ProcessState == nil {
+				t.Error("bot process still running after the game ended")
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// APIVersion is the Battlesnake webhook API version spoken by go-snake
+const APIVersion = "1"
+
+// Coord is a board position. Battlesnake puts (0, 0) in the bottom-left
+// corner, so Y runs opposite to go-snake's grid.
+type Coord struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Ruleset names the rules a game is played under
+type Ruleset struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// GameInfo describes the game a request belongs to
+type GameInfo struct {
+	ID      string  `json:"id"`
+	Ruleset Ruleset `json:"ruleset"`
+	Map     string  `json:"map"`
+	Timeout int     `json:"timeout"` // Milliseconds a bot has to answer
+	Source  string  `json:"source"`
+}
+
+// Battlesnake is one snake on the board
+type Battlesnake struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Health  int     `json:"health"`
+	Body    []Coord `json:"body"`
+	Latency string  `json:"latency"`
+	Head    Coord   `json:"head"`
+	Length  int     `json:"length"`
+	Shout   string  `json:"shout"`
+}
+
+// Board is the board as a bot sees it
+type Board struct {
+	Height  int           `json:"height"`
+	Width   int           `json:"width"`
+	Food    []Coord       `json:"food"`
+	Hazards []Coord       `json:"hazards"`
+	Snakes  []Battlesnake `json:"snakes"`
+
+	// Walls is a go-snake extension listing the level's wall cells, which
+	// kill like the board's edge. Standard Battlesnake bots ignore it.
+	Walls []Coord `json:"walls,omitempty"`
+}
+
+// State is the body of every /start, /move and /end request
+type State struct {
+	Game  GameInfo    `json:"game"`
+	Turn  int         `json:"turn"`
+	Board Board       `json:"board"`
+	You   Battlesnake `json:"you"`
+}
+
+// MoveResponse is a bot's answer to /move
+type MoveResponse struct {
+	Move  string `json:"move"`
+	Shout string `json:"shout,omitempty"`
+}
+
+// Info is a bot's answer to GET /
+type Info struct {
+	APIVersion string `json:"apiversion"`
+	Author     string `json:"author,omitempty"`
+	Color      string `json:"color,omitempty"`
+	Head       string `json:"head,omitempty"`
+	Tail       string `json:"tail,omitempty"`
+	Version    string `json:"version,omitempty"`
+}
+
+// NewState describes the game from the point of view of snake you
+func NewState(g *game.Game, you int, timeout time.Duration) *State {
+	st := &State{
+		Game: GameInfo{
+			ID:      strconv.FormatInt(g.Seed, 10),
+			Ruleset: Ruleset{Name: ruleset(g.Mode), Version: "go-snake"},
+			Map:     "standard",
+			Timeout: int(timeout / time.Millisecond),
+			Source:  "custom",
+		},
+		Turn: g.Tick,
+		Board: Board{
+			Height:  g.Grid,
+			Width:   g.Grid,
+			Food:    make([]Coord, len(g.Food)),
+			Hazards: []Coord{},
+			Snakes:  []Battlesnake{},
+		},
+	}
+
+	for i, f := range g.Food {
+		st.Board.Food[i] = toCoord(f, g.Grid)
+	}
+	for _, w := range g.Walls {
+		st.Board.Walls = append(st.Board.Walls, toCoord(w, g.Grid))
+	}
+	if len(g.Walls) > 0 {
+		st.Game.Map = g.Level
+	}
+	if g.Hazard > 0 {
+		for y := 0; y < g.Grid; y++ {
+			for x := 0; x < g.Grid; x++ {
+				if p := (game.Point2D{X: x, Y: y}); g.InHazard(p) {
+					st.Board.Hazards = append(st.Board.Hazards, toCoord(p, g.Grid))
+				}
+			}
+		}
+	}
+
+	for i, s := range g.Snakes {
+		snake := toBattlesnake(s, g.Grid)
+		if i == you {
+			st.You = snake
+		}
+		if s.Alive {
+			st.Board.Snakes = append(st.Board.Snakes, snake)
+		}
+	}
+	return st
+}
+
+// toBattlesnake converts a snake to its wire form
+func toBattlesnake(s *game.Snake, grid int) Battlesnake {
+	body := make([]Coord, len(s.Body))
+	for i, p := range s.Body {
+		body[i] = toCoord(p, grid)
+	}
+
+	name := s.Name
+	if name == "" {
+		name = fmt.Sprintf("Snake %d", s.ID+1)
+	}
+
+	health := s.Health
+	if !s.Alive {
+		health = 0
+	}
+
+	return Battlesnake{
+		ID:      snakeID(s.ID),
+		Name:    name,
+		Health:  health,
+		Body:    body,
+		Latency: "0",
+		Head:    body[0],
+		Length:  len(body),
+	}
+}
+
+// ruleset names a game mode the way Battlesnake does
+func ruleset(mode string) string {
+	if mode == game.ModeRoyale {
+		return "royale"
+	}
+	return "standard"
+}
+
+// snakeID names snake i on the wire
+func snakeID(i int) string {
+	return "snake-" + strconv.Itoa(i)
+}
+
+// toCoord flips a grid position into Battlesnake coordinates
+func toCoord(p game.Point2D, grid int) Coord {
+	return Coord{X: p.X, Y: grid - 1 - p.Y}
+}
+
+// ParseMove converts a move name to a direction
+func ParseMove(move string) (game.Direction, error) {
+	switch move {
+	case "up":
+		return game.Up, nil
+	case "down":
+		return game.Down, nil
+	case "left":
+		return game.Left, nil
+	case "right":
+		return game.Right, nil
+	}
+	return 0, fmt.Errorf("unknown move %q", move)
+}
+
+// MoveName converts a direction to its move name
+func MoveName(dir game.Direction) string {
+	switch dir {
+	case game.Up:
+		return "up"
+	case game.Down:
+		return "down"
+	case game.Left:
+		return "left"
+	default:
+		return "right"
+	}
+}
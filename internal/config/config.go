@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/C0d3-5t3w/go-snake/internal/paths"
+	defaults "github.com/C0d3-5t3w/go-snake/pkg/config"
 )
 
 // Config represents the game configuration
@@ -17,8 +20,22 @@ type Config struct {
 		SpeedIncrement float64 `yaml:"speed_increment"`
 		MaxSpeed       float64 `yaml:"max_speed"`
 		InitialLength  int     `yaml:"initial_length"`
+		Mode           string  `yaml:"mode"`  // "classic" or "royale"
+		Level          string  `yaml:"level"` // Wall layout: open, box, cross or pillars
 	} `yaml:"game"`
 
+	// Royale holds the rules of the large-arena mode
+	Royale struct {
+		GridSize       int `yaml:"grid_size"`
+		Snakes         int `yaml:"snakes"`          // Snakes in a local match, 8 to 16
+		Food           int `yaml:"food"`            // Food kept on the board
+		MaxHealth      int `yaml:"max_health"`      // Health after eating
+		Hunger         int `yaml:"hunger"`          // Health lost every tick
+		HazardDamage   int `yaml:"hazard_damage"`   // Extra health lost per tick in a hazard
+		HazardDelay    int `yaml:"hazard_delay"`    // Ticks before the hazard appears
+		HazardInterval int `yaml:"hazard_interval"` // Ticks between the hazard spreading inward
+	} `yaml:"royale"`
+
 	Graphics struct {
 		WindowWidth  int  `yaml:"window_width"`
 		WindowHeight int  `yaml:"window_height"`
@@ -42,12 +59,36 @@ type Config struct {
 		Grid       [3]float32 `yaml:"grid"`
 		Background [3]float32 `yaml:"background"`
 	} `yaml:"colors"`
+
+	Storage struct {
+		Backend string `yaml:"backend"` // json, journal or memory
+	} `yaml:"storage"`
+
+	Scores struct {
+		Top int `yaml:"top"` // High scores kept for each ruleset
+	} `yaml:"scores"`
+
+	Leaderboard struct {
+		URL    string `yaml:"url"`    // Shared leaderboard server, empty to disable
+		Player string `yaml:"player"` // Name scores are submitted under
+	} `yaml:"leaderboard"`
+}
+
+// FileName is the name of the config file inside paths.ConfigDir
+const FileName = "config.yaml"
+
+// Path returns the location of the config file
+func Path() string {
+	return filepath.Join(paths.ConfigDir(), FileName)
 }
 
 // LoadConfig loads configuration from the config file
 func LoadConfig() (*Config, error) {
 	// Find the config file
-	configPath := findConfigPath()
+	configPath, err := ensureConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	// Read the config file
 	data, err := ioutil.ReadFile(configPath)
@@ -55,32 +96,44 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	// Parse the config
-	var cfg Config
-	err = yaml.Unmarshal(data, &cfg)
+	// Parse the config over the defaults, so sections missing from files
+	// written by older versions keep their default values
+	cfg, err := Default()
 	if err != nil {
 		return nil, err
 	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
 
+// Default returns the configuration shipped with the game
+func Default() (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(defaults.Default, &cfg); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
-// findConfigPath locates the config.yaml file
-func findConfigPath() string {
-	// Try different common locations
-	locations := []string{
-		filepath.Join("pkg", "config", "config.yaml"),
-		filepath.Join("..", "pkg", "config", "config.yaml"),
-		filepath.Join("..", "..", "pkg", "config", "config.yaml"),
+// ensureConfig makes sure the config file exists, copying it from where
+// older versions kept it or writing the defaults, and returns its path
+func ensureConfig() (string, error) {
+	configPath := Path()
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		return configPath, nil
 	}
 
-	for _, loc := range locations {
-		if _, err := os.Stat(loc); err == nil {
-			return loc
-		}
+	if legacy := paths.FindLegacy(paths.LegacyConfigDirs, FileName); legacy != "" {
+		log.Printf("Copying config from %s to %s", legacy, configPath)
+		return configPath, paths.Copy(legacy, configPath)
 	}
 
-	// Default location if not found
-	log.Println("Config file not found in standard locations, using default path")
-	return filepath.Join("pkg", "config", "config.yaml")
+	log.Printf("Writing default config to %s", configPath)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return "", err
+	}
+	return configPath, ioutil.WriteFile(configPath, defaults.Default, 0644)
 }
@@ -0,0 +1,214 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+)
+
+const hudHeight = 16 // Height of the score strip above the board
+
+// Options controls how frames are rendered and written
+type Options struct {
+	Scale int  // Pixels per grid cell
+	FPS   int  // Frames per second of the animation
+	From  int  // First tick to include
+	To    int  // Last tick to include, or 0 for the end of the game
+	Grid  bool // Draw grid lines
+	HUD   bool // Draw the score strip
+
+	MaxFrames int // Most frames kept for a GIF, or 0 for no limit
+}
+
+// DefaultOptions returns the options used when none are given
+func DefaultOptions() Options {
+	return Options{
+		Scale: 10,
+		FPS:   10,
+		Grid:  true,
+		HUD:   true,
+	}
+}
+
+// Exporter captures game ticks as paletted frames, kept in memory for a
+// GIF or written out as PNGs when streaming
+type Exporter struct {
+	opts    Options
+	theme   render.Theme
+	palette color.Palette
+	frames  []*image.Paletted
+	count   int
+
+	// PNG sequence frames are written to as they are captured, if any
+	dir, prefix string
+	err         error
+}
+
+// NewExporter creates an exporter drawing in a theme
+func NewExporter(theme render.Theme, opts Options) *Exporter {
+	if opts.Scale <= 0 {
+		opts.Scale = DefaultOptions().Scale
+	}
+	if opts.FPS <= 0 {
+		opts.FPS = DefaultOptions().FPS
+	}
+
+	// Background first so it is the GIF's default index
+	palette := color.Palette{
+		theme.Background,
+		blend(theme.Grid, theme.Background),
+		theme.SnakeHead,
+		theme.SnakeBody,
+		theme.Food,
+		theme.Text,
+		theme.Hazard,
+		blend(theme.Grid, theme.Hazard),
+		theme.Wall,
+	}
+	for i := range theme.Opponents {
+		head, body := theme.SnakeColors(i+1, 0)
+		palette = append(palette, head, body)
+	}
+	for i := range render.Palette {
+		head, body := render.PaletteColors(i + 1)
+		palette = append(palette, head, body)
+	}
+
+	return &Exporter{
+		opts:    opts,
+		theme:   theme,
+		palette: palette,
+	}
+}
+
+// Record re-simulates a replay and captures every tick in range
+func (e *Exporter) Record(r *replay.Replay) error {
+	p := replay.NewPlayer(r)
+	e.Capture(p.Game)
+	for (e.opts.To <= 0 || p.Game.Tick < e.opts.To) && p.Step() && e.err == nil {
+		e.Capture(p.Game)
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	if e.count == 0 {
+		return fmt.Errorf("no ticks in range %d-%d (game has %d ticks)", e.opts.From, e.opts.To, r.Result.Ticks)
+	}
+	return nil
+}
+
+// StreamPNGs makes the exporter write each frame it captures to dir as a
+// numbered PNG instead of keeping it. Call it before capturing.
+func (e *Exporter) StreamPNGs(dir, prefix string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	e.dir, e.prefix = dir, prefix
+	return nil
+}
+
+// Capture renders the game's current tick if it falls inside the trim range
+// and there is room for it. Call it after each tick of a live game to
+// record it.
+func (e *Exporter) Capture(g *game.Game) {
+	if g.Tick < e.opts.From || (e.opts.To > 0 && g.Tick > e.opts.To) || e.Full() || e.err != nil {
+		return
+	}
+
+	frame := e.render(g)
+	if e.dir == "" {
+		e.frames = append(e.frames, frame)
+	} else if e.err = writePNG(filepath.Join(e.dir, fmt.Sprintf("%s%05d.png", e.prefix, e.count)), frame); e.err != nil {
+		return
+	}
+	e.count++
+}
+
+// Full reports whether the exporter has kept as many frames as it may
+func (e *Exporter) Full() bool {
+	return e.opts.MaxFrames > 0 && len(e.frames) >= e.opts.MaxFrames
+}
+
+// Frames returns the number of captured frames
+func (e *Exporter) Frames() int {
+	return e.count
+}
+
+// WriteGIF encodes the captured frames as a looping animated GIF
+func (e *Exporter) WriteGIF(w io.Writer) error {
+	if len(e.frames) == 0 {
+		return errors.New("no frames captured")
+	}
+
+	anim := &gif.GIF{}
+	delay := 100 / e.opts.FPS // GIF delays are in hundredths of a second
+	if delay < 1 {
+		delay = 1
+	}
+	for _, frame := range e.frames {
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	// Hold the last frame so the end of the game is visible
+	anim.Delay[len(anim.Delay)-1] = 200
+
+	return gif.EncodeAll(w, anim)
+}
+
+// writePNG writes one frame to a PNG file
+func writePNG(path string, frame image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, frame); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// render draws a single frame of the board with the software renderer
+func (e *Exporter) render(g *game.Game) *image.Paletted {
+	s := e.opts.Scale
+	top := 0
+	var hud []string
+	if e.opts.HUD {
+		top = hudHeight
+		hud = []string{fmt.Sprintf("Score: %d  Tick: %d", g.Snakes[0].Score, g.Tick)}
+	}
+
+	layout := render.Layout{
+		TileSize: s,
+		OffsetY:  top,
+		Grid:     e.opts.Grid && s > 2,
+	}
+	rgba := render.Image(g, e.theme, layout, hud, g.Grid*s, g.Grid*s+top)
+
+	// Quantize to the theme palette
+	img := image.NewPaletted(rgba.Bounds(), e.palette)
+	draw.Draw(img, img.Rect, rgba, image.Point{}, draw.Src)
+	return img
+}
+
+// blend composites a translucent color over an opaque background
+func blend(fg, bg color.RGBA) color.RGBA {
+	a := uint32(fg.A)
+	mix := func(f, b uint8) uint8 {
+		return uint8((uint32(f)*a + uint32(b)*(255-a)) / 255)
+	}
+	return color.RGBA{R: mix(fg.R, bg.R), G: mix(fg.G, bg.G), B: mix(fg.B, bg.B), A: 255}
+}
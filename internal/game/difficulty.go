@@ -0,0 +1,43 @@
+package game
+
+import "github.com/C0d3-5t3w/go-snake/internal/config"
+
+// Difficulties scale how fast the snake moves, picked by name
+const (
+	DifficultyEasy   = "easy"   // Three quarters of the configured speeds
+	DifficultyMedium = "medium" // The configured speeds
+	DifficultyHard   = "hard"   // One and a half times the configured speeds
+)
+
+// Difficulties lists the difficulties from easiest to hardest
+var Difficulties = []string{DifficultyEasy, DifficultyMedium, DifficultyHard}
+
+// difficultySpeed is the factor each difficulty scales speeds by
+var difficultySpeed = map[string]float64{
+	DifficultyEasy:   0.75,
+	DifficultyMedium: 1,
+	DifficultyHard:   1.5,
+}
+
+// WithDifficulty returns a copy of cfg with the speeds scaled for a
+// difficulty. Unknown difficulties play at the configured speeds. Games
+// record the copy, so replays of them play back at the same speeds.
+func WithDifficulty(cfg *config.Config, difficulty string) *config.Config {
+	scaled := *cfg
+	if f, ok := difficultySpeed[difficulty]; ok {
+		scaled.Game.InitialSpeed *= f
+		scaled.Game.SpeedIncrement *= f
+		scaled.Game.MaxSpeed *= f
+	}
+	return &scaled
+}
+
+// SetDifficulty makes the game run at base's speeds scaled for a
+// difficulty, keeping the level it is played on, and records the
+// difficulty so the game is ranked under it
+func (g *Game) SetDifficulty(base *config.Config, difficulty string) {
+	level := g.Config.Game.Level
+	g.Config = WithDifficulty(base, difficulty)
+	g.Config.Game.Level = level
+	g.Difficulty = difficulty
+}
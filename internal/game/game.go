@@ -17,16 +17,57 @@ const (
 	Down // Renamed from Backward
 )
 
+// Opposite returns the direction pointing the other way
+func (d Direction) Opposite() Direction {
+	switch d {
+	case Left:
+		return Right
+	case Right:
+		return Left
+	case Up:
+		return Down
+	default:
+		return Up
+	}
+}
+
+// Valid reports whether d is one of the four directions
+func (d Direction) Valid() bool {
+	return d >= Left && d <= Down
+}
+
+// Game modes
+const (
+	ModeClassic = "classic" // Eat to grow, the last snake alive wins
+	ModeRoyale  = "royale"  // Many snakes, hunger and hazards closing in
+)
+
+// DefaultHealth is a snake's health in modes without health rules
+const DefaultHealth = 100
+
 // Point2D represents a position in 2D space
 type Point2D struct {
 	X, Y int
 }
 
-// Snake represents the player's snake
+// Snake represents a player's snake
 type Snake struct {
+	ID        int
+	Name      string
+	Color     int // Palette color picked in a lobby, 0 for the default
 	Body      []Point2D
 	Direction Direction
 	GrowCount int
+	Score     int
+	Alive     bool
+	Health    int // Drains in royale mode; the snake dies at zero
+
+	forfeit bool // Set by Forfeit, takes effect on the next tick
+}
+
+// Head returns the snake's head position
+func (s *Snake) Head() Point2D {
+	return s.Body[0]
 }
 
 // GameState represents the current state of the game
@@ -38,186 +79,607 @@ const (
 	GameOver
 )
 
+// Input records a direction change, or a snake leaving the game, that
+// took effect on a given tick
+type Input struct {
+	Tick      int
+	Snake     int
+	Direction Direction
+	Forfeit   bool // The snake was removed from play, e.g. its bot crashed
+}
+
+// EventKind tells what happened in an Event
+type EventKind int
+
+const (
+	EventStart    EventKind = iota // A new game began
+	EventTurn                      // A snake changed direction, From and To are set
+	EventEat                       // A snake ate food
+	EventDeath                     // A snake died or left the game
+	EventGameOver                  // The game ended
+)
+
+// Event is something that happened during a game, reported through OnEvent
+type Event struct {
+	Kind     EventKind
+	Tick     int
+	Snake    int // Snake the event is about, 0 for start and game over
+	From, To Direction
+}
+
+// GameResult summarizes a finished (or in-progress) game for one snake
+type GameResult struct {
+	Score  int       `json:"score"`
+	Length int       `json:"length"`
+	Ticks  int       `json:"ticks"`
+	State  GameState `json:"state"`
+}
+
 // Game represents the snake game
 type Game struct {
 	Config        *config.Config
-	Snake         Snake
-	Food          Point2D
+	Difficulty    string // Difficulty Config's speeds were scaled for, see SetDifficulty
+	Mode          string
+	Level         string    // Wall layout, see Levels
+	Walls         []Point2D // Wall cells of the level
+	Snakes        []*Snake
+	Food          []Point2D
 	Grid          int
-	Score         int
+	Hazard        int // Depth of the hazard ring closing in from the edges
 	State         GameState
 	Speed         float64
 	LastUpdate    time.Time
-	OnScoreChange func(int)
+	OnScoreChange func(int)   // Called with the first snake's score
+	OnTick        func(*Game) // Called after every tick and after a reset
+	BeforeTick    func(*Game) // Called before every tick, e.g. to let bots steer
+	OnEvent       func(Event) // Called for turns, food eaten, deaths and the start and end of a game
+
+	// Deterministic simulation state
+	Seed    int64
+	Tick    int
+	Inputs  []Input // Direction changes applied so far, in tick order
+	rng     *rand.Rand
+	lastDir []Direction // Direction each snake moved on the previous tick
+	cells   []bool      // Cells covered by living snakes, indexed y*Grid+x
+	walls   []bool      // Wall cells, indexed like cells
 }
 
-// NewGame creates a new game instance
+// NewGame creates a new single-player game instance
 func NewGame(cfg *config.Config) *Game {
-	rand.Seed(time.Now().UnixNano())
+	return NewGameWithSeed(cfg, time.Now().UnixNano())
+}
+
+// NewGameWithSeed creates a new single-player game instance whose food
+// placement is driven by the given seed, so the same seed and inputs
+// replay identically
+func NewGameWithSeed(cfg *config.Config, seed int64) *Game {
+	return NewMultiplayerGame(cfg, seed, 1)
+}
+
+// NewMultiplayerGame creates a game with the given number of snakes
+func NewMultiplayerGame(cfg *config.Config, seed int64, players int) *Game {
+	if players < 1 {
+		players = 1
+	}
 
 	game := &Game{
 		Config: cfg,
+		Mode:   cfg.Game.Mode,
+		Level:  cfg.Game.Level,
 		Grid:   cfg.Game.GridSize,
 		Speed:  cfg.Game.InitialSpeed,
 		State:  Paused,
+		Snakes: make([]*Snake, players),
+	}
+	if game.Mode == "" {
+		game.Mode = ModeClassic
+	}
+	if game.Mode == ModeRoyale {
+		game.Grid = cfg.Royale.GridSize
 	}
 
-	game.Reset()
+	game.ResetWithSeed(seed)
 	return game
 }
 
-// Reset resets the game to initial state
+// Reset resets the game to initial state with a fresh seed
 func (g *Game) Reset() {
-	// Create snake in the center of the grid
-	center := g.Grid / 2
-	g.Snake = Snake{
-		Body: []Point2D{
-			{X: center, Y: center},
-		},
-		Direction: Right, // Start moving right
-	}
+	g.ResetWithSeed(time.Now().UnixNano())
+}
 
-	// Grow snake to initial length
-	g.Snake.GrowCount = g.Config.Game.InitialLength - 1
+// ResetWithSeed resets the game to initial state using the given seed
+func (g *Game) ResetWithSeed(seed int64) {
+	g.Seed = seed
+	g.rng = rand.New(rand.NewSource(seed))
+	g.Tick = 0
+	g.Inputs = nil
+	g.lastDir = make([]Direction, len(g.Snakes))
+	g.Hazard = 0
+	g.buildWalls()
+
+	for i := range g.Snakes {
+		name, color := "", 0
+		if g.Snakes[i] != nil {
+			name, color = g.Snakes[i].Name, g.Snakes[i].Color
+		}
+
+		start, dir := g.startPosition(i)
+		g.Snakes[i] = &Snake{
+			ID:        i,
+			Name:      name,
+			Color:     color,
+			Body:      []Point2D{start},
+			Direction: dir,
+			GrowCount: g.Config.Game.InitialLength - 1, // Grow snake to initial length
+			Alive:     true,
+			Health:    g.maxHealth(),
+		}
+		g.lastDir[i] = dir
+	}
+	g.indexCells()
 
 	// Place food
+	g.Food = nil
 	g.PlaceFood()
 
-	// Reset score and speed
-	g.Score = 0
+	// Reset speed
 	g.Speed = g.Config.Game.InitialSpeed
 	g.State = Playing
 	g.LastUpdate = time.Now()
 
 	// Notify score change
 	if g.OnScoreChange != nil {
-		g.OnScoreChange(g.Score)
+		g.OnScoreChange(0)
+	}
+	g.emit(Event{Kind: EventStart})
+	if g.OnTick != nil {
+		g.OnTick(g)
+	}
+}
+
+// emit reports an event to OnEvent, stamped with the current tick
+func (g *Game) emit(e Event) {
+	if g.OnEvent != nil {
+		e.Tick = g.Tick
+		g.OnEvent(e)
 	}
 }
 
-// PlaceFood places food at a random position not occupied by the snake
+// Resume prepares a game assembled from saved state, such as a network
+// snapshot, to be stepped
+func (g *Game) Resume() {
+	g.lastDir = make([]Direction, len(g.Snakes))
+	for i, s := range g.Snakes {
+		g.lastDir[i] = s.Direction
+	}
+	g.buildWalls()
+	g.indexCells()
+}
+
+// startPosition picks where snake i starts and which way it faces. On
+// levels with walls the start moves to the nearest row with room ahead.
+func (g *Game) startPosition(i int) (Point2D, Direction) {
+	p, dir := g.defaultStart(i)
+	if len(g.Walls) == 0 {
+		return p, dir
+	}
+
+	for offset := 0; offset < g.Grid; offset++ {
+		for _, y := range []int{p.Y + offset, p.Y - offset} {
+			q := Point2D{X: p.X, Y: y}
+			if g.clearAhead(q, dir) && !g.startTaken(q, i) {
+				return q, dir
+			}
+		}
+	}
+	return p, dir
+}
+
+// startTaken reports whether a snake placed before snake i starts at p
+func (g *Game) startTaken(p Point2D, i int) bool {
+	for _, s := range g.Snakes[:i] {
+		if s != nil && len(s.Body) > 0 && s.Body[0] == p {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultStart is where snake i starts on an open board
+func (g *Game) defaultStart(i int) (Point2D, Direction) {
+	// A lone snake starts in the center of the grid moving right
+	n := len(g.Snakes)
+	if n == 1 {
+		center := g.Grid / 2
+		return Point2D{X: center, Y: center}, Right
+	}
+
+	// Otherwise snakes are spread over the rows, alternating sides and
+	// facing the middle
+	row := (i + 1) * g.Grid / (n + 1)
+	if i%2 == 0 {
+		return Point2D{X: g.Grid / 4, Y: row}, Right
+	}
+	return Point2D{X: g.Grid - 1 - g.Grid/4, Y: row}, Left
+}
+
+// PlaceFood tops the board up with food at random positions not occupied
+// by any snake or other food
 func (g *Game) PlaceFood() {
-	for {
+	// Games mirrored from the network have no seed, so new food only
+	// appears once the server reports it
+	if g.rng == nil {
+		return
+	}
+
+	want := g.foodCount()
+	if len(g.Food) < want {
+		// A full board has no room left, and searching it would never end
+		if free := len(g.Food) + g.freeCells(); free < want {
+			want = free
+		}
+	}
+
+	for len(g.Food) < want {
 		// Generate random position
 		food := Point2D{
-			X: rand.Intn(g.Grid),
-			Y: rand.Intn(g.Grid),
+			X: g.rng.Intn(g.Grid),
+			Y: g.rng.Intn(g.Grid),
 		}
 
-		// Check if position overlaps with snake
-		overlap := false
-		for _, part := range g.Snake.Body {
-			if part.X == food.X && part.Y == food.Y {
-				overlap = true
-				break
+		if !g.Occupied(food) && !g.IsWall(food) && g.foodAt(food) < 0 {
+			g.Food = append(g.Food, food)
+		}
+	}
+}
+
+// freeCells counts the cells free of snakes, walls and food
+func (g *Game) freeCells() int {
+	free := 0
+	for y := 0; y < g.Grid; y++ {
+		for x := 0; x < g.Grid; x++ {
+			p := Point2D{X: x, Y: y}
+			if !g.Occupied(p) && !g.IsWall(p) && g.foodAt(p) < 0 {
+				free++
 			}
 		}
+	}
+	return free
+}
 
-		if !overlap {
-			g.Food = food
-			break
+// foodAt returns the index of the food at p, or -1
+func (g *Game) foodAt(p Point2D) int {
+	for i, f := range g.Food {
+		if f == p {
+			return i
 		}
 	}
+	return -1
 }
 
-// ChangeDirection changes the snake's direction
+// foodCount returns how much food the board holds
+func (g *Game) foodCount() int {
+	if g.Mode == ModeRoyale && g.Config.Royale.Food > 0 {
+		return g.Config.Royale.Food
+	}
+	return 1
+}
+
+// maxHealth returns a snake's health after eating
+func (g *Game) maxHealth() int {
+	if g.Mode == ModeRoyale && g.Config.Royale.MaxHealth > 0 {
+		return g.Config.Royale.MaxHealth
+	}
+	return DefaultHealth
+}
+
+// InHazard reports whether p lies in the hazard ring
+func (g *Game) InHazard(p Point2D) bool {
+	d := p.X
+	for _, v := range []int{p.Y, g.Grid - 1 - p.X, g.Grid - 1 - p.Y} {
+		if v < d {
+			d = v
+		}
+	}
+	return d < g.Hazard
+}
+
+// hazardDepth returns how far the hazard has spread at the current tick
+func (g *Game) hazardDepth() int {
+	r := g.Config.Royale
+	if g.Mode != ModeRoyale || r.HazardInterval <= 0 || g.Tick < r.HazardDelay {
+		return 0
+	}
+
+	depth := 1 + (g.Tick-r.HazardDelay)/r.HazardInterval
+	if max := (g.Grid + 1) / 2; depth > max {
+		depth = max
+	}
+	return depth
+}
+
+// indexCells rebuilds the lookup of cells covered by living snakes, so
+// collision checks stay cheap with many snakes on a large board
+func (g *Game) indexCells() {
+	n := g.Grid * g.Grid
+	if len(g.cells) != n {
+		g.cells = make([]bool, n)
+	} else {
+		for i := range g.cells {
+			g.cells[i] = false
+		}
+	}
+
+	for _, s := range g.Snakes {
+		if !s.Alive {
+			continue
+		}
+		for _, part := range s.Body {
+			if part.X >= 0 && part.X < g.Grid && part.Y >= 0 && part.Y < g.Grid {
+				g.cells[part.Y*g.Grid+part.X] = true
+			}
+		}
+	}
+}
+
+// Occupied reports whether a living snake covers the position
+func (g *Game) Occupied(p Point2D) bool {
+	if len(g.cells) == g.Grid*g.Grid {
+		if p.X < 0 || p.X >= g.Grid || p.Y < 0 || p.Y >= g.Grid {
+			return false
+		}
+		return g.cells[p.Y*g.Grid+p.X]
+	}
+
+	// Games mirrored from the network have no index
+	for _, s := range g.Snakes {
+		if !s.Alive {
+			continue
+		}
+		for _, part := range s.Body {
+			if part == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ChangeDirection changes the first snake's direction
 func (g *Game) ChangeDirection(dir Direction) {
+	g.ChangeSnakeDirection(0, dir)
+}
+
+// ChangeSnakeDirection changes the direction of snake i
+func (g *Game) ChangeSnakeDirection(i int, dir Direction) {
+	if i < 0 || i >= len(g.Snakes) || !g.Snakes[i].Alive || !dir.Valid() {
+		return
+	}
+
 	// Prevent 180-degree turns
-	opposites := map[Direction]Direction{
-		Up:    Down,
-		Down:  Up,
-		Left:  Right,
-		Right: Left,
+	if dir.Opposite() != g.Snakes[i].Direction {
+		g.Snakes[i].Direction = dir
 	}
+}
 
-	if opposites[dir] != g.Snake.Direction {
-		g.Snake.Direction = dir
+// Forfeit removes snake i from play at the start of the next tick. The
+// forfeit is recorded like an input, so replays reproduce it.
+func (g *Game) Forfeit(i int) {
+	if i >= 0 && i < len(g.Snakes) && g.Snakes[i].Alive {
+		g.Snakes[i].forfeit = true
 	}
 }
 
-// Update updates the game state
+// Update advances the game by one tick once enough wall-clock time has passed
 func (g *Game) Update() bool {
 	if g.State != Playing {
 		return false
 	}
 
 	now := time.Now()
-	updateInterval := time.Duration(1000/g.Speed) * time.Millisecond
-
-	if now.Sub(g.LastUpdate) < updateInterval {
+	if now.Sub(g.LastUpdate) < g.TickInterval() {
 		return false
 	}
 
 	g.LastUpdate = now
+	return g.Step()
+}
 
-	// Save head position before moving
-	head := g.Snake.Body[0]
+// TickInterval returns the wall-clock time between ticks at the current speed
+func (g *Game) TickInterval() time.Duration {
+	return time.Duration(1000/g.Speed) * time.Millisecond
+}
 
-	// Calculate new head position
-	newHead := Point2D{X: head.X, Y: head.Y}
+// Step advances the simulation by exactly one tick, independent of time
+func (g *Game) Step() bool {
+	if g.State == GameOver {
+		return false
+	}
 
-	// Move based on direction
-	switch g.Snake.Direction {
-	case Up:
-		newHead.Y-- // Ebiten Y is down, so decrement for Up
-	case Down:
-		newHead.Y++ // Ebiten Y is down, so increment for Down
-	case Left:
-		newHead.X--
-	case Right:
-		newHead.X++
+	if g.BeforeTick != nil {
+		g.BeforeTick(g)
 	}
 
-	// Check for wall collision
-	if newHead.X < 0 || newHead.X >= g.Grid ||
-		newHead.Y < 0 || newHead.Y >= g.Grid {
-		g.State = GameOver
-		return true
+	// Record forfeits and direction changes so the game can be replayed
+	forfeited := false
+	for i, s := range g.Snakes {
+		if s.Alive && s.forfeit {
+			g.Inputs = append(g.Inputs, Input{Tick: g.Tick, Snake: i, Forfeit: true})
+			s.Alive = false
+			forfeited = true
+			g.emit(Event{Kind: EventDeath, Snake: i})
+			continue
+		}
+		if s.Alive && s.Direction != g.lastDir[i] {
+			g.Inputs = append(g.Inputs, Input{Tick: g.Tick, Snake: i, Direction: s.Direction})
+			g.emit(Event{Kind: EventTurn, Snake: i, From: g.lastDir[i], To: s.Direction})
+			g.lastDir[i] = s.Direction
+		}
 	}
+	g.Tick++
+	g.Hazard = g.hazardDepth()
 
-	// Check for self collision
-	for _, part := range g.Snake.Body {
-		if newHead.X == part.X && newHead.Y == part.Y {
-			g.State = GameOver
-			return true
+	// Forfeits free their cells before anyone moves
+	if forfeited {
+		g.indexCells()
+	}
+
+	// Calculate every new head position before moving anything
+	heads := make([]Point2D, len(g.Snakes))
+	for i, s := range g.Snakes {
+		if !s.Alive {
+			continue
 		}
+
+		// Move based on direction
+		newHead := s.Head()
+		switch s.Direction {
+		case Up:
+			newHead.Y-- // Ebiten Y is down, so decrement for Up
+		case Down:
+			newHead.Y++ // Ebiten Y is down, so increment for Down
+		case Left:
+			newHead.X--
+		case Right:
+			newHead.X++
+		}
+		heads[i] = newHead
 	}
 
-	// Check for food collision
-	ateFood := newHead.X == g.Food.X && newHead.Y == g.Food.Y
+	// Count how many snakes move into each cell, for head-on collisions
+	arrivals := make(map[Point2D]int, len(g.Snakes))
+	for i, s := range g.Snakes {
+		if s.Alive {
+			arrivals[heads[i]]++
+		}
+	}
 
-	// Add new head to the snake
-	g.Snake.Body = append([]Point2D{newHead}, g.Snake.Body...)
+	// Check collisions against the board as it was before this tick
+	dead := make([]bool, len(g.Snakes))
+	for i, s := range g.Snakes {
+		if !s.Alive {
+			continue
+		}
+		newHead := heads[i]
 
-	// If food was eaten or snake is still growing
-	if ateFood || g.Snake.GrowCount > 0 {
-		if ateFood {
-			g.Score += 10
-			g.PlaceFood()
-			g.Snake.GrowCount++
+		// Check for wall collision
+		if newHead.X < 0 || newHead.X >= g.Grid ||
+			newHead.Y < 0 || newHead.Y >= g.Grid {
+			dead[i] = true
+			continue
+		}
 
-			// Increase speed
-			if g.Speed < g.Config.Game.MaxSpeed {
-				g.Speed += g.Config.Game.SpeedIncrement
-			}
+		// Check for collision with any snake's body, including its own,
+		// and the level's walls
+		if g.Occupied(newHead) || g.IsWall(newHead) {
+			dead[i] = true
+			continue
+		}
+
+		// Check for head-on collision with another snake
+		if arrivals[newHead] > 1 {
+			dead[i] = true
+		}
+	}
+
+	ateFood := false
+	for i, s := range g.Snakes {
+		if !s.Alive {
+			continue
+		}
+		if dead[i] {
+			s.Alive = false
+			g.emit(Event{Kind: EventDeath, Snake: i})
+			continue
+		}
+
+		// Add new head to the snake
+		newHead := heads[i]
+		s.Body = append([]Point2D{newHead}, s.Body...)
+
+		// If food was eaten or snake is still growing
+		if f := g.foodAt(newHead); f >= 0 {
+			ateFood = true
+			g.Food = append(g.Food[:f], g.Food[f+1:]...)
+			s.Score += 10
+			s.GrowCount++
+			s.Health = g.maxHealth()
 
 			// Notify score change
-			if g.OnScoreChange != nil {
-				g.OnScoreChange(g.Score)
+			if i == 0 && g.OnScoreChange != nil {
+				g.OnScoreChange(s.Score)
+			}
+			g.emit(Event{Kind: EventEat, Snake: i})
+		} else if g.Mode == ModeRoyale {
+			// Hunger and hazards drain health until the snake eats
+			s.Health -= g.Config.Royale.Hunger
+			if g.InHazard(newHead) {
+				s.Health -= g.Config.Royale.HazardDamage
+			}
+			if s.Health <= 0 {
+				s.Health = 0
+				s.Alive = false
+				g.emit(Event{Kind: EventDeath, Snake: i})
 			}
 		}
 
-		if g.Snake.GrowCount > 0 {
-			g.Snake.GrowCount--
+		if s.GrowCount > 0 {
+			s.GrowCount--
+		} else {
+			// Remove tail if not growing
+			s.Body = s.Body[:len(s.Body)-1]
 		}
-	} else {
-		// Remove tail if not growing
-		g.Snake.Body = g.Snake.Body[:len(g.Snake.Body)-1]
+	}
+	g.indexCells()
+
+	if ateFood {
+		g.PlaceFood()
+
+		// Increase speed, except in royale where many snakes eat
+		if g.Mode != ModeRoyale && g.Speed < g.Config.Game.MaxSpeed {
+			g.Speed += g.Config.Game.SpeedIncrement
+		}
+	}
+
+	// A solo game ends when the snake dies, a multiplayer game when at most
+	// one snake is left
+	alive := g.AliveCount()
+	if alive == 0 || (len(g.Snakes) > 1 && alive <= 1) {
+		g.State = GameOver
+		g.emit(Event{Kind: EventGameOver})
+	}
+
+	if g.OnTick != nil {
+		g.OnTick(g)
 	}
 
 	return true
 }
 
+// AliveCount returns the number of snakes still in play
+func (g *Game) AliveCount() int {
+	n := 0
+	for _, s := range g.Snakes {
+		if s.Alive {
+			n++
+		}
+	}
+	return n
+}
+
+// Winner returns the last snake standing, or nil if there is none
+func (g *Game) Winner() *Snake {
+	if g.State != GameOver || len(g.Snakes) < 2 {
+		return nil
+	}
+	for _, s := range g.Snakes {
+		if s.Alive {
+			return s
+		}
+	}
+	return nil
+}
+
 // TogglePause toggles the pause state
 func (g *Game) TogglePause() {
 	if g.State == Playing {
@@ -232,3 +694,19 @@ func (g *Game) TogglePause() {
 func (g *Game) IsGameOver() bool {
 	return g.State == GameOver
 }
+
+// Result returns a summary of the game so far for the first snake
+func (g *Game) Result() GameResult {
+	return g.ResultFor(0)
+}
+
+// ResultFor returns a summary of the game so far for snake i
+func (g *Game) ResultFor(i int) GameResult {
+	s := g.Snakes[i]
+	return GameResult{
+		Score:  s.Score,
+		Length: len(s.Body),
+		Ticks:  g.Tick,
+		State:  g.State,
+	}
+}
@@ -0,0 +1,23 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+)
+
+func TestChangeSnakeDirectionIgnoresInvalid(t *testing.T) {
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGameWithSeed(cfg, 1)
+	want := g.Snakes[0].Direction
+
+	for _, dir := range []Direction{-1, Down + 1, 1 << 30} {
+		g.ChangeSnakeDirection(0, dir)
+		if got := g.Snakes[0].Direction; got != want {
+			t.Errorf("direction %d: snake heading %d, want %d", dir, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package game
+
+// Levels are board layouts with walls a snake dies on, picked by name
+const (
+	LevelOpen    = "open"    // No walls
+	LevelBox     = "box"     // A walled square with a gap in each side
+	LevelCross   = "cross"   // Two crossing walls with a gap in the middle
+	LevelPillars = "pillars" // Square pillars spread over the board
+)
+
+// Levels lists the available levels in the order menus show them
+var Levels = []string{LevelOpen, LevelBox, LevelCross, LevelPillars}
+
+// ValidLevel reports whether name is a known level. The empty name means
+// the open board.
+func ValidLevel(name string) bool {
+	if name == "" {
+		return true
+	}
+	for _, l := range Levels {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LevelWalls returns the wall cells of a level on a board of the given size
+func LevelWalls(level string, grid int) []Point2D {
+	var walls []Point2D
+	add := func(x, y int) {
+		if x >= 0 && x < grid && y >= 0 && y < grid {
+			walls = append(walls, Point2D{X: x, Y: y})
+		}
+	}
+
+	mid := grid / 2
+	inset := grid / 5
+	gap := grid / 10
+	if gap < 1 {
+		gap = 1
+	}
+
+	switch level {
+	case LevelBox:
+		for i := inset; i < grid-inset; i++ {
+			if i >= mid-gap && i < mid+gap {
+				continue
+			}
+			add(i, inset)
+			add(i, grid-1-inset)
+			add(inset, i)
+			add(grid-1-inset, i)
+		}
+	case LevelCross:
+		for i := inset; i < grid-inset; i++ {
+			if i >= mid-gap && i <= mid+gap {
+				continue
+			}
+			add(i, mid)
+			add(mid, i)
+		}
+	case LevelPillars:
+		step := grid / 4
+		if step < 1 {
+			step = 1
+		}
+		for py := step; py < grid-1; py += step {
+			for px := step; px < grid-1; px += step {
+				add(px-1, py-1)
+				add(px, py-1)
+				add(px-1, py)
+				add(px, py)
+			}
+		}
+	}
+	return walls
+}
+
+// buildWalls lays out the walls of the game's level
+func (g *Game) buildWalls() {
+	g.Walls = LevelWalls(g.Level, g.Grid)
+	g.walls = make([]bool, g.Grid*g.Grid)
+	for _, w := range g.Walls {
+		g.walls[w.Y*g.Grid+w.X] = true
+	}
+}
+
+// IsWall reports whether p is a wall cell of the level
+func (g *Game) IsWall(p Point2D) bool {
+	if len(g.walls) != g.Grid*g.Grid || p.X < 0 || p.X >= g.Grid || p.Y < 0 || p.Y >= g.Grid {
+		return false
+	}
+	return g.walls[p.Y*g.Grid+p.X]
+}
+
+// clearAhead reports whether a snake starting at p facing dir has room to
+// get going without running into a wall
+func (g *Game) clearAhead(p Point2D, dir Direction) bool {
+	for i := 0; i < g.Grid/4+1; i++ {
+		if p.X < 0 || p.X >= g.Grid || p.Y < 0 || p.Y >= g.Grid || g.IsWall(p) {
+			return false
+		}
+		switch dir {
+		case Left:
+			p.X--
+		case Right:
+			p.X++
+		case Up:
+			p.Y--
+		case Down:
+			p.Y++
+		}
+	}
+	return true
+}
@@ -0,0 +1,15 @@
+package game
+
+import "testing"
+
+func TestLevelWallsOnSmallBoards(t *testing.T) {
+	for _, level := range Levels {
+		for grid := 1; grid <= 12; grid++ {
+			for _, w := range LevelWalls(level, grid) {
+				if w.X < 0 || w.X >= grid || w.Y < 0 || w.Y >= grid {
+					t.Errorf("%s level on a %d board has a wall off the board at %v", level, grid, w)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/C0d3-5t3w/go-snake/internal/achievements"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+	"github.com/C0d3-5t3w/go-snake/internal/render/ebitenrender"
+)
+
+// toastDuration is how long an unlocked achievement is announced
+const toastDuration = 4 * time.Second
+
+// SetAchievements tracks the achievements in local games, announcing the
+// ones reached and listing them all after a game
+func (eg *EbitenGame) SetAchievements(defs []achievements.Achievement) {
+	eg.achievements = defs
+
+	tracker := achievements.NewTracker(defs, eg.storage)
+	tracker.OnUnlock = func(a achievements.Achievement) {
+		eg.toast = a
+		eg.toastUntil = time.Now().Add(toastDuration)
+	}
+	tracker.Attach(eg.game)
+}
+
+// updateAchievements closes the achievements screen on V or Escape
+func (eg *EbitenGame) updateAchievements() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyV) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		eg.achievementsOpen = false
+	}
+}
+
+// drawAchievements lists the achievements with a bar showing the active
+// profile's progress toward each
+func (eg *EbitenGame) drawAchievements(screen *ebiten.Image) {
+	backend := ebitenrender.New(screen)
+	p := eg.storage.ActiveProfile()
+
+	prims := []render.Primitive{
+		render.Clear{Color: eg.theme.Background},
+		render.Text{X: 20, Y: 20, Text: "Achievements of " + p.Name, Color: eg.theme.Text},
+	}
+
+	const barX, barW, barH = 20, 200, 10
+	y := 52
+	for _, a := range eg.achievements {
+		progress := p.Achievements[a.ID]
+		status := fmt.Sprintf("%d/%d", progress.Progress, a.Goal)
+		if progress.Done() {
+			status = "unlocked " + progress.Unlocked.Local().Format("2006-01-02")
+		}
+
+		fill := eg.theme.SnakeBody
+		if progress.Done() {
+			fill = eg.theme.Food
+		}
+		prims = append(prims,
+			render.Text{X: barX, Y: y, Text: a.Name + " - " + a.Description, Color: eg.theme.Text},
+			render.Rect{X: barX, Y: float32(y + 18), W: barW, H: barH, Color: eg.theme.Grid},
+			render.Rect{X: barX, Y: float32(y + 18), W: float32(a.Fraction(progress) * barW), H: barH, Color: fill},
+			render.Text{X: barX + barW + 10, Y: y + 14, Text: status, Color: eg.theme.Text},
+		)
+		y += 40
+	}
+
+	prims = append(prims, render.Text{X: 20, Y: eg.config.Graphics.WindowHeight - 30, Text: "V/Escape: Back", Color: eg.theme.Text})
+	render.Draw(backend, prims)
+}
+
+// drawToast announces the last achievement unlocked until it expires
+func (eg *EbitenGame) drawToast(screen *ebiten.Image) {
+	if eg.toast.ID == "" || time.Now().After(eg.toastUntil) {
+		return
+	}
+
+	w, h := screen.Size()
+	const boxW, boxH = 320, 44
+	x, y := float32(w-boxW)/2, float32(h-boxH-20)
+	render.Draw(ebitenrender.New(screen), []render.Primitive{
+		render.Rect{X: x, Y: y, W: boxW, H: boxH, Color: eg.theme.Grid},
+		render.Text{X: int(x) + 10, Y: int(y) + 6, Text: "Achievement unlocked: " + eg.toast.Name, Color: eg.theme.Food},
+		render.Text{X: int(x) + 10, Y: int(y) + 24, Text: eg.toast.Description, Color: eg.theme.Text},
+	})
+}
@@ -2,24 +2,32 @@ package gui
 
 import (
 	"fmt"
-	"image/color"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
-	"github.com/hajimehoshi/ebiten/v2/vector"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
 
+	"github.com/C0d3-5t3w/go-snake/internal/achievements"
 	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/export"
 	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/leaderboard"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+	"github.com/C0d3-5t3w/go-snake/internal/render/ebitenrender"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
 	"github.com/C0d3-5t3w/go-snake/internal/storage"
 )
 
 const (
-	tileSize = 20 // Size of each grid tile in pixels
+	tileSize         = 20   // Size of each grid tile in pixels
+	maxCaptureFrames = 1200 // Longest live capture, saved once reached
 )
 
 // EbitenGame holds the game state for Ebitengine
@@ -31,12 +39,53 @@ type EbitenGame struct {
 	infoFont  font.Face
 	lastFrame time.Time
 
-	// Cached images for performance
-	snakeHeadImg *ebiten.Image
-	snakeBodyImg *ebiten.Image
-	foodImg      *ebiten.Image
-	bgImg        *ebiten.Image
-	gridImg      *ebiten.Image // Optional grid image
+	self   int    // Index of the player's snake
+	notice string // Message shown under the status, such as new unlocks
+
+	// Profile menu, shown before a local game starts
+	profileMenu bool
+	profileRow  int
+	profileEdit *textInput // Name being typed, nil when not editing
+	renaming    string     // Profile being renamed, empty when creating one
+	profileErr  string
+
+	// Replay state
+	player   *replay.Player // Non-nil when watching a replay
+	archived bool           // Whether the finished game has been saved
+
+	// Live GIF capture, toggled with F12
+	capture *export.Exporter
+
+	// Hosted game state
+	remote    *netplay.Client // Non-nil when playing a hosted game
+	netDebug  bool            // Whether the network overlay is shown
+	lobbyRow  int             // Selected row of the lobby
+	lobbyName *textInput      // Name being edited in the lobby
+
+	// LAN game browser, shown until a game is joined
+	lan         *netplay.Browser
+	lanName     string // Player name used when joining
+	lanSelected int
+	lanErr      string
+
+	// Board colors, from the config or the profile's theme
+	theme render.Theme
+
+	// Settings screen and the settings it applies
+	settingsOpen bool
+	settingsRow  int
+	bindingKey   bool // Waiting for the key to bind to the selected control
+	gridLines    bool
+	keys         keyMap
+
+	// Shared leaderboard, nil when not configured
+	leaderboard *leaderboard.Syncer
+
+	// Achievements, nil when not tracked
+	achievements     []achievements.Achievement
+	achievementsOpen bool                     // Whether the achievements screen is shown
+	toast            achievements.Achievement // Last one unlocked, announced until toastUntil
+	toastUntil       time.Time
 }
 
 // NewEbitenGUI initializes the Ebiten game wrapper
@@ -50,6 +99,7 @@ func NewEbitenGUI(g *game.Game, cfg *config.Config, s *storage.Storage) (*Ebiten
 		storage:  s,
 		tileSize: tileSize,
 		infoFont: infoFont,
+		theme:    render.ThemeFromConfig(cfg),
 	}
 
 	// Initialize Ebiten window settings
@@ -60,8 +110,8 @@ func NewEbitenGUI(g *game.Game, cfg *config.Config, s *storage.Storage) (*Ebiten
 		ebiten.SetFullscreen(true)
 	}
 
-	// Pre-render images for drawing elements
-	eg.createImages()
+	// The active profile's settings override the config's
+	eg.applySettings(s.GetSettings())
 
 	// Set game callbacks (if needed, e.g., score updates)
 	g.OnScoreChange = func(score int) {
@@ -71,41 +121,65 @@ func NewEbitenGUI(g *game.Game, cfg *config.Config, s *storage.Storage) (*Ebiten
 	return eg, nil
 }
 
+// NewReplayGUI initializes the Ebiten wrapper in replay playback mode
+func NewReplayGUI(p *replay.Player, cfg *config.Config, s *storage.Storage) (*EbitenGame, error) {
+	eg, err := NewEbitenGUI(p.Game, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+
+	eg.player = p
+	ebiten.SetWindowTitle("Go Snake 2D - Replay")
+	return eg, nil
+}
+
+// SetLeaderboard submits finished games to a shared leaderboard and shows
+// its top scores next to the local ones
+func (eg *EbitenGame) SetLeaderboard(l *leaderboard.Syncer) {
+	eg.leaderboard = l
+}
+
 // Run starts the Ebitengine game loop
 func (eg *EbitenGame) Run() error {
 	return ebiten.RunGame(eg)
 }
 
-// createImages pre-renders simple images for snake, food, etc.
-func (eg *EbitenGame) createImages() {
-	s := eg.tileSize
-
-	// Snake Head (using config color)
-	hc := eg.config.Colors.SnakeHead
-	eg.snakeHeadImg = ebiten.NewImage(s, s)
-	vector.DrawFilledRect(eg.snakeHeadImg, 0, 0, float32(s), float32(s), color.RGBA{R: uint8(hc[0] * 255), G: uint8(hc[1] * 255), B: uint8(hc[2] * 255), A: 255}, false)
-
-	// Snake Body (using config color)
-	bc := eg.config.Colors.SnakeBody
-	eg.snakeBodyImg = ebiten.NewImage(s, s)
-	vector.DrawFilledRect(eg.snakeBodyImg, 0, 0, float32(s), float32(s), color.RGBA{R: uint8(bc[0] * 255), G: uint8(bc[1] * 255), B: uint8(bc[2] * 255), A: 255}, false)
+// Update proceeds the game state.
+func (eg *EbitenGame) Update() error {
+	// Replays are driven by the player instead of live input
+	if eg.player != nil {
+		eg.handleReplayInput()
+		eg.player.Update()
+		eg.game = eg.player.Game
+		return nil
+	}
 
-	// Food (using config color)
-	fc := eg.config.Colors.Food
-	eg.foodImg = ebiten.NewImage(s, s)
-	vector.DrawFilledRect(eg.foodImg, 0, 0, float32(s), float32(s), color.RGBA{R: uint8(fc[0] * 255), G: uint8(fc[1] * 255), B: uint8(fc[2] * 255), A: 255}, false)
+	// Pick a LAN game before anything else
+	if eg.lan != nil && eg.remote == nil {
+		eg.updateLAN()
+		return nil
+	}
 
-	// Background (using config color)
-	bgc := eg.config.Colors.Background
-	eg.bgImg = ebiten.NewImage(1, 1) // Create a 1x1 pixel image for the background color
-	eg.bgImg.Fill(color.RGBA{R: uint8(bgc[0] * 255), G: uint8(bgc[1] * 255), B: uint8(bgc[2] * 255), A: 255})
+	// Hosted games are simulated by the server
+	if eg.remote != nil {
+		eg.updateRemote()
+		return nil
+	}
 
-	// Grid (optional, draw lines)
-	// We can draw the grid dynamically in the Draw function instead for simplicity
-}
+	// Hold the local game until a profile is picked
+	if eg.profileMenu {
+		eg.updateProfiles()
+		return nil
+	}
+	if eg.achievementsOpen {
+		eg.updateAchievements()
+		return nil
+	}
+	if eg.settingsOpen {
+		eg.updateSettings()
+		return nil
+	}
 
-// Update proceeds the game state.
-func (eg *EbitenGame) Update() error {
 	// Handle input
 	eg.handleInput()
 
@@ -113,22 +187,136 @@ func (eg *EbitenGame) Update() error {
 	// Note: Ebiten's Update function is called 60 times per second by default.
 	// The internal game Update() has its own speed control based on LastUpdate.
 	// This is fine, the game logic will only advance when its internal timer allows.
-	eg.game.Update()
+	if eg.game.Update() && eg.capture != nil {
+		eg.capture.Capture(eg.game)
+		if eg.capture.Full() {
+			eg.toggleCapture()
+		}
+	}
+
+	// Record the finished game once
+	if eg.game.IsGameOver() && !eg.archived {
+		eg.archived = true
+		r, unlocked, err := replay.Archive(eg.storage, eg.game, eg.storage.ActiveProfile().Name)
+		if err != nil {
+			log.Printf("Failed to save replay: %v", err)
+		}
+		if len(unlocked) > 0 {
+			labels := make([]string, len(unlocked))
+			for i, u := range unlocked {
+				labels[i] = storage.UnlockLabel(u)
+			}
+			eg.notice = "Unlocked " + strings.Join(labels, ", ") + "!"
+		}
+		if r != nil && eg.leaderboard != nil {
+			if err := eg.leaderboard.Enqueue(r); err != nil {
+				log.Printf("Failed to queue leaderboard submission: %v", err)
+			}
+		}
+	}
 
 	return nil
 }
 
+// toggleCapture starts recording a GIF of the live game in the colors on
+// screen, or stops and writes it in the background
+func (eg *EbitenGame) toggleCapture() {
+	if eg.capture == nil {
+		opts := export.DefaultOptions()
+		opts.MaxFrames = maxCaptureFrames
+		eg.capture = export.NewExporter(eg.theme, opts)
+		eg.capture.Capture(eg.game)
+		return
+	}
+
+	e := eg.capture
+	eg.capture = nil
+	go saveCapture(e, eg.storage.ExportDir())
+}
+
+// saveCapture encodes a finished capture into an export directory
+func saveCapture(e *export.Exporter, dir string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("Failed to create export directory: %v", err)
+		return
+	}
+
+	path := filepath.Join(dir, time.Now().Format("20060102-150405")+".gif")
+	f, err := os.Create(path)
+	if err != nil {
+		log.Printf("Failed to save capture: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if err := e.WriteGIF(f); err != nil {
+		log.Printf("Failed to save capture: %v", err)
+		return
+	}
+	log.Printf("Saved %d frames to %s", e.Frames(), path)
+}
+
+// handleReplayInput processes playback controls
+func (eg *EbitenGame) handleReplayInput() {
+	p := eg.player
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		p.TogglePause()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		p.SpeedUp()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		p.SlowDown()
+	}
+
+	// Stepping pauses playback so single ticks can be inspected
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) {
+		p.Paused = true
+		p.Step()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+		p.Paused = true
+		p.Seek(p.Game.Tick - 1)
+	}
+
+	// Seek in larger jumps
+	if inpututil.IsKeyJustPressed(ebiten.KeyPageDown) {
+		p.Seek(p.Game.Tick + 50)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyPageUp) {
+		p.Seek(p.Game.Tick - 50)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyHome) || inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		p.Seek(0)
+	}
+}
+
 // handleInput processes user input
 func (eg *EbitenGame) handleInput() {
 	// Game controls
-	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+	if inpututil.IsKeyJustPressed(eg.keys.pause) {
 		eg.game.TogglePause()
 	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) && eg.game.State != game.Playing {
+		eg.ShowSettings()
+	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
 		if eg.game.IsGameOver() {
+			eg.applyDifficulty(eg.storage.GetSettings().Difficulty)
 			eg.game.Reset()
+			eg.archived = false
+			eg.notice = ""
 		}
 	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) && eg.game.IsGameOver() {
+		eg.ShowProfiles()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyV) && eg.game.IsGameOver() && eg.achievements != nil {
+		eg.achievementsOpen = true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF12) {
+		eg.toggleCapture()
+	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
 		// Note: Ebiten handles closing the window, maybe map this to pause or menu?
 		log.Println("Escape pressed - exiting game (Ebiten handles window close)")
@@ -137,86 +325,176 @@ func (eg *EbitenGame) handleInput() {
 
 	// Movement controls - only process if playing
 	if eg.game.State == game.Playing {
-		// Map W to Up (Y-)
-		if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
-			eg.game.ChangeDirection(game.Up)
-		}
-		// Map S to Down (Y+)
-		if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
-			eg.game.ChangeDirection(game.Down)
-		}
-		// Map A to Left (X-)
-		if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
-			eg.game.ChangeDirection(game.Left)
-		}
-		// Map D to Right (X+)
-		if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) {
-			eg.game.ChangeDirection(game.Right)
+		if dir, ok := eg.directionInput(); ok {
+			eg.game.ChangeDirection(dir)
 		}
 	}
 }
 
+// directionInput returns the direction of a just-pressed movement key
+func (eg *EbitenGame) directionInput() (game.Direction, bool) {
+	switch {
+	case inpututil.IsKeyJustPressed(eg.keys.up):
+		return game.Up, true // Y-
+	case inpututil.IsKeyJustPressed(eg.keys.down):
+		return game.Down, true // Y+
+	case inpututil.IsKeyJustPressed(eg.keys.left):
+		return game.Left, true // X-
+	case inpututil.IsKeyJustPressed(eg.keys.right):
+		return game.Right, true // X+
+	}
+	return 0, false
+}
+
 // Draw draws the game screen.
 func (eg *EbitenGame) Draw(screen *ebiten.Image) {
-	// Draw background
-	screenW, screenH := screen.Size()
-	bgOpts := &ebiten.DrawImageOptions{}
-	bgOpts.GeoM.Scale(float64(screenW), float64(screenH)) // Scale the 1x1 pixel bg image
-	screen.DrawImage(eg.bgImg, bgOpts)
-
-	// Calculate offsets to center the grid
-	gridWidth := eg.game.Grid * eg.tileSize
-	gridHeight := eg.game.Grid * eg.tileSize
-	offsetX := (screenW - gridWidth) / 2
-	offsetY := (screenH - gridHeight) / 2
-
-	// Draw grid lines (optional, can be resource intensive)
-	gridC := eg.config.Colors.Grid
-	gridColor := color.RGBA{R: uint8(gridC[0] * 255), G: uint8(gridC[1] * 255), B: uint8(gridC[2] * 255), A: 100} // Semi-transparent grid
-	for i := 0; i <= eg.game.Grid; i++ {
-		fx := float32(offsetX + i*eg.tileSize)
-		fy := float32(offsetY + i*eg.tileSize)
-		// Vertical line
-		vector.StrokeLine(screen, fx, float32(offsetY), fx, float32(offsetY+gridHeight), 1, gridColor, false)
-		// Horizontal line
-		vector.StrokeLine(screen, float32(offsetX), fy, float32(offsetX+gridWidth), fy, 1, gridColor, false)
-	}
-
-	// Draw snake
-	for i, part := range eg.game.Snake.Body {
-		opts := &ebiten.DrawImageOptions{}
-		opts.GeoM.Translate(float64(offsetX+part.X*eg.tileSize), float64(offsetY+part.Y*eg.tileSize))
-		if i == 0 {
-			screen.DrawImage(eg.snakeHeadImg, opts)
-		} else {
-			screen.DrawImage(eg.snakeBodyImg, opts)
-		}
+	if eg.lan != nil && eg.remote == nil {
+		eg.drawLAN(screen)
+		return
+	}
+	if eg.remote != nil && eg.remote.InLobby() {
+		eg.drawLobby(screen)
+		return
+	}
+	if eg.profileMenu {
+		eg.drawProfiles(screen)
+		return
 	}
+	if eg.achievementsOpen {
+		eg.drawAchievements(screen)
+		return
+	}
+	if eg.settingsOpen {
+		eg.drawSettings(screen)
+		return
+	}
+
+	screenW, screenH := screen.Size()
+	backend := ebitenrender.New(screen)
 
-	// Draw food
-	foodOpts := &ebiten.DrawImageOptions{}
-	foodOpts.GeoM.Translate(float64(offsetX+eg.game.Food.X*eg.tileSize), float64(offsetY+eg.game.Food.Y*eg.tileSize))
-	screen.DrawImage(eg.foodImg, foodOpts)
+	// Draw the board centered on screen, scrolling with the player's snake
+	// when it does not fit
+	var focus game.Point2D
+	if eg.self < len(eg.game.Snakes) {
+		focus = eg.game.Snakes[eg.self].Head()
+	}
+	layout := render.FollowLayout(eg.game.Grid, eg.tileSize, screenW, screenH, focus)
+	layout.Self = eg.self
+	layout.Grid = eg.gridLines
+	render.Draw(backend, render.Board(eg.game, eg.theme, layout))
 
 	// Draw score and status
-	scoreText := fmt.Sprintf("Score: %d", eg.game.Score)
+	score := 0
+	if eg.self < len(eg.game.Snakes) {
+		score = eg.game.Snakes[eg.self].Score
+	}
+	scoreText := render.ScoreLine(eg.game, eg.self)
 	statusText := ""
 	switch eg.game.State {
 	case game.Playing:
 		statusText = "Playing - WASD: Move"
 	case game.Paused:
-		statusText = "Paused - Press P to Start"
+		statusText = "Paused - Press P to Start, O: Settings"
 	case game.GameOver:
-		statusText = fmt.Sprintf("Game Over - Score: %d - Press R", eg.game.Score)
+		statusText = fmt.Sprintf("Game Over - Score: %d - Press R, M: Profiles, O: Settings", score)
+		if eg.achievements != nil {
+			statusText += ", V: Achievements"
+		}
+	}
+
+	if eg.player != nil {
+		statusText = eg.replayStatus()
+	}
+	if eg.remote != nil {
+		statusText = eg.remoteStatus()
+	}
+
+	hud := []string{scoreText, statusText}
+	scoresY := 60
+	if eg.notice != "" && eg.player == nil && eg.remote == nil {
+		hud = append(hud, eg.notice)
+		scoresY += 20
 	}
+	render.Draw(backend, render.HUD(hud, eg.theme, 10, 10))
 
-	// Draw text using ebitenutil for simplicity
-	ebitenutil.DebugPrintAt(screen, scoreText, 10, 10)
-	ebitenutil.DebugPrintAt(screen, statusText, 10, 30)
+	// List high scores and their replays once the game has ended
+	if eg.player == nil && eg.remote == nil && eg.game.IsGameOver() {
+		eg.drawHighScores(screen, 10, scoresY)
+		if eg.leaderboard != nil {
+			eg.drawGlobalScores(screen, screenW/2, scoresY)
+		}
+	}
+
+	if eg.player == nil && eg.remote == nil {
+		eg.drawToast(screen)
+	}
+
+	if eg.capture != nil {
+		eg.print(screen, fmt.Sprintf("REC %d/%d frames (F12 to save)", eg.capture.Frames(), maxCaptureFrames), screenW-220, 30)
+	}
+
+	if eg.remote != nil && eg.netDebug {
+		eg.print(screen, eg.remote.Stats().String(), screenW-360, 50)
+	}
 
 	// Draw FPS counter
 	fps := ebiten.ActualFPS()
-	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("FPS: %.1f", fps), screenW-100, 10)
+	eg.print(screen, fmt.Sprintf("FPS: %.1f", fps), screenW-100, 10)
+}
+
+// replayStatus describes the playback position and controls
+func (eg *EbitenGame) replayStatus() string {
+	p := eg.player
+	state := "Playing"
+	if p.Paused {
+		state = "Paused"
+	}
+	if p.Done() {
+		state = "Finished"
+	}
+	return fmt.Sprintf("Replay %s - Tick %d/%d - %.2gx\nSpace: Pause  Left/Right: Step  PgUp/PgDn: Seek  Up/Down: Speed  Home: Restart",
+		state, p.Game.Tick, p.Replay.Result.Ticks, p.Speed)
+}
+
+// print draws text in the theme's text color
+func (eg *EbitenGame) print(screen *ebiten.Image, text string, x, y int) {
+	ebitenrender.New(screen).DrawText(x, y, text, eg.theme.Text)
+}
+
+// drawHighScores lists the stored high scores for the rules of the game
+// just played, marking those with a replay
+func (eg *EbitenGame) drawHighScores(screen *ebiten.Image, x, y int) {
+	rules := storage.RulesetOf(eg.game.Config, eg.game.Difficulty)
+	eg.print(screen, "High Scores - "+rules.String(), x, y)
+	for i, hs := range eg.storage.HighScoresFor(rules) {
+		line := fmt.Sprintf("%2d. %-12s %5d", i+1, hs.Player, hs.Score)
+		if hs.Verified {
+			line += " (verified)"
+		}
+		if hs.Replay != "" {
+			line += "  [replay " + hs.Replay + "]"
+		}
+		eg.print(screen, line, x, y+16*(i+1))
+	}
+}
+
+// drawGlobalScores lists the shared leaderboard's top scores
+func (eg *EbitenGame) drawGlobalScores(screen *ebiten.Image, x, y int) {
+	title := "Global Scores"
+	if n := eg.leaderboard.Pending(); n > 0 {
+		title += fmt.Sprintf(" (%d waiting to upload)", n)
+	}
+	eg.print(screen, title, x, y)
+
+	entries, err := eg.leaderboard.GlobalTop(storage.RulesetOf(eg.game.Config, eg.game.Difficulty))
+	if err != nil && entries == nil {
+		eg.print(screen, "Leaderboard unreachable", x, y+16)
+		return
+	}
+	for i, e := range entries {
+		line := fmt.Sprintf("%2d. %-12s %5d", i+1, e.Player, e.Score)
+		eg.print(screen, line, x, y+16*(i+1))
+	}
 }
 
 // Layout takes the outside size (e.g., window size) and returns the (logical) screen size.
@@ -0,0 +1,104 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// NewLANGUI initializes the Ebiten wrapper with a browser of the games
+// hosted on the local network. Joining one switches to the hosted game.
+func NewLANGUI(b *netplay.Browser, name string, cfg *config.Config, s *storage.Storage) (*EbitenGame, error) {
+	placeholder := game.NewGame(cfg)
+	placeholder.State = game.Paused
+
+	eg, err := NewEbitenGUI(placeholder, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+
+	eg.lan = b
+	eg.lanName = name
+	ebiten.SetWindowTitle("Go Snake 2D - Join LAN game")
+	return eg, nil
+}
+
+// updateLAN moves the selection and joins the chosen game
+func (eg *EbitenGame) updateLAN() {
+	games := eg.lan.Games()
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		eg.lanSelected++
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		eg.lanSelected--
+	}
+	if eg.lanSelected >= len(games) {
+		eg.lanSelected = len(games) - 1
+	}
+	if eg.lanSelected < 0 {
+		eg.lanSelected = 0
+	}
+	if len(games) == 0 {
+		return
+	}
+
+	// Enter joins as a player, S watches as a spectator
+	join := inpututil.IsKeyJustPressed(ebiten.KeyEnter)
+	watch := inpututil.IsKeyJustPressed(ebiten.KeyS)
+	if !join && !watch {
+		return
+	}
+
+	addr := games[eg.lanSelected].Addr
+	var client *netplay.Client
+	var err error
+	if watch {
+		client, err = netplay.Spectate(addr, eg.lanName, eg.config)
+	} else {
+		client, err = netplay.Dial(addr, eg.lanName, eg.config)
+	}
+	if err != nil {
+		eg.lanErr = fmt.Sprintf("Failed to join %s: %v", addr, err)
+		return
+	}
+
+	eg.lanErr = ""
+	eg.remote = client
+	ebiten.SetWindowTitle("Go Snake 2D")
+}
+
+// drawLAN lists the games found on the local network
+func (eg *EbitenGame) drawLAN(screen *ebiten.Image) {
+	screen.Fill(eg.theme.Background)
+
+	x, y := 20, 20
+	eg.print(screen, "Join LAN game - Up/Down: Select  Enter: Join  S: Spectate", x, y)
+	y += 32
+
+	games := eg.lan.Games()
+	if len(games) == 0 {
+		eg.print(screen, "Searching for games on the local network...", x, y)
+	}
+	for i, g := range games {
+		state := fmt.Sprintf("waiting %d/%d", g.Players, g.Needed)
+		if g.Playing {
+			state = "in progress"
+		}
+		marker := "  "
+		if i == eg.lanSelected {
+			marker = "> "
+		}
+		line := fmt.Sprintf("%s%-20s %-8s %2dx%-2d  %-14s %s", marker, g.Name, g.Mode, g.Board, g.Board, state, g.Addr)
+		eg.print(screen, line, x, y+16*i)
+	}
+
+	if eg.lanErr != "" {
+		eg.print(screen, eg.lanErr, x, eg.config.Graphics.WindowHeight-30)
+	}
+}
@@ -0,0 +1,192 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+	"github.com/C0d3-5t3w/go-snake/internal/render/ebitenrender"
+)
+
+// Lobby rows, top to bottom. Only the host sees the rules rows.
+const (
+	lobbyName = iota
+	lobbyColor
+	lobbyReady
+	lobbyMode
+	lobbyLevel
+	lobbySpeed
+	lobbyBoard
+)
+
+// lobbyRules maps the host's rows to the rules they change
+var lobbyRules = map[int]netplay.RuleField{
+	lobbyMode:  netplay.RuleMode,
+	lobbyLevel: netplay.RuleLevel,
+	lobbySpeed: netplay.RuleSpeed,
+	lobbyBoard: netplay.RuleGrid,
+}
+
+// updateLobby edits the player's name and color, readies up and lets the
+// host pick the rules
+func (eg *EbitenGame) updateLobby() {
+	l := eg.remote.Lobby()
+	me := l.Players[l.You]
+	if eg.lobbyName == nil {
+		eg.lobbyName = newTextInput(me.Name, 20)
+	}
+
+	rows := lobbyReady + 1
+	if l.IsHost() {
+		rows = lobbyBoard + 1
+	}
+
+	// Up/Down move between rows, leaving the name field submits it
+	move := 0
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) || inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		move = 1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		move = -1
+	}
+	if move != 0 {
+		if eg.lobbyRow == lobbyName {
+			eg.submitName(me)
+		}
+		eg.lobbyRow = (eg.lobbyRow + move + rows) % rows
+	}
+	if eg.lobbyRow >= rows {
+		eg.lobbyRow = lobbyReady
+	}
+
+	eg.lobbyName.focus = eg.lobbyRow == lobbyName
+	if eg.lobbyName.update() {
+		eg.submitName(me)
+		eg.lobbyRow = lobbyReady
+		return
+	}
+	if eg.lobbyRow == lobbyName {
+		return
+	}
+
+	step := 0
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) {
+		step = 1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+		step = -1
+	}
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeySpace) ||
+		(eg.lobbyRow == lobbyReady && inpututil.IsKeyJustPressed(ebiten.KeyEnter)):
+		eg.remote.SetReady(!me.Ready)
+	case step != 0 && eg.lobbyRow == lobbyColor:
+		eg.remote.SetProfile(me.Name, l.NextColor(step, len(render.Palette)))
+	case step != 0 && l.IsHost():
+		if field, ok := lobbyRules[eg.lobbyRow]; ok {
+			eg.remote.SetRules(l.Rules.Step(field, step))
+		}
+	}
+}
+
+// submitName sends the edited name if it changed
+func (eg *EbitenGame) submitName(me netplay.LobbyPlayer) {
+	name := strings.TrimSpace(eg.lobbyName.String())
+	if name == "" {
+		eg.lobbyName.Set(me.Name)
+		return
+	}
+	if name != me.Name {
+		eg.remote.SetProfile(name, me.Color)
+	}
+}
+
+// drawLobby shows the player's settings, the rules and everyone's seat
+func (eg *EbitenGame) drawLobby(screen *ebiten.Image) {
+	screen.Fill(eg.theme.Background)
+	backend := ebitenrender.New(screen)
+
+	l := eg.remote.Lobby()
+	me := l.Players[l.You]
+	x, y := 20, 20
+
+	humans := 0
+	for _, p := range l.Players {
+		if !p.Bot {
+			humans++
+		}
+	}
+	eg.print(screen, fmt.Sprintf("Lobby - %d/%d players, %d needed to start", humans, l.Max, l.Needed), x, y)
+	y += 32
+
+	name := me.Name
+	if eg.lobbyName != nil {
+		name = eg.lobbyName.display()
+	}
+	ready := "No"
+	if me.Ready {
+		ready = "Yes"
+	}
+	rows := []string{
+		"Name:   " + name,
+		"Color:  < " + render.ColorName(me.Color) + " >",
+		"Ready:  " + ready,
+	}
+	if l.IsHost() {
+		rows = append(rows,
+			"Mode:   < "+l.Rules.Mode+" >",
+			"Level:  < "+l.Rules.Level+" >",
+			fmt.Sprintf("Speed:  < %.0f ticks/s >", l.Rules.Speed),
+			fmt.Sprintf("Board:  < %dx%d >", l.Rules.Grid, l.Rules.Grid),
+		)
+	} else {
+		rows = append(rows, "Rules:  "+l.Rules.String()+" (picked by the host)")
+	}
+	for i, row := range rows {
+		marker := "  "
+		if i == eg.lobbyRow {
+			marker = "> "
+		}
+		eg.print(screen, marker+row, x, y)
+		y += 16
+	}
+	y += 16
+
+	// Everyone's seat, with a swatch of their color
+	for i, p := range l.Players {
+		if p.Color > 0 && p.Color <= len(render.Palette) {
+			_, body := render.PaletteColors(p.Color)
+			render.Draw(backend, []render.Primitive{render.Rect{X: float32(x), Y: float32(y + 2), W: 12, H: 12, Color: body}})
+		}
+
+		state := "not ready"
+		if p.Ready {
+			state = "ready"
+		}
+		var tags []string
+		if i == l.Host {
+			tags = append(tags, "host")
+		}
+		if i == l.You {
+			tags = append(tags, "you")
+		}
+		if p.Bot {
+			tags = append(tags, "bot")
+		}
+		eg.print(screen, fmt.Sprintf("%-20s %-10s %s", p.Name, state, strings.Join(tags, ", ")), x+20, y)
+		y += 16
+	}
+	y += 16
+
+	if left := eg.remote.Countdown(); left > 0 {
+		eg.print(screen, fmt.Sprintf("Starting in %d...", int(left.Seconds())+1), x, y)
+	}
+
+	help := "Up/Down: Select  Left/Right: Change  Space: Ready  Type to edit your name"
+	eg.print(screen, help, x, eg.config.Graphics.WindowHeight-30)
+}
@@ -0,0 +1,184 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// ShowProfiles opens the profile menu, holding the local game until a
+// profile is picked
+func (eg *EbitenGame) ShowProfiles() {
+	eg.profileMenu = true
+	eg.profileEdit = nil
+	eg.profileErr = ""
+
+	active := eg.storage.ActiveProfile().Name
+	for i, p := range eg.storage.Profiles() {
+		if p.Name == active {
+			eg.profileRow = i
+		}
+	}
+}
+
+// updateProfiles moves the selection, plays as the chosen profile and
+// creates or renames profiles with a typed name
+func (eg *EbitenGame) updateProfiles() {
+	profiles := eg.storage.Profiles()
+
+	// While a name is being typed, Enter submits it and Escape cancels
+	if eg.profileEdit != nil {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			eg.profileEdit = nil
+			eg.profileErr = ""
+			return
+		}
+		if eg.profileEdit.update() {
+			eg.submitProfile()
+		}
+		return
+	}
+
+	// The last row creates a new profile
+	rows := len(profiles) + 1
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) || inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		eg.profileRow = (eg.profileRow + 1) % rows
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		eg.profileRow = (eg.profileRow + rows - 1) % rows
+	}
+	if eg.profileRow >= rows {
+		eg.profileRow = rows - 1
+	}
+
+	enter := inpututil.IsKeyJustPressed(ebiten.KeyEnter)
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyN) || (enter && eg.profileRow == len(profiles)):
+		eg.editProfile("", "")
+	case inpututil.IsKeyJustPressed(ebiten.KeyR) && eg.profileRow < len(profiles):
+		name := profiles[eg.profileRow].Name
+		eg.editProfile(name, name)
+	case enter:
+		eg.playAs(profiles[eg.profileRow].Name)
+	}
+}
+
+// editProfile starts typing a name, for a new profile when renaming is empty
+func (eg *EbitenGame) editProfile(renaming, text string) {
+	eg.renaming = renaming
+	eg.profileEdit = newTextInput(text, storage.MaxProfileName)
+	eg.profileEdit.focus = true
+	eg.profileErr = ""
+}
+
+// submitProfile creates or renames a profile with the typed name,
+// keeping the field open to correct a rejected name
+func (eg *EbitenGame) submitProfile() {
+	var name string
+	var err error
+	if eg.renaming != "" {
+		name, err = eg.storage.RenameProfile(eg.renaming, eg.profileEdit.String())
+	} else {
+		name, err = eg.storage.CreateProfile(eg.profileEdit.String())
+	}
+	if err == nil {
+		err = eg.storage.Save()
+	}
+	if err != nil {
+		eg.profileErr = err.Error()
+		return
+	}
+
+	eg.profileEdit = nil
+	eg.profileErr = ""
+	for i, p := range eg.storage.Profiles() {
+		if p.Name == name {
+			eg.profileRow = i
+		}
+	}
+}
+
+// playAs makes a profile active and starts a fresh game, on the open level
+// if the profile has not unlocked the configured one
+func (eg *EbitenGame) playAs(name string) {
+	err := eg.storage.SetActiveProfile(name)
+	if err == nil {
+		err = eg.storage.Save()
+	}
+	if err != nil {
+		eg.profileErr = err.Error()
+		return
+	}
+
+	eg.profileMenu = false
+	eg.notice = ""
+	settings := eg.storage.GetSettings()
+	eg.applySettings(settings)
+	eg.applyDifficulty(settings.Difficulty)
+	if need := eg.storage.ActiveProfile().Locked("level:" + eg.game.Level); need > 0 {
+		eg.notice = fmt.Sprintf("Level %s unlocks at a best score of %d, playing %s", eg.game.Level, need, game.LevelOpen)
+		eg.game.Level = game.LevelOpen
+		eg.game.Config.Game.Level = game.LevelOpen
+	}
+
+	eg.game.Snakes[0].Name = name
+	eg.game.Reset()
+	eg.archived = false
+}
+
+// drawProfiles lists the profiles with their stats and unlocks
+func (eg *EbitenGame) drawProfiles(screen *ebiten.Image) {
+	screen.Fill(eg.theme.Background)
+
+	x, y := 20, 20
+	eg.print(screen, "Choose a profile", x, y)
+	y += 32
+
+	profiles := eg.storage.Profiles()
+	for i, p := range profiles {
+		marker := "  "
+		if i == eg.profileRow {
+			marker = "> "
+		}
+		line := fmt.Sprintf("%s%-20s best %5d  games %4d  longest %3d", marker, p.Name, p.Stats.BestScore, p.Stats.GamesPlayed, p.Stats.LongestSnake)
+		if len(p.Unlocks) > 0 {
+			labels := make([]string, len(p.Unlocks))
+			for j, u := range p.Unlocks {
+				labels[j] = storage.UnlockLabel(u)
+			}
+			line += "  unlocked: " + strings.Join(labels, ", ")
+		}
+		eg.print(screen, line, x, y)
+		y += 16
+	}
+
+	marker := "  "
+	if eg.profileRow == len(profiles) {
+		marker = "> "
+	}
+	eg.print(screen, marker+"+ New profile", x, y)
+	y += 32
+
+	if eg.profileEdit != nil {
+		prompt := "New profile name: "
+		if eg.renaming != "" {
+			prompt = fmt.Sprintf("Rename %s to: ", eg.renaming)
+		}
+		eg.print(screen, prompt+eg.profileEdit.display(), x, y)
+		y += 16
+	}
+	if eg.profileErr != "" {
+		eg.print(screen, eg.profileErr, x, y)
+	}
+
+	help := "Up/Down: Select  Enter: Play  N: New profile  R: Rename"
+	if eg.profileEdit != nil {
+		help = "Type a name  Enter: Save  Escape: Cancel"
+	}
+	eg.print(screen, help, x, eg.config.Graphics.WindowHeight-30)
+}
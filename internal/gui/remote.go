@@ -0,0 +1,91 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// NewRemoteGUI initializes the Ebiten wrapper for a hosted game
+func NewRemoteGUI(c *netplay.Client, cfg *config.Config, s *storage.Storage) (*EbitenGame, error) {
+	// Until the match starts an empty board is shown
+	placeholder := game.NewGame(cfg)
+	placeholder.State = game.Paused
+
+	eg, err := NewEbitenGUI(placeholder, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+
+	eg.remote = c
+	return eg, nil
+}
+
+// updateRemote sends direction intents and mirrors the server's state
+func (eg *EbitenGame) updateRemote() {
+	// F3 shows ping and prediction figures
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		eg.netDebug = !eg.netDebug
+	}
+
+	if eg.remote.InLobby() {
+		eg.updateLobby()
+		return
+	}
+
+	if eg.remote.Spectating() {
+		// Spectators switch which snake the camera follows
+		if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) || inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+			eg.remote.CycleFollow(1)
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+			eg.remote.CycleFollow(-1)
+		}
+	} else if dir, ok := eg.directionInput(); ok {
+		eg.remote.SendDirection(dir)
+	}
+
+	if g := eg.remote.Game(); g != nil {
+		eg.game = g
+		eg.self = eg.remote.Follow()
+	}
+}
+
+// remoteStatus describes the connection and match state
+func (eg *EbitenGame) remoteStatus() string {
+	if err := eg.remote.Err(); err != nil {
+		return fmt.Sprintf("Disconnected: %v", err)
+	}
+
+	if standings := eg.remote.Standings(); standings != nil {
+		parts := make([]string, len(standings))
+		for i, st := range standings {
+			parts[i] = fmt.Sprintf("%s: %d", st.Name, st.Score)
+			if st.Winner {
+				parts[i] += " (winner)"
+			}
+		}
+		return "Game Over - " + strings.Join(parts, ", ")
+	}
+
+	if eg.remote.Game() == nil {
+		return "Waiting for the match to start"
+	}
+
+	if eg.remote.Spectating() {
+		name := ""
+		if eg.self < len(eg.game.Snakes) {
+			name = eg.game.Snakes[eg.self].Name
+		}
+		return fmt.Sprintf("Spectating %s - Left/Right: Switch snake", name)
+	}
+
+	return "Online - Arrows: Move"
+}
@@ -0,0 +1,260 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// Rows of the settings screen
+const (
+	settingDifficulty = iota
+	settingMusic
+	settingSfx
+	settingTheme
+	settingFullscreen
+	settingVsync
+	settingGridLines
+	settingKeyUp
+	settingKeyDown
+	settingKeyLeft
+	settingKeyRight
+	settingKeyPause
+	settingRows
+)
+
+// volumeStep is how much Left and Right change a volume
+const volumeStep = 0.1
+
+// keyMap holds the keys bound to the game controls
+type keyMap struct {
+	up, down, left, right, pause ebiten.Key
+}
+
+// bindKeys parses key bindings, keeping the default for names that are
+// not keys
+func bindKeys(b storage.KeyBindings) keyMap {
+	defaults := storage.DefaultKeys()
+	return keyMap{
+		up:    parseKey(b.Up, defaults.Up),
+		down:  parseKey(b.Down, defaults.Down),
+		left:  parseKey(b.Left, defaults.Left),
+		right: parseKey(b.Right, defaults.Right),
+		pause: parseKey(b.Pause, defaults.Pause),
+	}
+}
+
+// parseKey returns the key with the given name, or else the fallback
+func parseKey(name, fallback string) ebiten.Key {
+	var k ebiten.Key
+	if k.UnmarshalText([]byte(name)) != nil {
+		k.UnmarshalText([]byte(fallback))
+	}
+	return k
+}
+
+// applySettings shows the game with a profile's theme, grid lines,
+// window mode and key bindings
+func (eg *EbitenGame) applySettings(s storage.Settings) {
+	eg.theme = render.NamedTheme(s.Theme, eg.config)
+	eg.gridLines = s.GridLines
+	eg.keys = bindKeys(s.Keys)
+	ebiten.SetFullscreen(s.Fullscreen)
+	ebiten.SetVsyncEnabled(s.Vsync)
+}
+
+// applyDifficulty makes the local game run at a difficulty's speeds. It
+// must only be called before a Reset, so a run keeps the difficulty it
+// started with and is ranked under it.
+func (eg *EbitenGame) applyDifficulty(difficulty string) {
+	eg.game.SetDifficulty(eg.config, difficulty)
+}
+
+// ShowSettings opens the settings screen, pausing a running game
+func (eg *EbitenGame) ShowSettings() {
+	if eg.game.State == game.Playing {
+		eg.game.TogglePause()
+	}
+	eg.settingsOpen = true
+	eg.settingsRow = 0
+	eg.bindingKey = false
+}
+
+// updateSettings moves the selection and changes the selected setting,
+// applying it at once, except the difficulty, which the next game starts
+// with. Closing the screen saves the settings.
+func (eg *EbitenGame) updateSettings() {
+	// Wait for the key to bind, Escape keeping the old one
+	if eg.bindingKey {
+		pressed := inpututil.AppendJustPressedKeys(nil)
+		if len(pressed) == 0 {
+			return
+		}
+		eg.bindingKey = false
+		if pressed[0] != ebiten.KeyEscape {
+			eg.bindKey(strings.ToLower(pressed[0].String()))
+		}
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		eg.settingsOpen = false
+		if err := eg.storage.Save(); err != nil {
+			eg.notice = fmt.Sprintf("Failed to save settings: %v", err)
+		}
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) || inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		eg.settingsRow = (eg.settingsRow + 1) % settingRows
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		eg.settingsRow = (eg.settingsRow + settingRows - 1) % settingRows
+	}
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft):
+		eg.changeSetting(-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowRight):
+		eg.changeSetting(1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		if eg.settingsRow >= settingKeyUp {
+			eg.bindingKey = true
+		} else {
+			eg.changeSetting(1)
+		}
+	}
+}
+
+// changeSetting steps the selected setting forward or back and applies it
+func (eg *EbitenGame) changeSetting(delta int) {
+	s := eg.storage.GetSettings()
+	switch eg.settingsRow {
+	case settingDifficulty:
+		s.Difficulty = cycle(game.Difficulties, s.Difficulty, delta)
+	case settingMusic:
+		s.MusicVolume = stepVolume(s.MusicVolume, delta)
+	case settingSfx:
+		s.SfxVolume = stepVolume(s.SfxVolume, delta)
+	case settingTheme:
+		s.Theme = cycle(render.Themes, s.Theme, delta)
+	case settingFullscreen:
+		s.Fullscreen = !s.Fullscreen
+	case settingVsync:
+		s.Vsync = !s.Vsync
+	case settingGridLines:
+		s.GridLines = !s.GridLines
+	default:
+		return
+	}
+
+	eg.storage.UpdateSettings(s)
+	eg.applySettings(s)
+}
+
+// bindKey binds the selected control to a key. A control already on that
+// key takes the selected control's old key, so none is left unbound.
+func (eg *EbitenGame) bindKey(name string) {
+	s := eg.storage.GetSettings()
+	bindings := keyBindingFields(&s.Keys)
+	selected := bindings[eg.settingsRow-settingKeyUp]
+
+	for _, b := range bindings {
+		if b != selected && *b == name {
+			*b = *selected
+		}
+	}
+	*selected = name
+
+	eg.storage.UpdateSettings(s)
+	eg.applySettings(s)
+}
+
+// keyBindingFields returns the bindings in the order of the settings rows
+func keyBindingFields(k *storage.KeyBindings) []*string {
+	return []*string{&k.Up, &k.Down, &k.Left, &k.Right, &k.Pause}
+}
+
+// cycle returns the option delta places from current, wrapping around.
+// An unknown current value starts from the first option.
+func cycle(options []string, current string, delta int) string {
+	i := 0
+	for j, o := range options {
+		if o == current {
+			i = j
+		}
+	}
+	return options[(i+delta+len(options))%len(options)]
+}
+
+// stepVolume raises or lowers a volume by volumeStep, keeping it in 0..1
+func stepVolume(v float64, delta int) float64 {
+	v = float64(int(v/volumeStep+0.5)+delta) * volumeStep
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// drawSettings lists the settings with their values
+func (eg *EbitenGame) drawSettings(screen *ebiten.Image) {
+	screen.Fill(eg.theme.Background)
+	s := eg.storage.GetSettings()
+
+	x, y := 20, 20
+	eg.print(screen, "Settings of "+eg.storage.ActiveProfile().Name, x, y)
+	y += 32
+
+	onOff := map[bool]string{true: "on", false: "off"}
+	values := []struct{ label, value string }{
+		{"Difficulty", s.Difficulty + "  (from the next game)"},
+		{"Music volume", volumeBar(s.MusicVolume) + "  (no audio yet)"},
+		{"Sound volume", volumeBar(s.SfxVolume) + "  (no audio yet)"},
+		{"Theme", s.Theme},
+		{"Fullscreen", onOff[s.Fullscreen]},
+		{"Vsync", onOff[s.Vsync]},
+		{"Grid lines", onOff[s.GridLines]},
+		{"Move up", s.Keys.Up},
+		{"Move down", s.Keys.Down},
+		{"Move left", s.Keys.Left},
+		{"Move right", s.Keys.Right},
+		{"Pause", s.Keys.Pause},
+	}
+	for i, v := range values {
+		marker := "  "
+		if i == eg.settingsRow {
+			marker = "> "
+			if eg.bindingKey {
+				v.value = "press a key..."
+			}
+		}
+		eg.print(screen, fmt.Sprintf("%s%-14s %s", marker, v.label, v.value), x, y)
+		y += 16
+	}
+
+	help := "Up/Down: Select  Left/Right: Change  Enter: Change or bind key  O/Escape: Save and close"
+	if eg.bindingKey {
+		help = "Press the key to bind  Escape: Cancel"
+	}
+	eg.print(screen, help, x, eg.config.Graphics.WindowHeight-30)
+}
+
+// volumeBar draws a volume as a ten-step bar with its percentage
+func volumeBar(v float64) string {
+	filled := int(v/volumeStep + 0.5)
+	if filled < 0 {
+		filled = 0
+	} else if filled > 10 {
+		filled = 10
+	}
+	return fmt.Sprintf("[%s%s] %3.0f%%", strings.Repeat("#", filled), strings.Repeat("-", 10-filled), v*100)
+}
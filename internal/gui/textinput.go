@@ -0,0 +1,61 @@
+package gui
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// textInput is a single-line text field edited with the keyboard
+type textInput struct {
+	text  []rune
+	max   int  // Longest text accepted, in characters
+	focus bool // Whether typing goes to this field
+}
+
+// newTextInput creates a field holding text
+func newTextInput(text string, max int) *textInput {
+	return &textInput{text: []rune(text), max: max}
+}
+
+// String returns the text typed so far
+func (t *textInput) String() string {
+	return string(t.text)
+}
+
+// Set replaces the text
+func (t *textInput) Set(text string) {
+	t.text = []rune(text)
+}
+
+// update takes typed characters and backspaces while focused, reporting
+// whether Enter was pressed to submit the text
+func (t *textInput) update() bool {
+	if !t.focus {
+		return false
+	}
+
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if len(t.text) < t.max && r >= ' ' {
+			t.text = append(t.text, r)
+		}
+	}
+
+	// Backspace repeats while held
+	if d := inpututil.KeyPressDuration(ebiten.KeyBackspace); d == 1 || (d > 30 && d%3 == 0) {
+		if len(t.text) > 0 {
+			t.text = t.text[:len(t.text)-1]
+		}
+	}
+
+	return inpututil.IsKeyJustPressed(ebiten.KeyEnter)
+}
+
+// display returns the text with a blinking cursor while focused
+func (t *textInput) display() string {
+	if t.focus && time.Now().UnixMilli()/500%2 == 0 {
+		return string(t.text) + "_"
+	}
+	return string(t.text)
+}
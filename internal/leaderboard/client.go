@@ -0,0 +1,99 @@
+package leaderboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client talks to a leaderboard server's HTTP API
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// RejectedError is returned when the server refused a submission as
+// malformed or invalid, so retrying it would not help. Other failures,
+// such as a wrong URL, a proxy error or rate limiting, are plain errors
+// and worth retrying.
+type RejectedError struct {
+	Status  int
+	Message string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("leaderboard rejected submission (%d): %s", e.Status, e.Message)
+}
+
+// NewClient creates a client for the server at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Submit sends a finished run to the leaderboard
+func (c *Client) Submit(sub Submission) (Entry, error) {
+	body, err := json.Marshal(sub)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	resp, err := c.HTTP.Post(c.BaseURL+"/api/scores", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return Entry{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return Entry{}, &RejectedError{Status: resp.StatusCode, Message: apiErr.Error}
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return Entry{}, fmt.Errorf("leaderboard returned %s", resp.Status)
+	}
+
+	var e Entry
+	err = json.NewDecoder(resp.Body).Decode(&e)
+	return e, err
+}
+
+// Top fetches the best scores in a segment
+func (c *Client) Top(q Query) ([]Entry, error) {
+	v := url.Values{}
+	if q.Mode != "" {
+		v.Set("mode", q.Mode)
+	}
+	if q.Board != 0 {
+		v.Set("board", strconv.Itoa(q.Board))
+	}
+	if q.Difficulty != "" {
+		v.Set("difficulty", q.Difficulty)
+	}
+	if q.Limit != 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	resp, err := c.HTTP.Get(c.BaseURL + "/api/scores?" + v.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("leaderboard returned %s", resp.Status)
+	}
+
+	var entries []Entry
+	err = json.NewDecoder(resp.Body).Decode(&entries)
+	return entries, err
+}
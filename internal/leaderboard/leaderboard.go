@@ -0,0 +1,51 @@
+package leaderboard
+
+import (
+	"time"
+)
+
+// DefaultMode is the mode of a regular single-player game
+const DefaultMode = "classic"
+
+// Submission is a finished run sent to the leaderboard
+type Submission struct {
+	Player     string    `json:"player"`
+	Score      int       `json:"score"`
+	Length     int       `json:"length"`
+	Ticks      int       `json:"ticks"`
+	Mode       string    `json:"mode"`
+	Board      int       `json:"board"` // Grid size
+	Difficulty string    `json:"difficulty"`
+	Date       time.Time `json:"date"`
+	Replay     []byte    `json:"replay,omitempty"` // Encoded replay file, sent as base64; required
+}
+
+// Entry is a stored leaderboard score
+type Entry struct {
+	ID         int       `json:"id"`
+	Player     string    `json:"player"`
+	Score      int       `json:"score"`
+	Length     int       `json:"length"`
+	Ticks      int       `json:"ticks"`
+	Mode       string    `json:"mode"`
+	Board      int       `json:"board"`
+	Difficulty string    `json:"difficulty"`
+	Date       time.Time `json:"date"`
+	Verified   bool      `json:"verified"` // Backed by a replay that re-simulates to this score
+	Replay     string    `json:"replay,omitempty"`
+}
+
+// Query selects a segment of the leaderboard. Empty fields match anything.
+type Query struct {
+	Mode       string
+	Board      int
+	Difficulty string
+	Limit      int
+}
+
+// Matches reports whether an entry belongs to the queried segment
+func (q Query) Matches(e Entry) bool {
+	return (q.Mode == "" || q.Mode == e.Mode) &&
+		(q.Board == 0 || q.Board == e.Board) &&
+		(q.Difficulty == "" || q.Difficulty == e.Difficulty)
+}
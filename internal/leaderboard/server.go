@@ -0,0 +1,352 @@
+package leaderboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+const (
+	defaultLimit  = 10
+	maxLimit      = 100
+	maxSubmission = 4 << 20 // Largest accepted request body
+	maxVerifying  = 2       // Replays re-simulated at once, others wait
+)
+
+// ErrMalformedReplay is returned when a submitted replay cannot be decoded
+var ErrMalformedReplay = errors.New("malformed replay")
+
+// Server is a self-hosted leaderboard with a REST/JSON API:
+//
+//	POST /api/scores                  submit a Submission, refused unless
+//	                                  its replay verifies
+//	GET  /api/scores?mode=&board=&difficulty=&limit=
+//	                                  top scores for a segment
+//	GET  /api/players/{name}/history  a player's scores, newest first
+//	GET  /api/replays/{id}            the replay behind a score
+type Server struct {
+	backend   storage.Backend
+	verifying chan struct{} // Holds a token per replay being verified
+
+	mu      sync.Mutex
+	entries []Entry
+	nextID  int
+}
+
+// fileData is the stored format of the leaderboard
+type fileData struct {
+	NextID  int     `json:"next_id"`
+	Entries []Entry `json:"entries"`
+}
+
+// NewServer loads (or creates) a leaderboard persisted in a storage backend
+func NewServer(b storage.Backend) (*Server, error) {
+	s := &Server{backend: b, verifying: make(chan struct{}, maxVerifying), nextID: 1}
+
+	data, err := b.Read()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var fd fileData
+		if err := json.Unmarshal(data, &fd); err != nil {
+			return nil, fmt.Errorf("corrupt leaderboard %s: %w", b, err)
+		}
+		s.entries = fd.Entries
+		s.nextID = fd.NextID
+	}
+
+	return s, nil
+}
+
+// Handler returns the HTTP API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/scores", s.handleSubmit)
+	mux.HandleFunc("GET /api/scores", s.handleTop)
+	mux.HandleFunc("GET /api/players/{name}/history", s.handleHistory)
+	mux.HandleFunc("GET /api/replays/{id}", s.handleReplay)
+	return mux
+}
+
+// Submit validates and stores a submission. A client retrying a run that
+// was already stored gets the stored entry back rather than a second copy.
+func (s *Server) Submit(sub Submission) (Entry, error) {
+	if strings.TrimSpace(sub.Player) == "" {
+		return Entry{}, errors.New("player is required")
+	}
+	if sub.Score < 0 {
+		return Entry{}, errors.New("score must not be negative")
+	}
+	if sub.Mode == "" {
+		sub.Mode = DefaultMode
+	}
+	if sub.Date.IsZero() {
+		sub.Date = time.Now()
+	} else if e, ok := s.find(sub.Player, sub.Date); ok {
+		return e, nil
+	}
+
+	e := Entry{
+		Player:     sub.Player,
+		Score:      sub.Score,
+		Length:     sub.Length,
+		Ticks:      sub.Ticks,
+		Mode:       sub.Mode,
+		Board:      sub.Board,
+		Difficulty: sub.Difficulty,
+		Date:       sub.Date,
+	}
+
+	// Only runs whose replay re-simulates to the claimed score are ranked,
+	// and the replay is the source of truth for the run's result and rules
+	if len(sub.Replay) == 0 {
+		return Entry{}, errors.New("a replay is required")
+	}
+	s.verifying <- struct{}{}
+	r, err := checkReplay(sub)
+	<-s.verifying
+	if err != nil {
+		return Entry{}, err
+	}
+	e.Verified = true
+	e.Length = r.Result.Length
+	e.Ticks = r.Result.Ticks
+
+	// File the run under the rules it was played by, not the ones claimed
+	rules := storage.RulesetOf(&r.Config, r.Difficulty)
+	e.Mode = rules.Mode
+	e.Board = rules.Board
+	e.Difficulty = rules.Difficulty
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A retry may have been verified alongside the first attempt
+	if prev, ok := s.findLocked(e.Player, e.Date); ok {
+		return prev, nil
+	}
+
+	e.ID = s.nextID
+	s.nextID++
+
+	e.Replay = fmt.Sprintf("%d%s", e.ID, replay.Extension)
+	if err := s.backend.WriteReplay(e.Replay, sub.Replay); err != nil {
+		return Entry{}, err
+	}
+
+	s.entries = append(s.entries, e)
+	if err := s.saveLocked(); err != nil {
+		s.entries = s.entries[:len(s.entries)-1]
+		return Entry{}, err
+	}
+
+	return e, nil
+}
+
+// find returns the stored run of a player played at a date, if any. The
+// key is the one clients use to tell their queued runs apart.
+func (s *Server) find(player string, date time.Time) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findLocked(player, date)
+}
+
+func (s *Server) findLocked(player string, date time.Time) (Entry, bool) {
+	for _, e := range s.entries {
+		if e.Player == player && e.Date.Equal(date) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// checkReplay decodes a submission's replay and re-simulates it. Decoding
+// can take as much memory as verifying, so callers hold a verifying token.
+func checkReplay(sub Submission) (*replay.Replay, error) {
+	r, err := replay.Decode(bytes.NewReader(sub.Replay))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedReplay, err)
+	}
+	if r.Result.Score != sub.Score {
+		return nil, fmt.Errorf("score %d does not match replay score %d", sub.Score, r.Result.Score)
+	}
+	if err := replay.Verify(r, nil); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Top returns the best verified scores in a segment, highest first.
+// Entries stored before replays were required may be unverified, and are
+// left out.
+func (s *Server) Top(q Query) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matches []Entry
+	for _, e := range s.entries {
+		if e.Verified && q.Matches(e) {
+			matches = append(matches, e)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+	return matches
+}
+
+// History returns every score of a player, newest first
+func (s *Server) History(player string) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var history []Entry
+	for _, e := range s.entries {
+		if strings.EqualFold(e.Player, player) {
+			history = append(history, e)
+		}
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Date.After(history[j].Date)
+	})
+	return history
+}
+
+// saveLocked persists the leaderboard under the backend's lock
+func (s *Server) saveLocked() error {
+	data, err := json.MarshalIndent(fileData{NextID: s.nextID, Entries: s.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	unlock, err := s.backend.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.backend.Write(data)
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var sub Submission
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubmission))
+	if err := dec.Decode(&sub); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	e, err := s.Submit(sub)
+	if errors.Is(err, ErrMalformedReplay) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, e)
+}
+
+func (s *Server) handleTop(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.Top(q))
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.History(r.PathValue("name")))
+}
+
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid id"))
+		return
+	}
+
+	s.mu.Lock()
+	name := ""
+	for _, e := range s.entries {
+		if e.ID == id {
+			name = e.Replay
+		}
+	}
+	s.mu.Unlock()
+
+	if name == "" {
+		writeError(w, http.StatusNotFound, errors.New("no replay for this score"))
+		return
+	}
+
+	data, err := s.backend.ReadReplay(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("no replay for this score"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+// parseQuery reads segment filters from the query string
+func parseQuery(r *http.Request) (Query, error) {
+	v := r.URL.Query()
+	q := Query{
+		Mode:       v.Get("mode"),
+		Difficulty: v.Get("difficulty"),
+		Limit:      defaultLimit,
+	}
+
+	if board := v.Get("board"); board != "" {
+		n, err := strconv.Atoi(board)
+		if err != nil {
+			return q, errors.New("board must be a number")
+		}
+		q.Board = n
+	}
+	if limit := v.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return q, errors.New("limit must be a positive number")
+		}
+		q.Limit = n
+	}
+	if q.Limit > maxLimit {
+		q.Limit = maxLimit
+	}
+
+	return q, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,177 @@
+package leaderboard
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// craftedReplay encodes a replay header whose input count is far beyond
+// anything a real game records, with no inputs behind it
+func craftedReplay(count uint64) []byte {
+	var b []byte
+	b = binary.AppendVarint(b, 1) // Seed
+	b = binary.AppendVarint(b, 0) // Date
+	for _, field := range []string{"mallory", "", "medium"} {
+		b = binary.AppendUvarint(b, uint64(len(field)))
+		b = append(b, field...)
+	}
+	for _, v := range []uint64{1, 0, 0, 0, 0} { // Snakes, score, length, ticks, state
+		b = binary.AppendUvarint(b, v)
+	}
+	b = binary.AppendUvarint(b, count)
+
+	var buf bytes.Buffer
+	buf.WriteString("GSRP\x04")
+	zw := gzip.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestSubmitRejectsOversizedInputCount(t *testing.T) {
+	s, err := NewServer(storage.NewMemoryBackend())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	body, err := json.Marshal(Submission{Player: "mallory", Replay: craftedReplay(1 << 40)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(srv.URL+"/api/scores", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if top := s.Top(Query{}); len(top) != 0 {
+		t.Errorf("rejected submission was stored: %+v", top)
+	}
+	if n := len(s.verifying); n != 0 {
+		t.Errorf("%d verification tokens still held", n)
+	}
+}
+
+// playedRun plays a short game to its end and returns it as a submission
+// with its replay
+func playedRun(t *testing.T, player string) Submission {
+	t.Helper()
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := game.NewGameWithSeed(cfg, 7)
+	g.Difficulty = game.DifficultyMedium
+	for g.Step() {
+		if g.Tick == 2 {
+			g.ChangeDirection(game.Up)
+		}
+	}
+
+	r := replay.FromGame(g, player)
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return Submission{Player: player, Score: r.Result.Score, Date: r.Date, Replay: buf.Bytes()}
+}
+
+// post submits a run over HTTP and returns the response status
+func post(t *testing.T, url string, sub Submission) int {
+	t.Helper()
+	body, err := json.Marshal(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(url+"/api/scores", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestSubmitRequiresVerifiedReplay(t *testing.T) {
+	s, err := NewServer(storage.NewMemoryBackend())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	good := playedRun(t, "alice")
+	inflated := good
+	inflated.Score += 999999
+	// A replay claiming more than its simulation reaches, with a matching
+	// claimed score
+	tampered := playedRun(t, "mallory")
+	r, err := replay.Decode(bytes.NewReader(tampered.Replay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Result.Score += 10
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	tampered.Score, tampered.Replay = r.Result.Score, buf.Bytes()
+
+	for _, tc := range []struct {
+		name   string
+		sub    Submission
+		status int
+	}{
+		{"no replay", Submission{Player: "mallory", Score: 999999}, http.StatusUnprocessableEntity},
+		{"score differs from replay", inflated, http.StatusUnprocessableEntity},
+		{"replay does not verify", tampered, http.StatusUnprocessableEntity},
+		{"verified run", good, http.StatusCreated},
+	} {
+		if status := post(t, srv.URL, tc.sub); status != tc.status {
+			t.Errorf("%s: got status %d, want %d", tc.name, status, tc.status)
+		}
+	}
+
+	top := s.Top(Query{})
+	if len(top) != 1 || top[0].Player != "alice" || !top[0].Verified {
+		t.Errorf("top = %+v, want only alice's verified run", top)
+	}
+}
+
+func TestSubmitIgnoresRetries(t *testing.T) {
+	s, err := NewServer(storage.NewMemoryBackend())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := playedRun(t, "alice")
+	first, err := s.Submit(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry, err := s.Submit(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if retry.ID != first.ID {
+		t.Errorf("retry stored as %d, want the first entry %d", retry.ID, first.ID)
+	}
+	if n := len(s.History("alice")); n != 1 {
+		t.Errorf("%d entries stored, want 1", n)
+	}
+}
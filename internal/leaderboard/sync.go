@@ -0,0 +1,303 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+const (
+	syncInterval = 30 * time.Second // How often the outbox and global scores are refreshed
+	minBackoff   = 5 * time.Second
+	maxBackoff   = 30 * time.Minute
+	globalLimit  = 10
+)
+
+// Syncer submits finished runs to a leaderboard server. Runs are queued in
+// storage first, so they survive restarts and are retried with backoff
+// while the server is unreachable.
+type Syncer struct {
+	Cache string // File the global scores are kept in between runs, empty for none
+
+	client  *Client
+	storage *storage.Storage
+	player  string
+
+	mu        sync.Mutex
+	query     Query   // Segment of the global scores shown
+	global    []Entry // Latest global top scores
+	globalErr error
+	wake      chan struct{}
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewSyncer creates a syncer submitting runs under the given player name,
+// or under the name each run was recorded with when it is empty
+func NewSyncer(client *Client, s *storage.Storage, player string) *Syncer {
+	return &Syncer{
+		client:  client,
+		storage: s,
+		player:  player,
+		query:   Query{Mode: DefaultMode, Limit: globalLimit},
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start runs the background sync loop until Stop is called
+func (s *Syncer) Start() {
+	// Show the last known scores until the first fetch completes
+	s.loadCache()
+
+	go func() {
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+
+		for {
+			s.Flush()
+			s.refreshGlobal()
+
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background sync loop
+func (s *Syncer) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Enqueue queues an archived run for submission and wakes the sync loop
+func (s *Syncer) Enqueue(r *replay.Replay) error {
+	player := s.player
+	if player == "" {
+		player = r.Player
+	}
+	mode, board := r.Config.Game.Mode, r.Config.Game.GridSize
+	if mode == "" {
+		mode = DefaultMode
+	}
+	if mode == game.ModeRoyale {
+		board = r.Config.Royale.GridSize
+	}
+	s.storage.QueueScore(storage.QueuedScore{
+		Player:     player,
+		Score:      r.Result.Score,
+		Length:     r.Result.Length,
+		Ticks:      r.Result.Ticks,
+		Mode:       mode,
+		Board:      board,
+		Difficulty: r.Difficulty,
+		Date:       r.Date,
+		Replay:     r.FileName(),
+	})
+	if err := s.storage.Save(); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Flush submits every queued run that is due, rescheduling failures
+func (s *Syncer) Flush() {
+	now := time.Now()
+	changed := false
+	for _, q := range s.storage.GetOutbox() {
+		if q.NextAttempt.After(now) {
+			continue
+		}
+		changed = true
+
+		sub := Submission{
+			Player:     q.Player,
+			Score:      q.Score,
+			Length:     q.Length,
+			Ticks:      q.Ticks,
+			Mode:       q.Mode,
+			Board:      q.Board,
+			Difficulty: q.Difficulty,
+			Date:       q.Date,
+		}
+		if q.Replay != "" {
+			if data, err := s.storage.ReadReplay(q.Replay); err == nil {
+				sub.Replay = data
+			}
+		}
+
+		_, err := s.client.Submit(sub)
+
+		var rejected *RejectedError
+		switch {
+		case err == nil:
+			s.remove(q)
+		case errors.As(err, &rejected):
+			// Retrying a rejected run would never succeed
+			log.Printf("Dropping queued score: %v", err)
+			s.remove(q)
+		default:
+			s.reschedule(q, now)
+		}
+	}
+
+	if !changed {
+		return
+	}
+	if err := s.storage.Save(); err != nil {
+		log.Printf("Failed to save leaderboard outbox: %v", err)
+	}
+}
+
+// remove drops a run from the outbox
+func (s *Syncer) remove(q storage.QueuedScore) {
+	s.storage.UpdateOutbox(func(outbox []storage.QueuedScore) []storage.QueuedScore {
+		kept := outbox[:0]
+		for _, o := range outbox {
+			if !sameRun(o, q) {
+				kept = append(kept, o)
+			}
+		}
+		return kept
+	})
+}
+
+// reschedule backs off a run that could not be delivered
+func (s *Syncer) reschedule(q storage.QueuedScore, now time.Time) {
+	s.storage.UpdateOutbox(func(outbox []storage.QueuedScore) []storage.QueuedScore {
+		for i := range outbox {
+			if sameRun(outbox[i], q) {
+				outbox[i].Attempts++
+				outbox[i].NextAttempt = now.Add(backoff(outbox[i].Attempts))
+			}
+		}
+		return outbox
+	})
+}
+
+// backoff doubles the wait after every failed attempt, up to a limit
+func backoff(attempts int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// sameRun identifies a queued run by player and finish time
+func sameRun(a, b storage.QueuedScore) bool {
+	return a.Player == b.Player && a.Date.Equal(b.Date)
+}
+
+// refreshGlobal fetches the global top scores of the segment shown
+func (s *Syncer) refreshGlobal() {
+	s.mu.Lock()
+	q := s.query
+	s.mu.Unlock()
+
+	entries, err := s.client.Top(q)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q != s.query {
+		// Another segment was asked for meanwhile, and is fetched next
+		return
+	}
+	s.globalErr = err
+	if err == nil {
+		s.global = entries
+		s.saveCache(entries)
+	}
+}
+
+// loadCache reads the global scores saved by an earlier run
+func (s *Syncer) loadCache() {
+	if s.Cache == "" {
+		return
+	}
+	data, err := ioutil.ReadFile(s.Cache)
+	if err != nil {
+		return
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.global == nil {
+		s.global = entries
+	}
+}
+
+// saveCache keeps the global scores for the next run. Must be called with
+// the lock held.
+func (s *Syncer) saveCache(entries []Entry) {
+	if s.Cache == "" {
+		return
+	}
+	data, err := json.Marshal(entries)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(s.Cache), 0755)
+	}
+	if err == nil {
+		err = storage.WriteFileAtomic(s.Cache, data, 0644)
+	}
+	if err != nil {
+		log.Printf("Failed to cache global scores: %v", err)
+	}
+}
+
+// GlobalTop returns the last fetched global top scores in the mode, board
+// and difficulty of a ruleset, and the error of the last fetch if it
+// failed. Asking for another segment than before fetches it in the
+// background.
+func (s *Syncer) GlobalTop(rules storage.Ruleset) ([]Entry, error) {
+	q := Query{Mode: rules.Mode, Board: rules.Board, Difficulty: rules.Difficulty, Limit: globalLimit}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q != s.query {
+		s.query = q
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	// Scores of the segment shown before, or cached by an earlier run,
+	// may not be in this one
+	var entries []Entry
+	for _, e := range s.global {
+		if q.Matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, s.globalErr
+}
+
+// Pending returns how many runs are waiting to be submitted
+func (s *Syncer) Pending() int {
+	return len(s.storage.GetOutbox())
+}
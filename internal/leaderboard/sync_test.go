@@ -0,0 +1,49 @@
+package leaderboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+func TestFlushKeepsRunsOnTransientErrors(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		kept   bool
+	}{
+		{http.StatusNotFound, true},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusBadGateway, true},
+		{http.StatusBadRequest, false},
+		{http.StatusUnprocessableEntity, false},
+		{http.StatusCreated, false},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			w.Write([]byte("{}"))
+		}))
+
+		s, err := storage.NewStorage(storage.NewMemoryBackend())
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.QueueScore(storage.QueuedScore{Player: "alice", Score: 30, Mode: DefaultMode, Date: time.Now()})
+
+		syncer := NewSyncer(NewClient(srv.URL), s, "")
+		syncer.Flush()
+		srv.Close()
+
+		outbox := s.GetOutbox()
+		if kept := len(outbox) == 1; kept != tc.kept {
+			t.Errorf("status %d: run kept = %v, want %v", tc.status, kept, tc.kept)
+			continue
+		}
+		if tc.kept && !outbox[0].NextAttempt.After(time.Now()) {
+			t.Errorf("status %d: retry not backed off", tc.status)
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package netplay
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+const (
+	pingInterval = time.Second
+	maxAhead     = 20 // Most ticks the prediction may run ahead of the server
+)
+
+// Client connects a front-end to a hosted game and mirrors its state.
+// Players see their own snake predicted ahead of the server from their
+// inputs; the prediction is rolled back and re-simulated whenever the
+// server's ticks disagree with it.
+type Client struct {
+	config *config.Config
+	conn   *conn
+
+	spectator bool
+
+	mu        sync.Mutex
+	self      int
+	follow    int       // Snake the camera follows
+	state     *Snapshot // Authoritative state, nil until the match starts
+	lobby     *Lobby    // Latest lobby state, nil for spectators
+	startAt   time.Time // When the match starts, zero when not counting down
+	standings []Standing
+	err       error
+	done      chan struct{}
+
+	// Prediction state
+	predict     bool
+	predicted   *game.Game     // State run ahead of the server
+	pending     []pendingInput // Directions the server has not applied yet
+	seq         int
+	tickRate    time.Duration
+	lastAuth    time.Time // When the latest server tick arrived
+	corrections int       // Times the prediction had to be corrected
+	rtt         time.Duration
+}
+
+// pendingInput is a direction sent to the server but not yet acknowledged
+type pendingInput struct {
+	seq  int
+	tick int
+	dir  game.Direction
+}
+
+// NetStats describes the connection for a debug overlay
+type NetStats struct {
+	Ping        time.Duration // Round trip time, zero until measured
+	Predicting  bool
+	Ahead       int // Ticks the prediction runs ahead of the server
+	Pending     int // Directions not yet acknowledged
+	Corrections int
+}
+
+// Dialer connects to hosted games, optionally through injected latency
+// for testing how the game feels on a slow network
+type Dialer struct {
+	Latency   time.Duration // Added to each direction of the connection
+	Jitter    time.Duration // Random extra delay, up to this much
+	NoPredict bool          // Show only the server's state
+	Color     int           // Palette color to ask for when joining
+}
+
+// Dial connects to a server and joins under the given name
+func Dial(addr, name string, cfg *config.Config) (*Client, error) {
+	return (&Dialer{}).Dial(addr, name, cfg)
+}
+
+// Spectate connects to a server or broadcaster to watch without playing
+func Spectate(addr, name string, cfg *config.Config) (*Client, error) {
+	return (&Dialer{}).Spectate(addr, name, cfg)
+}
+
+// Dial connects to a server and joins under the given name
+func (d *Dialer) Dial(addr, name string, cfg *config.Config) (*Client, error) {
+	c, err := d.dial(addr, &Message{Type: MsgJoin, Name: name, Color: d.Color}, cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.predict = !d.NoPredict
+	go c.pingLoop()
+	return c, nil
+}
+
+// Spectate connects to a server or broadcaster to watch without playing
+func (d *Dialer) Spectate(addr, name string, cfg *config.Config) (*Client, error) {
+	c, err := d.dial(addr, &Message{Type: MsgSpectate, Name: name}, cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.spectator = true
+	return c, nil
+}
+
+// dial connects and sends the opening message
+func (d *Dialer) dial(addr string, hello *Message, cfg *config.Config) (*Client, error) {
+	nc, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if d.Latency > 0 || d.Jitter > 0 {
+		nc = NewLatencyConn(nc, d.Latency, d.Jitter)
+	}
+
+	c := &Client{
+		config: cfg,
+		conn:   newConn(nc),
+		done:   make(chan struct{}),
+	}
+
+	if err := c.conn.send(hello); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	go c.readLoop()
+	return c, nil
+}
+
+// readLoop applies server messages to the mirrored state
+func (c *Client) readLoop() {
+	defer close(c.done)
+
+	for {
+		m, err := c.conn.receive()
+		if err != nil {
+			c.mu.Lock()
+			if c.standings == nil && c.err == nil {
+				c.err = err
+			}
+			c.mu.Unlock()
+			return
+		}
+
+		c.mu.Lock()
+		switch m.Type {
+		case MsgLobby:
+			c.lobby = m.Lobby
+			c.startAt = time.Time{}
+			if m.Lobby != nil && m.Lobby.Countdown > 0 {
+				// The countdown left when the server sent it, less the
+				// time the message took to get here
+				c.startAt = time.Now().Add(time.Duration(m.Lobby.Countdown)*time.Millisecond - c.rtt/2)
+			}
+		case MsgWelcome:
+			c.startAt = time.Time{}
+			c.self = m.Snake
+			c.state = m.Snapshot
+			c.standings = nil
+			if m.Snake >= 0 {
+				c.follow = m.Snake
+			} else if c.state != nil && c.follow >= len(c.state.Snakes) {
+				c.follow = 0
+			}
+			c.tickRate = time.Duration(m.TickMs) * time.Millisecond
+			c.pending = nil
+			c.predicted = nil
+			c.lastAuth = time.Now()
+			c.reconcile(0)
+		case MsgDelta:
+			if c.state != nil && m.Delta != nil {
+				c.state.Apply(m.Delta)
+				c.lastAuth = time.Now()
+				c.reconcile(m.Ack)
+			}
+		case MsgPong:
+			c.rtt = time.Since(time.Unix(0, m.Time))
+		case MsgResult:
+			c.standings = m.Standings
+		case MsgError:
+			c.err = errors.New(m.Error)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// pingLoop measures the round trip time until the connection ends
+func (c *Client) pingLoop() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		if c.conn.send(&Message{Type: MsgPing, Time: time.Now().UnixNano()}) != nil {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// predicting reports whether the client runs its own snake ahead of the
+// server. Must be called with the lock held.
+func (c *Client) predicting() bool {
+	return c.predict && c.state != nil && c.tickRate > 0 && c.self >= 0 &&
+		c.self < len(c.state.Snakes) && c.state.State == game.Playing
+}
+
+// reconcile rebuilds the prediction from the latest server state: inputs
+// the server has applied are dropped, the rest are replayed on top of the
+// server's tick up to where the prediction had got to. Must be called with
+// the lock held.
+func (c *Client) reconcile(ack int) {
+	kept := c.pending[:0]
+	for _, in := range c.pending {
+		if in.seq > ack {
+			kept = append(kept, in)
+		}
+	}
+	c.pending = kept
+
+	if !c.predicting() {
+		c.predicted = nil
+		return
+	}
+
+	old := c.predicted
+	g := c.state.Game(c.config)
+	target := g.Tick
+	if old != nil && old.Tick > target {
+		target = old.Tick
+	}
+	if target > g.Tick+maxAhead {
+		target = g.Tick + maxAhead
+	}
+	c.simulate(g, target)
+
+	// Count a correction when the server disagreed with what the player
+	// was shown for their own snake
+	if old != nil && old.Tick == g.Tick && !sameSnake(old.Snakes[c.self], g.Snakes[c.self]) {
+		c.corrections++
+	}
+	c.predicted = g
+}
+
+// simulate steps a predicted game to the target tick, applying pending
+// inputs on the ticks they were made for. Must be called with the lock held.
+func (c *Client) simulate(g *game.Game, target int) {
+	base := c.state.Tick
+	for g.Tick < target && !g.IsGameOver() {
+		// Like the server, only the latest due input counts
+		var due *pendingInput
+		for i := range c.pending {
+			in := &c.pending[i]
+			if in.tick == g.Tick || (g.Tick == base && in.tick < base) {
+				due = in
+			}
+		}
+		if due != nil {
+			g.ChangeSnakeDirection(c.self, due.dir)
+		}
+		g.Step()
+	}
+}
+
+// advance runs the prediction up to the tick the server will be on when
+// an input sent now arrives. Must be called with the lock held.
+func (c *Client) advance() {
+	if !c.predicting() || c.predicted == nil {
+		return
+	}
+
+	elapsed := time.Since(c.lastAuth) + c.rtt
+	target := c.state.Tick + int(elapsed/c.tickRate) + 1
+	if target > c.state.Tick+maxAhead {
+		target = c.state.Tick + maxAhead
+	}
+	// Catch up a tick at a time so the snake never visibly jumps
+	if target > c.predicted.Tick+1 {
+		target = c.predicted.Tick + 1
+	}
+	c.simulate(c.predicted, target)
+}
+
+// sameSnake reports whether two versions of a snake look the same
+func sameSnake(a, b *game.Snake) bool {
+	return a.Alive == b.Alive && a.Direction == b.Direction && samePoints(a.Body, b.Body)
+}
+
+// SendDirection asks the server to turn the player's snake
+func (c *Client) SendDirection(dir game.Direction) error {
+	c.mu.Lock()
+	c.seq++
+	m := &Message{Type: MsgDirection, Direction: dir, Seq: c.seq}
+	if c.predicting() && c.predicted != nil {
+		c.advance()
+		m.Tick = c.predicted.Tick
+		c.pending = append(c.pending, pendingInput{seq: c.seq, tick: m.Tick, dir: dir})
+	}
+	c.mu.Unlock()
+
+	return c.conn.send(m)
+}
+
+// Self returns the index of the player's snake, or -1 when spectating
+func (c *Client) Self() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.self
+}
+
+// Spectating reports whether the client is watching rather than playing
+func (c *Client) Spectating() bool {
+	return c.spectator
+}
+
+// Follow returns the snake the camera follows
+func (c *Client) Follow() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.follow
+}
+
+// CycleFollow moves the camera to the next (step 1) or previous (step -1)
+// living snake
+func (c *Client) CycleFollow(step int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == nil || len(c.state.Snakes) == 0 {
+		return
+	}
+
+	n := len(c.state.Snakes)
+	for i := 1; i <= n; i++ {
+		next := ((c.follow+step*i)%n + n) % n
+		if c.state.Snakes[next].Alive {
+			c.follow = next
+			return
+		}
+	}
+}
+
+// Game returns a copy of the current game state, or nil before the match
+// starts. Players get their prediction, advanced to the current time.
+func (c *Client) Game() *game.Game {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == nil {
+		return nil
+	}
+	if c.predicting() && c.predicted != nil {
+		c.advance()
+		return TakeSnapshot(c.predicted).Game(c.config)
+	}
+	return c.state.Game(c.config)
+}
+
+// Stats returns ping and prediction figures for a debug overlay
+func (c *Client) Stats() NetStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := NetStats{
+		Ping:        c.rtt,
+		Predicting:  c.predicting() && c.predicted != nil,
+		Pending:     len(c.pending),
+		Corrections: c.corrections,
+	}
+	if st.Predicting {
+		st.Ahead = c.predicted.Tick - c.state.Tick
+	}
+	return st
+}
+
+// String formats the stats as one line
+func (st NetStats) String() string {
+	line := fmt.Sprintf("Ping %dms", st.Ping.Milliseconds())
+	if !st.Predicting {
+		return line + "  Prediction off"
+	}
+	return fmt.Sprintf("%s  Ahead %d  Pending %d  Corrections %d", line, st.Ahead, st.Pending, st.Corrections)
+}
+
+// Lobby returns a copy of the latest lobby state, or nil before the
+// server has sent one
+func (c *Client) Lobby() *Lobby {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lobby == nil {
+		return nil
+	}
+	l := *c.lobby
+	l.Players = append([]LobbyPlayer(nil), c.lobby.Players...)
+	return &l
+}
+
+// InLobby reports whether the player is waiting in the lobby for the
+// match to start
+func (c *Client) InLobby() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lobby != nil && c.state == nil && c.err == nil
+}
+
+// Countdown returns the time left before the match starts, or zero when
+// the lobby is not counting down
+func (c *Client) Countdown() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.startAt.IsZero() || c.state != nil {
+		return 0
+	}
+	if left := time.Until(c.startAt); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// SetProfile changes the player's name and palette color in the lobby
+func (c *Client) SetProfile(name string, color int) error {
+	return c.conn.send(&Message{Type: MsgProfile, Name: name, Color: color})
+}
+
+// SetReady tells the lobby whether the player is ready to start
+func (c *Client) SetReady(ready bool) error {
+	return c.conn.send(&Message{Type: MsgReady, Ready: ready})
+}
+
+// SetRules asks the lobby to play the next match under the given rules.
+// Only the host may change them.
+func (c *Client) SetRules(r Rules) error {
+	return c.conn.send(&Message{Type: MsgRules, Rules: &r})
+}
+
+// Standings returns the final result once the server has sent it
+func (c *Client) Standings() []Standing {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.standings
+}
+
+// Err returns the error that ended the connection, if any
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Done is closed when the connection ends
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close disconnects from the server
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,68 @@
+package netplay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// converged advances the prediction like a frame would, then reports
+// whether the client has no inputs in flight and shows its snake exactly
+// where the server's latest state leads with no more turns
+func converged(c *Client) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance()
+	if len(c.pending) > 0 || !c.predicting() || c.predicted == nil || c.predicted.Tick <= c.state.Tick {
+		return false
+	}
+	g := c.state.Game(c.config)
+	for g.Tick < c.predicted.Tick && !g.IsGameOver() {
+		g.Step()
+	}
+	return g.Tick == c.predicted.Tick && sameSnake(g.Snakes[c.self], c.predicted.Snakes[c.self])
+}
+
+func TestPredictionUnderLatency(t *testing.T) {
+	s, cfg, addr := startServer(t, 1)
+	defer s.Close()
+	// The round trip is longer than the prediction may run ahead, so
+	// every turn reaches the server after the tick it was predicted for
+	s.TickRate = 10 * time.Millisecond
+	cfg.Game.GridSize = MaxGrid
+	dialer := &Dialer{Latency: 110 * time.Millisecond, Jitter: 10 * time.Millisecond}
+
+	c, err := dialer.Dial(addr, "alice", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	waitFor(t, "the lobby", func() bool { return c.Lobby() != nil })
+	if err := c.SetReady(true); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the match to start", func() bool { return c.Game() != nil })
+
+	// Drive a lap around the middle of the board, waiting for each turn
+	// to be acknowledged before making the next
+	for _, dir := range []game.Direction{game.Down, game.Left, game.Up} {
+		if err := c.SendDirection(dir); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, "the prediction to converge", func() bool {
+			if c.Err() != nil || c.Standings() != nil {
+				t.Fatalf("match ended early: %v", c.Err())
+			}
+			return converged(c)
+		})
+		if g := c.Game(); g.Snakes[c.Self()].Direction != dir {
+			t.Errorf("snake heading %v after turning %v", g.Snakes[c.Self()].Direction, dir)
+		}
+	}
+
+	if st := c.Stats(); st.Corrections == 0 {
+		t.Errorf("no corrections counted for late turns: %+v", st)
+	}
+}
@@ -0,0 +1,174 @@
+package netplay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DiscoveryPort is the UDP port hosted games announce themselves on
+	DiscoveryPort = 7778
+
+	announceInterval = time.Second     // How often a game announces itself
+	announceTTL      = 5 * time.Second // How long a silent game stays listed
+	maxAnnounceSize  = 1024
+)
+
+// Announcement describes a hosted game to browsers on the local network
+type Announcement struct {
+	Name    string `json:"name"`
+	Mode    string `json:"mode"`
+	Players int    `json:"players"` // Players waiting for the next match
+	Needed  int    `json:"needed"`  // Players needed to start a match
+	Playing bool   `json:"playing"` // Whether a match is in progress
+	Board   int    `json:"board"`
+	Port    int    `json:"port"` // TCP port of the game server
+
+	// Filled in by the browser
+	Addr     string    `json:"-"` // host:port to join
+	LastSeen time.Time `json:"-"`
+}
+
+// Announcer periodically broadcasts a game's announcement
+type Announcer struct {
+	Target   string // UDP address announcements are sent to
+	Interval time.Duration
+	Status   func() Announcement // Called for each announcement
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewAnnouncer creates an announcer broadcasting on the discovery port
+func NewAnnouncer(status func() Announcement) *Announcer {
+	return &Announcer{
+		Target:   fmt.Sprintf("255.255.255.255:%d", DiscoveryPort),
+		Interval: announceInterval,
+		Status:   status,
+		closed:   make(chan struct{}),
+	}
+}
+
+// Run sends announcements until the announcer is closed
+func (a *Announcer) Run() error {
+	c, err := net.Dial("udp", a.Target)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	for {
+		data, err := json.Marshal(a.Status())
+		if err != nil {
+			return err
+		}
+		// A missed announcement is made up for by the next one
+		c.Write(data)
+
+		select {
+		case <-ticker.C:
+		case <-a.closed:
+			return nil
+		}
+	}
+}
+
+// Close stops the announcer
+func (a *Announcer) Close() {
+	a.closeOnce.Do(func() { close(a.closed) })
+}
+
+// Browser keeps a live list of the games announced on the local network
+type Browser struct {
+	TTL time.Duration // How long a silent game stays listed
+
+	conn net.PacketConn
+
+	mu    sync.Mutex
+	games map[string]Announcement // Keyed by join address
+}
+
+// Browse listens for announcements on addr, such as ":7778"
+func Browse(addr string) (*Browser, error) {
+	c, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Browser{
+		TTL:   announceTTL,
+		conn:  c,
+		games: make(map[string]Announcement),
+	}
+	go b.readLoop()
+	return b, nil
+}
+
+// readLoop records announcements until the browser is closed
+func (b *Browser) readLoop() {
+	buf := make([]byte, maxAnnounceSize)
+	for {
+		n, from, err := b.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		var a Announcement
+		if json.Unmarshal(buf[:n], &a) != nil || a.Port <= 0 {
+			continue
+		}
+
+		// Games are joined on the address the announcement came from
+		host, _, err := net.SplitHostPort(from.String())
+		if err != nil {
+			continue
+		}
+		a.Addr = net.JoinHostPort(host, strconv.Itoa(a.Port))
+		a.LastSeen = time.Now()
+
+		b.mu.Lock()
+		b.games[a.Addr] = a
+		b.mu.Unlock()
+	}
+}
+
+// Games returns the games announced recently, sorted by name
+func (b *Browser) Games() []Announcement {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	games := make([]Announcement, 0, len(b.games))
+	for addr, a := range b.games {
+		if time.Since(a.LastSeen) > b.TTL {
+			delete(b.games, addr)
+			continue
+		}
+		games = append(games, a)
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].Name != games[j].Name {
+			return games[i].Name < games[j].Name
+		}
+		return games[i].Addr < games[j].Addr
+	})
+	return games
+}
+
+// Addr returns the address the browser listens on
+func (b *Browser) Addr() net.Addr {
+	return b.conn.LocalAddr()
+}
+
+// Close stops listening for announcements
+func (b *Browser) Close() error {
+	return b.conn.Close()
+}
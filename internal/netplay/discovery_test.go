@@ -0,0 +1,40 @@
+package netplay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnnounceAndBrowse(t *testing.T) {
+	b, err := Browse("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	a := NewAnnouncer(func() Announcement {
+		return Announcement{Name: "loopback", Mode: "classic", Players: 1, Needed: 2, Board: 20, Port: 7777}
+	})
+	a.Target = b.Addr().String()
+	a.Interval = 10 * time.Millisecond
+	done := make(chan error, 1)
+	go func() { done <- a.Run() }()
+
+	waitFor(t, "the announcement", func() bool { return len(b.Games()) == 1 })
+	got := b.Games()[0]
+	if got.Name != "loopback" || got.Players != 1 || got.Needed != 2 || got.Board != 20 {
+		t.Errorf("browsed %+v", got)
+	}
+	if got.Addr != "127.0.0.1:7777" {
+		t.Errorf("join address %q, want 127.0.0.1:7777", got.Addr)
+	}
+
+	a.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// A game that stops announcing drops off the list
+	b.TTL = 20 * time.Millisecond
+	waitFor(t, "the game to expire", func() bool { return len(b.Games()) == 0 })
+}
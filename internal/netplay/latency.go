@@ -0,0 +1,159 @@
+package netplay
+
+import (
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// latencyConn delays everything sent and received over a connection, to
+// try out the game on a slow network without leaving the machine
+type latencyConn struct {
+	net.Conn
+	latency time.Duration
+	jitter  time.Duration
+
+	mu   sync.Mutex
+	rng  *rand.Rand
+	last time.Time // Latest delivery time handed out, keeps data in order
+
+	out    chan packet
+	in     *io.PipeReader
+	closed chan struct{}
+	once   sync.Once
+}
+
+// packet is data waiting for its delivery time
+type packet struct {
+	data []byte
+	due  time.Time
+}
+
+// NewLatencyConn wraps c so both directions are delayed by latency plus
+// up to jitter extra. Data still arrives in the order it was sent.
+func NewLatencyConn(c net.Conn, latency, jitter time.Duration) net.Conn {
+	pr, pw := io.Pipe()
+	lc := &latencyConn{
+		Conn:    c,
+		latency: latency,
+		jitter:  jitter,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		out:     make(chan packet, 1024),
+		in:      pr,
+		closed:  make(chan struct{}),
+	}
+
+	incoming := make(chan packet, 1024)
+	go lc.writeLoop()
+	go lc.readLoop(incoming)
+	go lc.deliverLoop(incoming, pw)
+	return lc
+}
+
+// due picks when data handed over now should arrive
+func (c *latencyConn) due() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delay := c.latency
+	if c.jitter > 0 {
+		delay += time.Duration(c.rng.Int63n(int64(c.jitter)))
+	}
+	at := time.Now().Add(delay)
+	if at.Before(c.last) {
+		at = c.last
+	}
+	c.last = at
+	return at
+}
+
+// wait sleeps until t, returning false if the connection closed first
+func (c *latencyConn) wait(t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-c.closed:
+		return false
+	}
+}
+
+// Write queues data to be sent once its delay has passed
+func (c *latencyConn) Write(p []byte) (int, error) {
+	data := append([]byte(nil), p...)
+	select {
+	case c.out <- packet{data: data, due: c.due()}:
+		return len(p), nil
+	case <-c.closed:
+		return 0, net.ErrClosed
+	}
+}
+
+// Read returns data once its delay has passed
+func (c *latencyConn) Read(p []byte) (int, error) {
+	return c.in.Read(p)
+}
+
+// Close closes the connection and drops anything still in flight
+func (c *latencyConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+		c.in.Close()
+	})
+	return c.Conn.Close()
+}
+
+// writeLoop sends queued data when it is due
+func (c *latencyConn) writeLoop() {
+	for {
+		select {
+		case pk := <-c.out:
+			if !c.wait(pk.due) {
+				return
+			}
+			if _, err := c.Conn.Write(pk.data); err != nil {
+				c.Close()
+				return
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// readLoop stamps incoming data with its delivery time
+func (c *latencyConn) readLoop(incoming chan<- packet) {
+	defer close(incoming)
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.Conn.Read(buf)
+		if n > 0 {
+			select {
+			case incoming <- packet{data: append([]byte(nil), buf[:n]...), due: c.due()}:
+			case <-c.closed:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// deliverLoop hands incoming data to Read when it is due
+func (c *latencyConn) deliverLoop(incoming <-chan packet, pw *io.PipeWriter) {
+	for pk := range incoming {
+		if !c.wait(pk.due) {
+			break
+		}
+		if _, err := pw.Write(pk.data); err != nil {
+			break
+		}
+	}
+	pw.Close()
+}
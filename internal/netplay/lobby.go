@@ -0,0 +1,206 @@
+package netplay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// Limits on the rules a host can pick
+const (
+	MinSpeed = 1  // Ticks per second
+	MaxSpeed = 30 // Ticks per second
+	MinGrid  = 10
+	MaxGrid  = 60
+
+	maxNameLength = 20
+)
+
+// Modes lists the game modes a host can pick, in menu order
+var Modes = []string{game.ModeClassic, game.ModeRoyale}
+
+// Rules are the settings the host picks for the next match
+type Rules struct {
+	Mode  string  `json:"mode"`
+	Level string  `json:"level"`
+	Speed float64 `json:"speed"` // Ticks per second
+	Grid  int     `json:"grid"`  // Board size of classic games; royale uses its own
+}
+
+// RulesFromConfig returns the rules a server starts with
+func RulesFromConfig(cfg *config.Config, tickRate time.Duration) Rules {
+	r := Rules{
+		Mode:  cfg.Game.Mode,
+		Level: cfg.Game.Level,
+		Speed: float64(time.Second) / float64(tickRate),
+		Grid:  cfg.Game.GridSize,
+	}
+	if r.Mode == "" {
+		r.Mode = game.ModeClassic
+	}
+	if r.Level == "" {
+		r.Level = game.LevelOpen
+	}
+	return r
+}
+
+// Validate checks the rules are within the limits
+func (r Rules) Validate() error {
+	if r.Mode != game.ModeClassic && r.Mode != game.ModeRoyale {
+		return fmt.Errorf("unknown mode %q", r.Mode)
+	}
+	if !game.ValidLevel(r.Level) {
+		return fmt.Errorf("unknown level %q", r.Level)
+	}
+	if r.Speed < MinSpeed || r.Speed > MaxSpeed {
+		return fmt.Errorf("speed must be %d to %d ticks per second", MinSpeed, MaxSpeed)
+	}
+	if r.Grid < MinGrid || r.Grid > MaxGrid {
+		return fmt.Errorf("board size must be %d to %d", MinGrid, MaxGrid)
+	}
+	return nil
+}
+
+// Apply returns a copy of cfg with the rules filled in
+func (r Rules) Apply(cfg *config.Config) *config.Config {
+	c := *cfg
+	c.Game.Mode = r.Mode
+	c.Game.Level = r.Level
+	c.Game.GridSize = r.Grid
+	return &c
+}
+
+// TickRate returns the time between ticks
+func (r Rules) TickRate() time.Duration {
+	return time.Duration(float64(time.Second) / r.Speed)
+}
+
+// String summarizes the rules for a status line
+func (r Rules) String() string {
+	return fmt.Sprintf("%s, %s level, %dx%d, %.0f ticks/s", r.Mode, r.Level, r.Grid, r.Grid, r.Speed)
+}
+
+// LobbyPlayer is one seat in the lobby
+type LobbyPlayer struct {
+	Name  string `json:"name"`
+	Color int    `json:"color,omitempty"` // Palette color, 0 for the default
+	Ready bool   `json:"ready"`
+	Bot   bool   `json:"bot,omitempty"`
+}
+
+// Lobby is the room players wait in before a match. The first player to
+// join is the host and picks the rules; the match starts with a countdown
+// once enough players have joined and all of them are ready.
+type Lobby struct {
+	Players   []LobbyPlayer `json:"players"`
+	You       int           `json:"you"`    // Seat of the player receiving the lobby
+	Host      int           `json:"host"`   // Seat of the player who picks the rules
+	Needed    int           `json:"needed"` // Players needed to start
+	Max       int           `json:"max"`    // Most players the lobby takes
+	Rules     Rules         `json:"rules"`
+	Countdown int           `json:"countdown,omitempty"` // Milliseconds until the match starts, 0 when not counting down
+}
+
+// IsHost reports whether the receiving player picks the rules
+func (l *Lobby) IsHost() bool {
+	return l.You == l.Host
+}
+
+// ColorTaken reports whether a seat other than the receiving player's
+// already uses palette color c
+func (l *Lobby) ColorTaken(c int) bool {
+	if c == 0 {
+		return false
+	}
+	for i, p := range l.Players {
+		if i != l.You && p.Color == c {
+			return true
+		}
+	}
+	return false
+}
+
+// NextColor returns the next palette color after the receiving player's
+// in the given direction that nobody else uses. Colors run from 0, the
+// default, to colors.
+func (l *Lobby) NextColor(step, colors int) int {
+	cur := 0
+	if l.You >= 0 && l.You < len(l.Players) {
+		cur = l.Players[l.You].Color
+	}
+
+	n := colors + 1
+	for i := 1; i <= n; i++ {
+		c := ((cur+step*i)%n + n) % n
+		if !l.ColorTaken(c) {
+			return c
+		}
+	}
+	return cur
+}
+
+// RuleField names a setting in Rules
+type RuleField int
+
+const (
+	RuleMode RuleField = iota
+	RuleLevel
+	RuleSpeed
+	RuleGrid
+)
+
+// Step returns the rules with one setting moved to its next (step 1) or
+// previous (step -1) value, staying within the limits
+func (r Rules) Step(field RuleField, step int) Rules {
+	switch field {
+	case RuleMode:
+		r.Mode = cycle(Modes, r.Mode, step)
+	case RuleLevel:
+		r.Level = cycle(game.Levels, r.Level, step)
+	case RuleSpeed:
+		r.Speed = float64(clampInt(int(r.Speed+0.5)+step, MinSpeed, MaxSpeed))
+	case RuleGrid:
+		r.Grid = clampInt(r.Grid+2*step, MinGrid, MaxGrid)
+	}
+	return r
+}
+
+// cycle returns the value step places from cur in values, wrapping around
+func cycle(values []string, cur string, step int) string {
+	i := 0
+	for j, v := range values {
+		if v == cur {
+			i = j
+		}
+	}
+	n := len(values)
+	return values[((i+step)%n+n)%n]
+}
+
+// clampInt limits v to the range lo..hi
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// cleanName trims a player name to something fit for display
+func cleanName(name string) string {
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, name))
+	if r := []rune(name); len(r) > maxNameLength {
+		name = string(r[:maxNameLength])
+	}
+	return name
+}
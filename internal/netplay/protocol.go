@@ -0,0 +1,124 @@
+package netplay
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// DefaultPort is the TCP port hosted games listen on by default
+const DefaultPort = 7777
+
+// writeTimeout is how long a peer may hold up a message by not reading
+// before it is disconnected, so one stalled client cannot freeze a match
+const writeTimeout = 500 * time.Millisecond
+
+// Message types. Clients send join, spectate, profile, ready, rules, dir
+// and ping; the server sends the rest.
+const (
+	MsgJoin      = "join"     // Name, Color
+	MsgSpectate  = "spectate" // Name
+	MsgProfile   = "profile"  // Name, Color, while in the lobby
+	MsgReady     = "ready"    // Ready, while in the lobby
+	MsgRules     = "rules"    // Rules, from the host while in the lobby
+	MsgDirection = "dir"      // Direction, Seq, Tick the client expects it to apply on
+	MsgPing      = "ping"     // Time
+	MsgLobby     = "lobby"    // Lobby, whenever it changes
+	MsgWelcome   = "welcome"  // Snake (-1 for spectators), Snapshot, TickMs
+	MsgDelta     = "delta"    // Delta, Ack (players only)
+	MsgPong      = "pong"     // Time, echoed from the ping
+	MsgResult    = "result"   // Standings
+	MsgError     = "error"    // Error
+)
+
+// Message is a single line-delimited JSON protocol message
+type Message struct {
+	Type      string         `json:"type"`
+	Name      string         `json:"name,omitempty"`
+	Snake     int            `json:"snake,omitempty"`
+	Direction game.Direction `json:"dir,omitempty"`
+	Snapshot  *Snapshot      `json:"snapshot,omitempty"`
+	Delta     *Delta         `json:"delta,omitempty"`
+	Standings []Standing     `json:"standings,omitempty"`
+	Error     string         `json:"error,omitempty"`
+
+	// Lobby
+	Color int    `json:"color,omitempty"`
+	Ready bool   `json:"ready,omitempty"`
+	Rules *Rules `json:"rules,omitempty"`
+	Lobby *Lobby `json:"lobby,omitempty"`
+
+	// Prediction support
+	Seq    int   `json:"seq,omitempty"`     // Client's direction sequence number
+	Tick   int   `json:"tick,omitempty"`    // Tick a direction should apply on
+	Ack    int   `json:"ack,omitempty"`     // Latest direction Seq the server applied
+	Time   int64 `json:"time,omitempty"`    // Ping timestamp, echoed by pong
+	TickMs int   `json:"tick_ms,omitempty"` // Milliseconds between server ticks
+}
+
+// Standing is one snake's final placing
+type Standing struct {
+	Snake  int    `json:"snake"`
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+	Length int    `json:"length"`
+	Winner bool   `json:"winner"`
+}
+
+// Standings summarizes a finished game
+func Standings(g *game.Game) []Standing {
+	winner := g.Winner()
+	standings := make([]Standing, len(g.Snakes))
+	for i, s := range g.Snakes {
+		standings[i] = Standing{
+			Snake:  i,
+			Name:   s.Name,
+			Score:  s.Score,
+			Length: len(s.Body),
+			Winner: winner == s,
+		}
+	}
+	return standings
+}
+
+// conn wraps a network connection with message framing
+type conn struct {
+	net.Conn
+	dec *json.Decoder
+
+	mu  sync.Mutex // Serializes writers
+	enc *json.Encoder
+}
+
+func newConn(c net.Conn) *conn {
+	return &conn{
+		Conn: c,
+		dec:  json.NewDecoder(c),
+		enc:  json.NewEncoder(c),
+	}
+}
+
+// send writes one message, closing the connection if it cannot be
+// written in time
+func (c *conn) send(m *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := c.enc.Encode(m); err != nil {
+		c.Close()
+		return err
+	}
+	return nil
+}
+
+// receive reads one message
+func (c *conn) receive() (*Message, error) {
+	var m Message
+	if err := c.dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
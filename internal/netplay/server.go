@@ -0,0 +1,573 @@
+package netplay
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/bot"
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// DefaultCountdown is how long the lobby counts down before a match
+const DefaultCountdown = 3 * time.Second
+
+// Server hosts multi-snake games over TCP. Players gather in a lobby and
+// ready up; then clients send direction intents and the server advances
+// the authoritative game on a fixed tick, broadcasting each tick's changes.
+type Server struct {
+	Name       string // Shown to players browsing the local network
+	Config     *config.Config
+	Players    int           // Snakes needed before a match starts
+	MaxPlayers int           // Most players the lobby takes
+	TickRate   time.Duration // Default time between ticks, until the host changes the speed
+	Countdown  time.Duration // Time between everyone being ready and the match starting
+	Logf       func(format string, args ...interface{})
+
+	// Spectators watch matches without taking part
+	Spectators *Broadcaster
+
+	// Bots take the seats after the players in every match
+	Bots []bot.Controller
+
+	mu      sync.Mutex
+	waiting []*serverClient  // Players in the lobby, the host first
+	rules   *Rules           // Rules for the next match, nil until first needed
+	startAt time.Time        // When the countdown ends, zero when not counting down
+	playing bool             // Whether a match is in progress
+	intents map[int][]intent // Directions per snake waiting for their tick
+	acks    map[int]int      // Latest applied direction Seq per snake
+	tick    int              // Current tick of the match
+	changed chan struct{}    // Signalled when the lobby changes
+	closed  chan struct{}
+	ln      net.Listener
+}
+
+const (
+	maxIntentLead = 50            // Most ticks ahead a client may schedule a turn
+	maxIntents    = maxIntentLead // Most turns queued per snake, about one per tick of lead
+)
+
+// intent is a direction change waiting to be applied
+type intent struct {
+	dir  game.Direction
+	seq  int
+	tick int
+}
+
+// serverClient is a connected player
+type serverClient struct {
+	*conn
+	name    string
+	color   int
+	ready   bool
+	snake   int
+	playing bool // Whether the player is in the current match
+}
+
+// NewServer creates a server for matches of the given number of players
+func NewServer(cfg *config.Config, players int) *Server {
+	if players < 1 {
+		players = 1
+	}
+	max := players
+	if max < 8 {
+		max = 8
+	}
+
+	return &Server{
+		Config:     cfg,
+		Players:    players,
+		MaxPlayers: max,
+		TickRate:   time.Duration(float64(time.Second) / cfg.Game.InitialSpeed),
+		Countdown:  DefaultCountdown,
+		Logf:       log.Printf,
+		Spectators: NewBroadcaster(0),
+		changed:    make(chan struct{}, 1),
+		closed:     make(chan struct{}),
+	}
+}
+
+// ListenAndServe listens on addr and serves matches until closed
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts players on ln and runs one match after another until the
+// server is closed
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+
+	go s.acceptLoop(ln)
+
+	for {
+		players, rules, err := s.waitForPlayers()
+		if err != nil {
+			return nil
+		}
+		s.runMatch(players, rules)
+	}
+}
+
+// Close stops accepting players and ends the current match
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.closed:
+		return nil
+	default:
+	}
+	close(s.closed)
+
+	for _, c := range s.waiting {
+		c.Close()
+	}
+	s.Spectators.Close()
+	if s.ln != nil {
+		return s.ln.Close()
+	}
+	return nil
+}
+
+// Status describes the server for LAN discovery
+func (s *Server) Status() Announcement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules := s.currentRules()
+	a := Announcement{
+		Name:    s.Name,
+		Mode:    rules.Mode,
+		Players: len(s.waiting),
+		Needed:  s.Players,
+		Playing: s.playing,
+		Board:   rules.Grid,
+	}
+	if a.Mode == game.ModeRoyale {
+		a.Board = s.Config.Royale.GridSize
+	}
+	if s.ln != nil {
+		if addr, ok := s.ln.Addr().(*net.TCPAddr); ok {
+			a.Port = addr.Port
+		}
+	}
+	return a
+}
+
+// currentRules returns the rules for the next match, starting from the
+// config. Must be called with the lock held.
+func (s *Server) currentRules() Rules {
+	if s.rules == nil {
+		r := RulesFromConfig(s.Config, s.TickRate)
+		s.rules = &r
+	}
+	return *s.rules
+}
+
+// acceptLoop handles incoming connections until the listener closes
+func (s *Server) acceptLoop(ln net.Listener) {
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			s.Close()
+			return
+		}
+		go s.handshake(newConn(nc))
+	}
+}
+
+// handshake reads a client's join message and adds it to the lobby
+func (s *Server) handshake(c *conn) {
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	m, err := c.receive()
+	c.SetReadDeadline(time.Time{})
+	if err != nil || (m.Type != MsgJoin && m.Type != MsgSpectate) {
+		c.send(&Message{Type: MsgError, Error: "expected join or spectate"})
+		c.Close()
+		return
+	}
+
+	// Spectators can join at any time, including mid-match
+	if m.Type == MsgSpectate {
+		s.Logf("Spectator %q joined", m.Name)
+		s.Spectators.Add(c)
+		return
+	}
+
+	s.mu.Lock()
+	if len(s.waiting) >= s.MaxPlayers {
+		s.mu.Unlock()
+		c.send(&Message{Type: MsgError, Error: "game is full"})
+		c.Close()
+		return
+	}
+	client := &serverClient{conn: c, name: cleanName(m.Name)}
+	if client.name == "" {
+		client.name = fmt.Sprintf("Player %d", len(s.waiting)+1)
+	}
+	if !s.colorTaken(m.Color, client) {
+		client.color = m.Color
+	}
+	s.waiting = append(s.waiting, client)
+	count := len(s.waiting)
+	s.mu.Unlock()
+
+	s.Logf("Player %q joined (%d/%d)", client.name, count, s.Players)
+	go s.readLoop(client)
+	s.lobbyChanged()
+}
+
+// colorTaken reports whether a player other than p in the lobby uses
+// palette color c. Must be called with the lock held.
+func (s *Server) colorTaken(c int, p *serverClient) bool {
+	if c == 0 {
+		return false
+	}
+	for _, w := range s.waiting {
+		if w != p && w.color == c {
+			return true
+		}
+	}
+	return false
+}
+
+// lobbyChanged sends everyone in the lobby its new state and wakes the
+// match starter
+func (s *Server) lobbyChanged() {
+	s.mu.Lock()
+	rules := s.currentRules()
+	countdown := 0
+	if !s.startAt.IsZero() {
+		countdown = int(time.Until(s.startAt) / time.Millisecond)
+		if countdown < 1 {
+			countdown = 1
+		}
+	}
+
+	seats := make([]LobbyPlayer, 0, len(s.waiting)+len(s.Bots))
+	for _, w := range s.waiting {
+		seats = append(seats, LobbyPlayer{Name: w.name, Color: w.color, Ready: w.ready})
+	}
+	for i := range s.Bots {
+		seats = append(seats, LobbyPlayer{Name: fmt.Sprintf("Bot %d", i+1), Ready: true, Bot: true})
+	}
+	waiting := append([]*serverClient(nil), s.waiting...)
+	s.mu.Unlock()
+
+	for i, w := range waiting {
+		w.send(&Message{Type: MsgLobby, Lobby: &Lobby{
+			Players:   seats,
+			You:       i,
+			Needed:    s.Players,
+			Max:       s.MaxPlayers,
+			Rules:     rules,
+			Countdown: countdown,
+		}})
+	}
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// leave removes a disconnected player from the lobby
+func (s *Server) leave(p *serverClient) {
+	s.mu.Lock()
+	found := false
+	for i, w := range s.waiting {
+		if w == p {
+			s.waiting = append(s.waiting[:i:i], s.waiting[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if found {
+		s.Logf("Player %q left the lobby", p.name)
+		s.lobbyChanged()
+	}
+}
+
+// ready reports whether the lobby can start a match. Must be called with
+// the lock held.
+func (s *Server) ready() bool {
+	if len(s.waiting) < s.Players {
+		return false
+	}
+	for _, w := range s.waiting {
+		if !w.ready {
+			return false
+		}
+	}
+	return true
+}
+
+// waitForPlayers blocks until enough players are in the lobby, all of
+// them ready, and the countdown has run out. Anyone leaving or unreadying
+// cancels the countdown.
+func (s *Server) waitForPlayers() ([]*serverClient, Rules, error) {
+	for {
+		s.mu.Lock()
+		ready := s.ready()
+		counting := !s.startAt.IsZero()
+		if ready && counting && !time.Now().Before(s.startAt) {
+			players, rules := s.waiting, s.currentRules()
+			s.waiting = nil
+			s.startAt = time.Time{}
+			for i, p := range players {
+				p.ready = false
+				p.playing = true
+				p.snake = i
+			}
+			s.mu.Unlock()
+			return players, rules, nil
+		}
+
+		// Start or cancel the countdown
+		changed := ready != counting
+		if changed {
+			if ready {
+				s.startAt = time.Now().Add(s.Countdown)
+			} else {
+				s.startAt = time.Time{}
+			}
+		}
+		startAt := s.startAt
+		s.mu.Unlock()
+
+		if changed {
+			s.lobbyChanged()
+			continue
+		}
+
+		var countdown <-chan time.Time
+		var timer *time.Timer
+		if !startAt.IsZero() {
+			timer = time.NewTimer(time.Until(startAt))
+			countdown = timer.C
+		}
+
+		select {
+		case <-s.changed:
+		case <-countdown:
+		case <-s.closed:
+			return nil, Rules{}, errors.New("server closed")
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// runMatch plays one game with the given players under the host's rules
+func (s *Server) runMatch(players []*serverClient, rules Rules) {
+	cfg := rules.Apply(s.Config)
+	tickRate := rules.TickRate()
+
+	g := game.NewMultiplayerGame(cfg, time.Now().UnixNano(), len(players)+len(s.Bots))
+	for i, p := range players {
+		g.Snakes[i].Name = p.name
+		g.Snakes[i].Color = p.color
+	}
+
+	// Bots answer within half a tick so the match keeps its pace
+	var pilot *bot.Pilot
+	if len(s.Bots) > 0 {
+		controllers := make(map[int]bot.Controller)
+		for i, c := range s.Bots {
+			seat := len(players) + i
+			controllers[seat] = c
+			g.Snakes[seat].Name = fmt.Sprintf("Bot %d", i+1)
+		}
+		pilot = bot.NewPilot(controllers)
+		pilot.Timeout = tickRate / 2
+		pilot.Logf = s.Logf
+		defer pilot.End(g)
+	}
+
+	s.mu.Lock()
+	s.intents = make(map[int][]intent)
+	s.acks = make(map[int]int)
+	s.tick = 0
+	s.playing = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.playing = false
+		s.intents = nil
+		s.mu.Unlock()
+	}()
+
+	// Send everyone the starting state and their snake
+	prev := TakeSnapshot(g)
+	tickMs := int(tickRate / time.Millisecond)
+	for _, p := range players {
+		p.send(&Message{Type: MsgWelcome, Snake: p.snake, Snapshot: prev, TickMs: tickMs})
+	}
+	s.Spectators.Publish(prev)
+	s.Logf("Match started with %d players: %s", len(players), rules)
+
+	ticker := time.NewTicker(tickRate)
+	defer ticker.Stop()
+
+	for !g.IsGameOver() {
+		select {
+		case <-ticker.C:
+		case <-s.closed:
+			g.State = game.GameOver
+		}
+
+		// Apply the latest intent of each snake that is due, then advance
+		s.mu.Lock()
+		acks := make(map[int]int, len(s.acks))
+		for snake, queue := range s.intents {
+			// Only the latest due intent counts, as if it had
+			// overwritten the earlier ones
+			due := 0
+			for due < len(queue) && queue[due].tick <= g.Tick {
+				due++
+			}
+			if due > 0 {
+				latest := queue[due-1]
+				g.ChangeSnakeDirection(snake, latest.dir)
+				s.acks[snake] = latest.seq
+				s.intents[snake] = queue[due:]
+			}
+		}
+		for snake, seq := range s.acks {
+			acks[snake] = seq
+		}
+		s.mu.Unlock()
+
+		if pilot != nil {
+			pilot.Steer(g)
+		}
+		g.Step()
+
+		s.mu.Lock()
+		s.tick = g.Tick
+		s.mu.Unlock()
+
+		next := TakeSnapshot(g)
+		delta := prev.Diff(next)
+		prev = next
+		for _, p := range players {
+			p.send(&Message{Type: MsgDelta, Delta: delta, Ack: acks[p.snake]})
+		}
+		s.Spectators.Publish(next)
+	}
+
+	// The server owns the result
+	standings := Standings(g)
+	s.Spectators.Finish(standings)
+	for _, p := range players {
+		p.send(&Message{Type: MsgResult, Standings: standings})
+		p.Close()
+	}
+	s.Logf("Match finished after %d ticks", g.Tick)
+}
+
+// readLoop handles a player's messages, lobby changes first and direction
+// intents once the match is on, until it disconnects
+func (s *Server) readLoop(p *serverClient) {
+	for {
+		m, err := p.receive()
+		if err != nil {
+			s.leave(p)
+			return
+		}
+
+		switch m.Type {
+		case MsgPing:
+			p.send(&Message{Type: MsgPong, Time: m.Time})
+		case MsgProfile, MsgReady, MsgRules:
+			s.updateLobby(p, m)
+		case MsgDirection:
+			s.mu.Lock()
+			if p.playing && s.intents != nil && m.Direction.Valid() {
+				// Clients may schedule a turn for a later tick, within
+				// reason; late turns apply on the next tick
+				in := intent{dir: m.Direction, seq: m.Seq, tick: m.Tick}
+				if queue := s.intents[p.snake]; len(queue) > 0 && in.tick < queue[len(queue)-1].tick {
+					in.tick = queue[len(queue)-1].tick
+				}
+				if limit := s.tick + maxIntentLead; in.tick > limit {
+					in.tick = limit
+				}
+				// A full queue keeps the newest turn in place of the
+				// last one, so a flooding client cannot grow it
+				if queue := s.intents[p.snake]; len(queue) >= maxIntents {
+					queue[len(queue)-1] = in
+				} else {
+					s.intents[p.snake] = append(queue, in)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// updateLobby applies a lobby message from a player. Changing the rules
+// unreadies everyone so nobody starts a match they did not agree to.
+func (s *Server) updateLobby(p *serverClient, m *Message) {
+	s.mu.Lock()
+	host := len(s.waiting) > 0 && s.waiting[0] == p
+	inLobby := false
+	for _, w := range s.waiting {
+		inLobby = inLobby || w == p
+	}
+	if !inLobby {
+		s.mu.Unlock()
+		return
+	}
+
+	var reject string
+	switch m.Type {
+	case MsgProfile:
+		if name := cleanName(m.Name); name != "" {
+			p.name = name
+		}
+		if !s.colorTaken(m.Color, p) {
+			p.color = m.Color
+		}
+	case MsgReady:
+		p.ready = m.Ready
+	case MsgRules:
+		switch {
+		case !host:
+			reject = "only the host can change the rules"
+		case m.Rules == nil:
+			reject = "missing rules"
+		default:
+			if err := m.Rules.Validate(); err != nil {
+				reject = err.Error()
+				break
+			}
+			rules := *m.Rules
+			s.rules = &rules
+			for _, w := range s.waiting {
+				w.ready = false
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if reject != "" {
+		s.Logf("Rejected rules from %q: %s", p.name, reject)
+	}
+	s.lobbyChanged()
+}
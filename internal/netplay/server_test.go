@@ -0,0 +1,222 @@
+package netplay
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// startServer serves matches of the given size on a loopback port
+func startServer(t *testing.T, players int) (*Server, *config.Config, string) {
+	t.Helper()
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(cfg, players)
+	s.TickRate = 20 * time.Millisecond
+	s.Countdown = 0
+	s.Logf = func(string, ...interface{}) {}
+	go s.Serve(ln)
+	return s, cfg, ln.Addr().String()
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMatchOnLoopback(t *testing.T) {
+	s, cfg, addr := startServer(t, 2)
+	defer s.Close()
+
+	predicting, err := Dial(addr, "alice", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer predicting.Close()
+	waitFor(t, "alice to join first", func() bool { return predicting.Lobby() != nil })
+	mirror, err := (&Dialer{NoPredict: true}).Dial(addr, "bob", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mirror.Close()
+	spectator, err := Spectate(addr, "carol", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spectator.Close()
+
+	clients := []*Client{predicting, mirror}
+	waitFor(t, "both players in the lobby", func() bool {
+		for _, c := range clients {
+			if l := c.Lobby(); l == nil || len(l.Players) != 2 {
+				return false
+			}
+		}
+		return true
+	})
+	for _, c := range clients {
+		if err := c.SetReady(true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Steer once the match is on, so the server applies an intent
+	waitFor(t, "the match to start", func() bool { return predicting.Game() != nil })
+	if err := predicting.SendDirection(game.Up); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range append(clients, spectator) {
+		c := c
+		waitFor(t, "the result", func() bool { return c.Standings() != nil })
+	}
+
+	standings := mirror.Standings()
+	if len(standings) != 2 || standings[0].Name != "alice" || standings[1].Name != "bob" {
+		t.Fatalf("standings = %+v, want alice and bob", standings)
+	}
+	winners := 0
+	for _, st := range standings {
+		if st.Winner {
+			winners++
+		}
+	}
+	if winners > 1 {
+		t.Errorf("%d winners in %+v", winners, standings)
+	}
+
+	// Everyone mirroring the server from snapshots and deltas ends up
+	// with the state the standings were taken from
+	final := TakeSnapshot(mirror.Game())
+	if final.State != game.GameOver {
+		t.Errorf("final state %v, want game over", final.State)
+	}
+	for i, st := range standings {
+		sn := final.Snakes[i]
+		if sn.Score != st.Score || len(sn.Body) != st.Length {
+			t.Errorf("snake %d mirrored with score %d, length %d; standings say %d, %d", i, sn.Score, len(sn.Body), st.Score, st.Length)
+		}
+	}
+	for _, c := range []*Client{predicting, spectator} {
+		if got := c.Standings(); !reflect.DeepEqual(got, standings) {
+			t.Errorf("standings differ between clients: %+v and %+v", got, standings)
+		}
+	}
+	if watched := TakeSnapshot(spectator.Game()); !reflect.DeepEqual(watched, final) {
+		t.Errorf("spectator ended at %+v, player at %+v", watched, final)
+	}
+}
+
+func TestSendToStalledPeer(t *testing.T) {
+	// A pipe holds no data, so a peer that never reads blocks every write
+	server, peer := net.Pipe()
+	defer peer.Close()
+	c := newConn(server)
+
+	start := time.Now()
+	if err := c.send(&Message{Type: MsgPing}); err == nil {
+		t.Fatal("send to a peer that never reads succeeded")
+	}
+	if took := time.Since(start); took > 2*writeTimeout {
+		t.Errorf("send gave up after %v, want about %v", took, writeTimeout)
+	}
+
+	// The connection is closed, so later sends fail at once
+	start = time.Now()
+	if err := c.send(&Message{Type: MsgPing}); err == nil || time.Since(start) > writeTimeout/2 {
+		t.Errorf("send after a timeout returned %v after %v, want a quick error", err, time.Since(start))
+	}
+}
+
+func TestIntentQueueIsBounded(t *testing.T) {
+	s, cfg, addr := startServer(t, 1)
+	defer s.Close()
+	s.TickRate = time.Second // Keep the match going while turns pile up
+
+	c, err := Dial(addr, "alice", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	waitFor(t, "the lobby", func() bool { return c.Lobby() != nil })
+	if err := c.SetReady(true); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the match to start", func() bool { return c.Game() != nil })
+
+	// Flood the server with turns scheduled well ahead
+	const sent = 10 * maxIntents
+	for i := 1; i <= sent; i++ {
+		m := &Message{Type: MsgDirection, Direction: game.Up + game.Direction(i%2), Seq: i, Tick: 1 << 20}
+		if err := c.conn.send(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The last turn is always kept
+	queued := func() []intent {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return append([]intent(nil), s.intents[0]...)
+	}
+	waitFor(t, "the last turn to arrive", func() bool {
+		q := queued()
+		return len(q) > 0 && q[len(q)-1].seq == sent
+	})
+	if n := len(queued()); n > maxIntents {
+		t.Errorf("%d turns queued, want at most %d", n, maxIntents)
+	}
+}
+
+func TestInvalidDirectionsAreDropped(t *testing.T) {
+	s, cfg, addr := startServer(t, 1)
+	defer s.Close()
+	s.TickRate = time.Second
+
+	c, err := Dial(addr, "alice", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	waitFor(t, "the lobby", func() bool { return c.Lobby() != nil })
+	if err := c.SetReady(true); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the match to start", func() bool { return c.Game() != nil })
+
+	for i, dir := range []game.Direction{-1, game.Down + 1, 1 << 30, game.Up} {
+		m := &Message{Type: MsgDirection, Direction: dir, Seq: i + 1, Tick: 1 << 20}
+		if err := c.conn.send(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var queued []intent
+	waitFor(t, "the valid turn to arrive", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		queued = append([]intent(nil), s.intents[0]...)
+		return len(queued) > 0
+	})
+	if len(queued) != 1 || queued[0].dir != game.Up {
+		t.Errorf("queued %+v, want only the turn up", queued)
+	}
+}
@@ -0,0 +1,150 @@
+package netplay
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Broadcaster streams a game to spectators. Spectators receive a full
+// snapshot when they join and per-tick deltas afterwards, held back by
+// Delay ticks so they cannot feed information to players.
+type Broadcaster struct {
+	Delay int
+
+	mu         sync.Mutex
+	history    []*Snapshot // Recent snapshots, oldest first
+	sent       *Snapshot   // Last state sent to spectators
+	spectators []*conn
+	ln         net.Listener
+}
+
+// NewBroadcaster creates a broadcaster with the given delay in ticks
+func NewBroadcaster(delay int) *Broadcaster {
+	if delay < 0 {
+		delay = 0
+	}
+	return &Broadcaster{Delay: delay}
+}
+
+// ListenAndServe accepts spectators on addr, for games not hosted by a Server
+func (b *Broadcaster) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return b.Serve(ln)
+}
+
+// Serve accepts spectators on ln until it is closed
+func (b *Broadcaster) Serve(ln net.Listener) error {
+	b.mu.Lock()
+	b.ln = ln
+	b.mu.Unlock()
+
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			return nil
+		}
+
+		go func(c *conn) {
+			c.SetReadDeadline(time.Now().Add(10 * time.Second))
+			m, err := c.receive()
+			c.SetReadDeadline(time.Time{})
+			if err != nil || m.Type != MsgSpectate {
+				c.send(&Message{Type: MsgError, Error: "this address only accepts spectators"})
+				c.Close()
+				return
+			}
+			b.Add(c)
+		}(newConn(nc))
+	}
+}
+
+// Add starts streaming to a spectator connection
+func (b *Broadcaster) Add(c *conn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.sent != nil {
+		if c.send(&Message{Type: MsgWelcome, Snake: -1, Snapshot: b.sent}) != nil {
+			c.Close()
+			return
+		}
+	}
+	b.spectators = append(b.spectators, c)
+}
+
+// Publish records the state after a tick and forwards the delayed state
+// to spectators. A snapshot with an earlier tick than the last one starts
+// a new game.
+func (b *Broadcaster) Publish(s *Snapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n := len(b.history); n > 0 && s.Tick < b.history[n-1].Tick {
+		b.history = nil
+	}
+	b.history = append(b.history, s.Clone())
+	if len(b.history) > b.Delay+1 {
+		b.history = b.history[len(b.history)-b.Delay-1:]
+	}
+
+	b.sendLocked(b.history[0])
+}
+
+// Finish flushes the rest of the game to spectators and sends the result
+func (b *Broadcaster) Finish(standings []Standing) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n := len(b.history); n > 0 {
+		b.sendLocked(b.history[n-1])
+		b.history = b.history[n-1:]
+	}
+	b.broadcastLocked(&Message{Type: MsgResult, Standings: standings})
+}
+
+// Close disconnects all spectators and stops accepting new ones
+func (b *Broadcaster) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, c := range b.spectators {
+		c.Close()
+	}
+	b.spectators = nil
+
+	if b.ln != nil {
+		return b.ln.Close()
+	}
+	return nil
+}
+
+// sendLocked brings spectators up to the given state
+func (b *Broadcaster) sendLocked(s *Snapshot) {
+	switch {
+	case b.sent == nil || s.Tick < b.sent.Tick:
+		// New game: start from a full snapshot
+		b.broadcastLocked(&Message{Type: MsgWelcome, Snake: -1, Snapshot: s})
+	case s.Tick > b.sent.Tick:
+		b.broadcastLocked(&Message{Type: MsgDelta, Delta: b.sent.Diff(s)})
+	default:
+		return
+	}
+	b.sent = s
+}
+
+// broadcastLocked sends a message to every spectator, dropping those that fail
+func (b *Broadcaster) broadcastLocked(m *Message) {
+	kept := b.spectators[:0]
+	for _, c := range b.spectators {
+		if c.send(m) != nil {
+			c.Close()
+			continue
+		}
+		kept = append(kept, c)
+	}
+	b.spectators = kept
+}
@@ -0,0 +1,226 @@
+package netplay
+
+import (
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// SnakeState is the wire form of a snake
+type SnakeState struct {
+	ID        int            `json:"id"`
+	Name      string         `json:"name"`
+	Color     int            `json:"color,omitempty"`
+	Body      []game.Point2D `json:"body"`
+	Direction game.Direction `json:"dir"`
+	Score     int            `json:"score"`
+	Alive     bool           `json:"alive"`
+	Health    int            `json:"health"`
+	GrowCount int            `json:"grow,omitempty"`
+}
+
+// Snapshot is the full state of a game at one tick
+type Snapshot struct {
+	Tick   int            `json:"tick"`
+	Grid   int            `json:"grid"`
+	Mode   string         `json:"mode"`
+	Level  string         `json:"level,omitempty"`
+	Food   []game.Point2D `json:"food"`
+	Hazard int            `json:"hazard"`
+	State  game.GameState `json:"state"`
+	Speed  float64        `json:"speed"`
+	Snakes []SnakeState   `json:"snakes"`
+}
+
+// SnakeDelta describes how one snake changed over a tick
+type SnakeDelta struct {
+	ID        int            `json:"id"`
+	Head      *game.Point2D  `json:"head,omitempty"` // New head cell, if the snake moved
+	Trim      int            `json:"trim,omitempty"` // Tail cells removed after adding the head
+	Body      []game.Point2D `json:"body,omitempty"` // Whole body, when a head and trim cannot describe the change
+	Direction game.Direction `json:"dir"`
+	Score     int            `json:"score"`
+	Alive     bool           `json:"alive"`
+	Health    int            `json:"health"`
+	GrowCount int            `json:"grow,omitempty"`
+}
+
+// Delta describes how a snapshot changed over one or more ticks
+type Delta struct {
+	Tick   int            `json:"tick"`
+	Food   []game.Point2D `json:"food,omitempty"` // Nil when unchanged
+	Hazard int            `json:"hazard"`
+	State  game.GameState `json:"state"`
+	Speed  float64        `json:"speed"`
+	Snakes []SnakeDelta   `json:"snakes,omitempty"`
+}
+
+// TakeSnapshot captures the current state of a game
+func TakeSnapshot(g *game.Game) *Snapshot {
+	s := &Snapshot{
+		Tick:   g.Tick,
+		Grid:   g.Grid,
+		Mode:   g.Mode,
+		Level:  g.Level,
+		Food:   append([]game.Point2D(nil), g.Food...),
+		Hazard: g.Hazard,
+		State:  g.State,
+		Speed:  g.Speed,
+		Snakes: make([]SnakeState, len(g.Snakes)),
+	}
+
+	for i, snake := range g.Snakes {
+		body := make([]game.Point2D, len(snake.Body))
+		copy(body, snake.Body)
+		s.Snakes[i] = SnakeState{
+			ID:        snake.ID,
+			Name:      snake.Name,
+			Color:     snake.Color,
+			Body:      body,
+			Direction: snake.Direction,
+			Score:     snake.Score,
+			Alive:     snake.Alive,
+			Health:    snake.Health,
+			GrowCount: snake.GrowCount,
+		}
+	}
+
+	return s
+}
+
+// Clone returns a deep copy of the snapshot
+func (s *Snapshot) Clone() *Snapshot {
+	c := *s
+	c.Food = append([]game.Point2D(nil), s.Food...)
+	c.Snakes = make([]SnakeState, len(s.Snakes))
+	for i, snake := range s.Snakes {
+		c.Snakes[i] = snake
+		c.Snakes[i].Body = append([]game.Point2D(nil), snake.Body...)
+	}
+	return &c
+}
+
+// Game builds a game mirroring the snapshot, suitable for rendering and
+// for predicting ahead with Step
+func (s *Snapshot) Game(cfg *config.Config) *game.Game {
+	g := &game.Game{
+		Config: cfg,
+		Grid:   s.Grid,
+		Mode:   s.Mode,
+		Level:  s.Level,
+		Food:   append([]game.Point2D(nil), s.Food...),
+		Hazard: s.Hazard,
+		State:  s.State,
+		Speed:  s.Speed,
+		Tick:   s.Tick,
+		Snakes: make([]*game.Snake, len(s.Snakes)),
+	}
+
+	for i, snake := range s.Snakes {
+		g.Snakes[i] = &game.Snake{
+			ID:        snake.ID,
+			Name:      snake.Name,
+			Color:     snake.Color,
+			Body:      append([]game.Point2D(nil), snake.Body...),
+			Direction: snake.Direction,
+			Score:     snake.Score,
+			Alive:     snake.Alive,
+			Health:    snake.Health,
+			GrowCount: snake.GrowCount,
+		}
+	}
+
+	g.Resume()
+	return g
+}
+
+// Diff computes the delta that turns s into next
+func (s *Snapshot) Diff(next *Snapshot) *Delta {
+	d := &Delta{
+		Tick:   next.Tick,
+		Hazard: next.Hazard,
+		State:  next.State,
+		Speed:  next.Speed,
+	}
+
+	if !samePoints(next.Food, s.Food) {
+		d.Food = append([]game.Point2D{}, next.Food...)
+	}
+
+	for i, cur := range next.Snakes {
+		prev := s.Snakes[i]
+		moved := len(cur.Body) > 0 && (len(prev.Body) == 0 || cur.Body[0] != prev.Body[0])
+		if !moved && cur.Direction == prev.Direction && cur.Score == prev.Score && cur.Alive == prev.Alive && cur.Health == prev.Health && cur.GrowCount == prev.GrowCount {
+			continue
+		}
+
+		sd := SnakeDelta{
+			ID:        cur.ID,
+			Direction: cur.Direction,
+			Score:     cur.Score,
+			Alive:     cur.Alive,
+			Health:    cur.Health,
+			GrowCount: cur.GrowCount,
+		}
+		if moved {
+			// A single step is a new head plus a trimmed tail; anything
+			// else (such as a delta spanning several ticks) sends the body
+			head := cur.Body[0]
+			trim := len(prev.Body) + 1 - len(cur.Body)
+			if trim >= 0 && trim <= len(prev.Body) && samePoints(cur.Body[1:], prev.Body[:len(prev.Body)-trim]) {
+				sd.Head = &head
+				sd.Trim = trim
+			} else {
+				sd.Body = append([]game.Point2D(nil), cur.Body...)
+			}
+		}
+		d.Snakes = append(d.Snakes, sd)
+	}
+
+	return d
+}
+
+// Apply updates the snapshot in place with a delta
+func (s *Snapshot) Apply(d *Delta) {
+	s.Tick = d.Tick
+	s.Hazard = d.Hazard
+	s.State = d.State
+	s.Speed = d.Speed
+	if d.Food != nil {
+		s.Food = append([]game.Point2D(nil), d.Food...)
+	}
+
+	for _, sd := range d.Snakes {
+		if sd.ID < 0 || sd.ID >= len(s.Snakes) {
+			continue
+		}
+
+		snake := &s.Snakes[sd.ID]
+		snake.Direction = sd.Direction
+		snake.Score = sd.Score
+		snake.Alive = sd.Alive
+		snake.Health = sd.Health
+		snake.GrowCount = sd.GrowCount
+
+		if sd.Body != nil {
+			snake.Body = append([]game.Point2D(nil), sd.Body...)
+		} else if sd.Head != nil {
+			snake.Body = append([]game.Point2D{*sd.Head}, snake.Body...)
+			if sd.Trim > 0 && sd.Trim <= len(snake.Body) {
+				snake.Body = snake.Body[:len(snake.Body)-sd.Trim]
+			}
+		}
+	}
+}
+
+// samePoints reports whether two bodies are identical
+func samePoints(a, b []game.Point2D) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
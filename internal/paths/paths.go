@@ -0,0 +1,169 @@
+package paths
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// App is the directory name used inside each base directory
+const App = "go-snake"
+
+// Environment variables that override the directories
+const (
+	EnvConfigDir = "GO_SNAKE_CONFIG_DIR"
+	EnvDataDir   = "GO_SNAKE_DATA_DIR"
+	EnvCacheDir  = "GO_SNAKE_CACHE_DIR"
+)
+
+// Directories older versions kept their files in, relative to the
+// working directory
+var (
+	LegacyConfigDirs = []string{
+		filepath.Join("pkg", "config"),
+		filepath.Join("..", "pkg", "config"),
+		filepath.Join("..", "..", "pkg", "config"),
+	}
+	LegacyDataDirs = []string{
+		filepath.Join("pkg", "storage"),
+		filepath.Join("..", "pkg", "storage"),
+		filepath.Join("..", "..", "pkg", "storage"),
+	}
+)
+
+// Directories set from command line flags, which take precedence over
+// the environment
+var configDir, dataDir, cacheDir string
+
+// SetConfigDir overrides the config directory
+func SetConfigDir(dir string) { configDir = dir }
+
+// SetDataDir overrides the data directory
+func SetDataDir(dir string) { dataDir = dir }
+
+// SetCacheDir overrides the cache directory
+func SetCacheDir(dir string) { cacheDir = dir }
+
+// ConfigDir returns the directory holding config.yaml:
+// $XDG_CONFIG_HOME/go-snake on Linux and the BSDs
+func ConfigDir() string {
+	return pick(configDir, EnvConfigDir, os.UserConfigDir, LegacyConfigDirs[0])
+}
+
+// DataDir returns the directory holding scores, replays and exports:
+// $XDG_DATA_HOME/go-snake on Linux and the BSDs
+func DataDir() string {
+	return pick(dataDir, EnvDataDir, userDataDir, LegacyDataDirs[0])
+}
+
+// CacheDir returns the directory for files that can be fetched or built
+// again: $XDG_CACHE_HOME/go-snake on Linux and the BSDs
+func CacheDir() string {
+	return pick(cacheDir, EnvCacheDir, os.UserCacheDir, filepath.Join(os.TempDir(), App))
+}
+
+// pick resolves a directory from a flag, then the environment, then the
+// platform base directory, falling back when the platform has none
+func pick(flagged, env string, base func() (string, error), fallback string) string {
+	if flagged != "" {
+		return flagged
+	}
+	if dir := os.Getenv(env); dir != "" {
+		return dir
+	}
+	if dir, err := base(); err == nil {
+		return filepath.Join(dir, App)
+	}
+	return fallback
+}
+
+// userDataDir is the data counterpart of os.UserConfigDir, which the
+// standard library lacks
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return os.UserConfigDir()
+	case "darwin", "ios", "plan9":
+		return os.UserConfigDir()
+	}
+
+	// Relative paths are invalid per the XDG spec and are ignored
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// FindLegacy returns the first of dirs containing name, or "" if none do
+func FindLegacy(dirs []string, name string) string {
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Copy copies a file, or a directory and everything in it, to dst,
+// creating dst's parent directories. Existing files in dst are kept.
+func Copy(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		if _, err := os.Stat(dst); err == nil {
+			return nil
+		}
+		return copyFile(src, dst, info.Mode().Perm())
+	}
+
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	entries, err := ioutil.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := Copy(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies a single file's contents
+func copyFile(src, dst string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,78 @@
+package ebitenrender
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+)
+
+// Backend draws render primitives onto an Ebiten image
+type Backend struct {
+	Dst *ebiten.Image
+}
+
+var _ render.Backend = (*Backend)(nil)
+
+// New creates an Ebiten backend targeting dst
+func New(dst *ebiten.Image) *Backend {
+	return &Backend{Dst: dst}
+}
+
+// Clear fills the whole image
+func (b *Backend) Clear(c color.RGBA) {
+	b.Dst.Fill(c)
+}
+
+// FillRect fills a rectangle
+func (b *Backend) FillRect(x, y, w, h float32, c color.RGBA) {
+	vector.DrawFilledRect(b.Dst, x, y, w, h, c, false)
+}
+
+// StrokeLine draws a line
+func (b *Backend) StrokeLine(x1, y1, x2, y2, width float32, c color.RGBA) {
+	vector.StrokeLine(b.Dst, x1, y1, x2, y2, width, c, false)
+}
+
+// Size of a debug font character
+const (
+	charWidth  = 6
+	charHeight = 16
+)
+
+// textImage is scratch space text is printed on in the debug font's white,
+// then tinted as it is copied into place
+var textImage *ebiten.Image
+
+// DrawText draws text using the debug font in the given color
+func (b *Backend) DrawText(x, y int, text string, c color.RGBA) {
+	lines := strings.Split(text, "\n")
+	w, h := 0, len(lines)*charHeight
+	for _, line := range lines {
+		if lw := utf8.RuneCountInString(line)*charWidth + 1; lw > w {
+			w = lw
+		}
+	}
+
+	if textImage == nil || textImage.Bounds().Dx() < w || textImage.Bounds().Dy() < h {
+		iw, ih := w, h
+		if textImage != nil {
+			iw, ih = max(iw, textImage.Bounds().Dx()), max(ih, textImage.Bounds().Dy())
+			textImage.Deallocate()
+		}
+		textImage = ebiten.NewImage(iw, ih)
+	}
+	textImage.Clear()
+	ebitenutil.DebugPrintAt(textImage, text, 0, 0)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(x), float64(y))
+	op.ColorScale.ScaleWithColor(c)
+	b.Dst.DrawImage(textImage.SubImage(image.Rect(0, 0, w, h)).(*ebiten.Image), op)
+}
@@ -0,0 +1,333 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// Theme holds the colors used to draw the board
+type Theme struct {
+	Background color.RGBA
+	Grid       color.RGBA
+	SnakeHead  color.RGBA
+	SnakeBody  color.RGBA
+	Food       color.RGBA
+	Hazard     color.RGBA // Background of hazard tiles
+	Wall       color.RGBA // Wall cells of the level
+	Text       color.RGBA
+	Opponents  []color.RGBA // Body colors for snakes other than the player's
+}
+
+// opponentColors are used for other snakes in multi-snake games
+var opponentColors = []color.RGBA{
+	{R: 0x3c, G: 0xb4, B: 0xff, A: 255}, // Blue
+	{R: 0xff, G: 0xe1, B: 0x19, A: 255}, // Yellow
+	{R: 0xf0, G: 0x32, B: 0xe6, A: 255}, // Magenta
+	{R: 0x46, G: 0xf0, B: 0xf0, A: 255}, // Cyan
+	{R: 0xfa, G: 0xbe, B: 0xbe, A: 255}, // Pink
+	{R: 0xaa, G: 0xff, B: 0xc3, A: 255}, // Mint
+	{R: 0xff, G: 0xff, B: 0xff, A: 255}, // White
+	{R: 0x80, G: 0x80, B: 0xff, A: 255}, // Lavender
+}
+
+// NamedColor is a snake color players can pick
+type NamedColor struct {
+	Name  string
+	Color color.RGBA
+}
+
+// Palette lists the snake colors offered in a lobby. A snake's Color
+// field is an index into it, counting from 1.
+var Palette = []NamedColor{
+	{"Orange", color.RGBA{R: 0xff, G: 0x80, B: 0x00, A: 255}},
+	{"Purple", color.RGBA{R: 0xcc, G: 0x4c, B: 0xff, A: 255}},
+	{"Green", color.RGBA{R: 0x3c, G: 0xdc, B: 0x4b, A: 255}},
+	{"Red", color.RGBA{R: 0xe6, G: 0x19, B: 0x4b, A: 255}},
+	{"Blue", color.RGBA{R: 0x3c, G: 0xb4, B: 0xff, A: 255}},
+	{"Yellow", color.RGBA{R: 0xff, G: 0xe1, B: 0x19, A: 255}},
+	{"Magenta", color.RGBA{R: 0xf0, G: 0x32, B: 0xe6, A: 255}},
+	{"Cyan", color.RGBA{R: 0x46, G: 0xf0, B: 0xf0, A: 255}},
+	{"Pink", color.RGBA{R: 0xfa, G: 0xbe, B: 0xbe, A: 255}},
+	{"White", color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 255}},
+}
+
+// ColorName returns the name of a palette color, or "Default"
+func ColorName(c int) string {
+	if c < 1 || c > len(Palette) {
+		return "Default"
+	}
+	return Palette[c-1].Name
+}
+
+// ThemeFromConfig builds a theme from the configured colors
+func ThemeFromConfig(cfg *config.Config) Theme {
+	grid := ToRGBA(cfg.Colors.Grid)
+	grid.A = 100 // Semi-transparent grid
+	background := ToRGBA(cfg.Colors.Background)
+	food := ToRGBA(cfg.Colors.Food)
+
+	return Theme{
+		Background: background,
+		Grid:       grid,
+		SnakeHead:  ToRGBA(cfg.Colors.SnakeHead),
+		SnakeBody:  ToRGBA(cfg.Colors.SnakeBody),
+		Food:       food,
+		Hazard:     mix(food, background, 0.3),
+		Wall:       ToRGBA(cfg.Colors.Grid),
+		Text:       color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		Opponents:  opponentColors,
+	}
+}
+
+// SnakeColors returns the head and body colors for snake i, using the
+// configured colors for the player's own snake
+func (t Theme) SnakeColors(i, self int) (head, body color.RGBA) {
+	if i == self || len(t.Opponents) == 0 {
+		return t.SnakeHead, t.SnakeBody
+	}
+
+	// Opponents are numbered in order, skipping the player's snake
+	n := i
+	if i > self {
+		n--
+	}
+	body = t.Opponents[n%len(t.Opponents)]
+	return lighten(body), body
+}
+
+// ColorsOf returns the head and body colors of snake i in g: the color
+// picked in the lobby if there is one, otherwise as SnakeColors
+func (t Theme) ColorsOf(g *game.Game, i, self int) (head, body color.RGBA) {
+	if c := g.Snakes[i].Color; c >= 1 && c <= len(Palette) {
+		return PaletteColors(c)
+	}
+	return t.SnakeColors(i, self)
+}
+
+// PaletteColors returns the head and body colors of palette color c
+func PaletteColors(c int) (head, body color.RGBA) {
+	body = Palette[c-1].Color
+	return lighten(body), body
+}
+
+// mix blends two opaque colors, weighting a by w
+func mix(a, b color.RGBA, w float32) color.RGBA {
+	m := func(x, y uint8) uint8 { return uint8(float32(x)*w + float32(y)*(1-w)) }
+	return color.RGBA{R: m(a.R, b.R), G: m(a.G, b.G), B: m(a.B, b.B), A: 255}
+}
+
+// lighten brightens a color half way towards white
+func lighten(c color.RGBA) color.RGBA {
+	return color.RGBA{R: c.R/2 + 128, G: c.G/2 + 128, B: c.B/2 + 128, A: c.A}
+}
+
+// ToRGBA converts a config color to an opaque RGBA color
+func ToRGBA(c [3]float32) color.RGBA {
+	return color.RGBA{R: uint8(c[0] * 255), G: uint8(c[1] * 255), B: uint8(c[2] * 255), A: 255}
+}
+
+// Layout positions the board on the target surface
+type Layout struct {
+	TileSize int
+	OffsetX  int
+	OffsetY  int
+	Grid     bool // Draw grid lines
+	Self     int  // Snake drawn with the configured snake colors
+}
+
+// CenteredLayout centers a grid of the given size on a surface
+func CenteredLayout(grid, tileSize, width, height int) Layout {
+	return Layout{
+		TileSize: tileSize,
+		OffsetX:  (width - grid*tileSize) / 2,
+		OffsetY:  (height - grid*tileSize) / 2,
+		Grid:     true,
+	}
+}
+
+// FollowLayout keeps the focused cell in view. Boards that fit on the
+// surface are centered; larger boards scroll with the focus, clamped to
+// the board edges.
+func FollowLayout(grid, tileSize, width, height int, focus game.Point2D) Layout {
+	l := CenteredLayout(grid, tileSize, width, height)
+	size := grid * tileSize
+
+	if size > width {
+		l.OffsetX = clamp(width/2-focus.X*tileSize-tileSize/2, width-size, 0)
+	}
+	if size > height {
+		l.OffsetY = clamp(height/2-focus.Y*tileSize-tileSize/2, height-size, 0)
+	}
+
+	return l
+}
+
+// clamp limits v to the range lo..hi
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Primitive is a single draw operation
+type Primitive interface {
+	draw(b Backend)
+}
+
+// Rect is a filled rectangle
+type Rect struct {
+	X, Y, W, H float32
+	Color      color.RGBA
+}
+
+// Line is a straight stroked line
+type Line struct {
+	X1, Y1, X2, Y2 float32
+	Width          float32
+	Color          color.RGBA
+}
+
+// Text is a line of text whose top-left corner is at X, Y
+type Text struct {
+	X, Y  int
+	Text  string
+	Color color.RGBA
+}
+
+// Clear fills the whole surface
+type Clear struct {
+	Color color.RGBA
+}
+
+func (p Rect) draw(b Backend)  { b.FillRect(p.X, p.Y, p.W, p.H, p.Color) }
+func (p Line) draw(b Backend)  { b.StrokeLine(p.X1, p.Y1, p.X2, p.Y2, p.Width, p.Color) }
+func (p Text) draw(b Backend)  { b.DrawText(p.X, p.Y, p.Text, p.Color) }
+func (p Clear) draw(b Backend) { b.Clear(p.Color) }
+
+// Backend draws primitives onto a concrete surface
+type Backend interface {
+	Clear(c color.RGBA)
+	FillRect(x, y, w, h float32, c color.RGBA)
+	StrokeLine(x1, y1, x2, y2, width float32, c color.RGBA)
+	DrawText(x, y int, text string, c color.RGBA)
+}
+
+// Draw replays primitives onto a backend in order
+func Draw(b Backend, prims []Primitive) {
+	for _, p := range prims {
+		p.draw(b)
+	}
+}
+
+// Board turns the game board into draw primitives: background, hazards,
+// grid, walls, food and snakes
+func Board(g *game.Game, t Theme, l Layout) []Primitive {
+	s := float32(l.TileSize)
+	ox, oy := float32(l.OffsetX), float32(l.OffsetY)
+	size := float32(g.Grid) * s
+
+	prims := []Primitive{Clear{Color: t.Background}}
+
+	// Draw the hazard ring as four bands along the edges
+	if d := g.Hazard; d > 0 {
+		if 2*d >= g.Grid {
+			prims = append(prims, Rect{X: ox, Y: oy, W: size, H: size, Color: t.Hazard})
+		} else {
+			band := float32(d) * s
+			inner := size - 2*band
+			prims = append(prims,
+				Rect{X: ox, Y: oy, W: size, H: band, Color: t.Hazard},
+				Rect{X: ox, Y: oy + size - band, W: size, H: band, Color: t.Hazard},
+				Rect{X: ox, Y: oy + band, W: band, H: inner, Color: t.Hazard},
+				Rect{X: ox + size - band, Y: oy + band, W: band, H: inner, Color: t.Hazard},
+			)
+		}
+	}
+
+	// Draw grid lines
+	if l.Grid {
+		for i := 0; i <= g.Grid; i++ {
+			f := float32(i) * s
+			prims = append(prims,
+				Line{X1: ox + f, Y1: oy, X2: ox + f, Y2: oy + size, Width: 1, Color: t.Grid},
+				Line{X1: ox, Y1: oy + f, X2: ox + size, Y2: oy + f, Width: 1, Color: t.Grid},
+			)
+		}
+	}
+
+	// Draw walls
+	for _, w := range g.Walls {
+		prims = append(prims, Rect{X: ox + float32(w.X)*s, Y: oy + float32(w.Y)*s, W: s, H: s, Color: t.Wall})
+	}
+
+	// Draw food
+	for _, f := range g.Food {
+		prims = append(prims, Rect{X: ox + float32(f.X)*s, Y: oy + float32(f.Y)*s, W: s, H: s, Color: t.Food})
+	}
+
+	// Draw snakes, the player's last so it stays on top. Dead snakes are
+	// removed from play but stay visible once the game is over.
+	order := make([]int, 0, len(g.Snakes))
+	for i := range g.Snakes {
+		if i != l.Self {
+			order = append(order, i)
+		}
+	}
+	if l.Self >= 0 && l.Self < len(g.Snakes) {
+		order = append(order, l.Self)
+	}
+
+	for _, n := range order {
+		snake := g.Snakes[n]
+		if !snake.Alive && g.State != game.GameOver {
+			continue
+		}
+
+		// Tail first so the head stays on top
+		head, body := t.ColorsOf(g, n, l.Self)
+		for i := len(snake.Body) - 1; i >= 0; i-- {
+			part := snake.Body[i]
+			c := body
+			if i == 0 {
+				c = head
+			}
+			prims = append(prims, Rect{X: ox + float32(part.X)*s, Y: oy + float32(part.Y)*s, W: s, H: s, Color: c})
+		}
+	}
+
+	return prims
+}
+
+// ScoreLine summarizes snake self's progress for a HUD. Royale games add
+// its health and how many snakes are left.
+func ScoreLine(g *game.Game, self int) string {
+	if self < 0 || self >= len(g.Snakes) {
+		return "Score: 0"
+	}
+
+	s := g.Snakes[self]
+	line := fmt.Sprintf("Score: %d", s.Score)
+	if g.Mode == game.ModeRoyale {
+		line += fmt.Sprintf("  Health: %d  Alive: %d/%d", s.Health, g.AliveCount(), len(g.Snakes))
+	}
+	return line
+}
+
+// HUD turns lines of text into primitives stacked from x, y
+func HUD(lines []string, t Theme, x, y int) []Primitive {
+	prims := make([]Primitive, 0, len(lines))
+	for i, line := range lines {
+		prims = append(prims, Text{X: x, Y: y + i*lineHeight, Text: line, Color: t.Text})
+	}
+	return prims
+}
+
+// lineHeight is the vertical spacing between HUD lines
+const lineHeight = 20
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// update rewrites the golden images instead of comparing against them
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+const goldenTile = 8
+
+// goldenStates builds the fixed game states drawn by the golden tests
+func goldenStates(t *testing.T) map[string]*game.Game {
+	t.Helper()
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A lone snake that has turned once
+	classic := game.NewGameWithSeed(cfg, 1)
+	for i := 0; i < 6; i++ {
+		if i == 3 {
+			classic.ChangeSnakeDirection(0, game.Up)
+		}
+		classic.Step()
+	}
+
+	// Two snakes on a walled level
+	walled := *cfg
+	walled.Game.Level = game.LevelBox
+	duel := game.NewMultiplayerGame(&walled, 2, 2)
+	for i := 0; i < 4; i++ {
+		duel.Step()
+	}
+
+	return map[string]*game.Game{"classic": classic, "duel": duel}
+}
+
+// TestGolden draws each state with each theme in the software backend and
+// compares the result with the image checked in under testdata
+func TestGolden(t *testing.T) {
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for stateName, g := range goldenStates(t) {
+		for _, themeName := range Themes {
+			name := fmt.Sprintf("%s_%s.png", stateName, themeName)
+			t.Run(name, func(t *testing.T) {
+				layout := Layout{TileSize: goldenTile, OffsetY: 16, Grid: true}
+				hud := []string{fmt.Sprintf("Score: %d", g.Snakes[0].Score)}
+				size := g.Grid * goldenTile
+				got := Image(g, NamedTheme(themeName, cfg), layout, hud, size, size+16)
+
+				path := filepath.Join("testdata", name)
+				if *update {
+					var buf bytes.Buffer
+					if err := png.Encode(&buf, got); err != nil {
+						t.Fatal(err)
+					}
+					if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				data, err := ioutil.ReadFile(path)
+				if err != nil {
+					t.Fatalf("%v (run go test -update to create it)", err)
+				}
+				want, err := png.Decode(bytes.NewReader(data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if p, ok := firstDifference(got, want); !ok {
+					t.Errorf("image differs from %s at %v: got %v, want %v", path, p, got.At(p.X, p.Y), want.At(p.X, p.Y))
+				}
+			})
+		}
+	}
+}
+
+// firstDifference compares two images pixel by pixel, returning the first
+// pixel that differs
+func firstDifference(a, b image.Image) (image.Point, bool) {
+	if a.Bounds() != b.Bounds() {
+		return a.Bounds().Max, false
+	}
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			r1, g1, b1, a1 := a.At(x, y).RGBA()
+			r2, g2, b2, a2 := b.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return image.Point{X: x, Y: y}, false
+			}
+		}
+	}
+	return image.Point{}, true
+}
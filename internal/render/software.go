@@ -0,0 +1,96 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// Software draws primitives onto any draw.Image, without a GPU
+type Software struct {
+	Dst draw.Image
+}
+
+// NewSoftware creates a software backend targeting dst
+func NewSoftware(dst draw.Image) *Software {
+	return &Software{Dst: dst}
+}
+
+// Image renders the board and HUD into a new RGBA image of the given size
+func Image(g *game.Game, t Theme, l Layout, hud []string, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	b := NewSoftware(img)
+	Draw(b, Board(g, t, l))
+	Draw(b, HUD(hud, t, 2, 2))
+	return img
+}
+
+// Clear fills the whole image
+func (s *Software) Clear(c color.RGBA) {
+	draw.Draw(s.Dst, s.Dst.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// FillRect fills a rectangle, blending translucent colors
+func (s *Software) FillRect(x, y, w, h float32, c color.RGBA) {
+	r := image.Rect(round(x), round(y), round(x+w), round(y+h))
+	draw.Draw(s.Dst, r.Intersect(s.Dst.Bounds()), image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// StrokeLine draws a line by stamping width-sized squares along it
+func (s *Software) StrokeLine(x1, y1, x2, y2, width float32, c color.RGBA) {
+	if width < 1 {
+		width = 1
+	}
+
+	dx, dy := float64(x2-x1), float64(y2-y1)
+	steps := int(math.Max(math.Abs(dx), math.Abs(dy)))
+	if steps == 0 {
+		steps = 1
+	}
+
+	// Axis-aligned lines (all the board uses) are a single rectangle
+	if dx == 0 || dy == 0 {
+		minX, minY := float32(math.Min(float64(x1), float64(x2))), float32(math.Min(float64(y1), float64(y2)))
+		w, h := float32(math.Abs(dx)), float32(math.Abs(dy))
+		if w < width {
+			w = width
+		}
+		if h < width {
+			h = width
+		}
+		s.FillRect(minX, minY, w, h, c)
+		return
+	}
+
+	src := image.NewUniform(c)
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		px := round(x1 + float32(dx*t))
+		py := round(y1 + float32(dy*t))
+		r := image.Rect(px, py, px+round(width), py+round(width))
+		draw.Draw(s.Dst, r.Intersect(s.Dst.Bounds()), src, image.Point{}, draw.Over)
+	}
+}
+
+// DrawText draws text with the built-in bitmap font
+func (s *Software) DrawText(x, y int, text string, c color.RGBA) {
+	face := basicfont.Face7x13
+	d := font.Drawer{
+		Dst:  s.Dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y+face.Ascent),
+	}
+	d.DrawString(text)
+}
+
+func round(f float32) int {
+	return int(math.Floor(float64(f) + 0.5))
+}
@@ -0,0 +1,44 @@
+package render
+
+import "github.com/C0d3-5t3w/go-snake/internal/config"
+
+// Themes players can pick in the settings, by name
+const (
+	ThemeConfig   = "config"   // The colors in the config file
+	ThemeClassic  = "classic"  // Green snake on black
+	ThemeMidnight = "midnight" // Blues on a dark navy board
+	ThemePaper    = "paper"    // Dark snake on a light board
+)
+
+// Themes lists the theme names in the order the settings offer them
+var Themes = []string{ThemeConfig, ThemeClassic, ThemeMidnight, ThemePaper}
+
+// presetColors holds the colors of the themes not taken from the config
+var presetColors = map[string]struct {
+	SnakeHead, SnakeBody, Food, Grid, Background [3]float32
+}{
+	ThemeClassic:  {[3]float32{0.6, 1.0, 0.4}, [3]float32{0.1, 0.7, 0.1}, [3]float32{1.0, 0.1, 0.1}, [3]float32{0.3, 0.3, 0.3}, [3]float32{0, 0, 0}},
+	ThemeMidnight: {[3]float32{0.6, 0.9, 1.0}, [3]float32{0.2, 0.5, 0.9}, [3]float32{1.0, 0.85, 0.2}, [3]float32{0.3, 0.4, 0.7}, [3]float32{0.02, 0.04, 0.15}},
+	ThemePaper:    {[3]float32{0.1, 0.1, 0.1}, [3]float32{0.35, 0.35, 0.35}, [3]float32{0.8, 0.1, 0.1}, [3]float32{0.6, 0.6, 0.6}, [3]float32{0.95, 0.93, 0.88}},
+}
+
+// NamedTheme builds the theme with the given name, falling back to the
+// configured colors for ThemeConfig and unknown names
+func NamedTheme(name string, cfg *config.Config) Theme {
+	preset, ok := presetColors[name]
+	if !ok {
+		return ThemeFromConfig(cfg)
+	}
+
+	c := *cfg
+	c.Colors.SnakeHead = preset.SnakeHead
+	c.Colors.SnakeBody = preset.SnakeBody
+	c.Colors.Food = preset.Food
+	c.Colors.Grid = preset.Grid
+	c.Colors.Background = preset.Background
+	t := ThemeFromConfig(&c)
+	if name == ThemePaper {
+		t.Text = t.SnakeHead // White text would not show on the light board
+	}
+	return t
+}
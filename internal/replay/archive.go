@@ -0,0 +1,51 @@
+package replay
+
+import (
+	"bytes"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// Archive saves a replay of a finished game in the storage and records
+// its high score, linking the two. The game is added to the
+// player's profile stats and the history log, and the unlocks it earned
+// are returned.
+func Archive(s *storage.Storage, g *game.Game, player string) (*Replay, []string, error) {
+	r := FromGame(g, player)
+
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		return nil, nil, err
+	}
+	name := r.FileName()
+	if err := s.WriteReplay(name, buf.Bytes()); err != nil {
+		return nil, nil, err
+	}
+
+	rules := storage.RulesetOf(&r.Config, r.Difficulty)
+	s.AddHighScore(player, r.Result.Score, rules, name)
+	s.SetVerified(name, Verify(r, nil) == nil)
+	unlocked := s.RecordGame(player, r.Result.Score, r.Result.Length, r.Result.Ticks)
+	if err := s.Save(); err != nil {
+		return nil, unlocked, err
+	}
+
+	rec := storage.GameRecord{
+		Date:    r.Date,
+		Profile: player,
+		Rules:   rules,
+		Result:  r.Result,
+		Replay:  name,
+	}
+	if err := s.AppendHistory(rec); err != nil {
+		return r, unlocked, err
+	}
+
+	return r, unlocked, nil
+}
+
+// FileName returns the name Archive stores the replay under
+func (r *Replay) FileName() string {
+	return r.Date.Format("20060102-150405.000000000") + Extension
+}
@@ -0,0 +1,107 @@
+package replay
+
+import (
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+const (
+	minPlaybackSpeed = 0.25
+	maxPlaybackSpeed = 16
+)
+
+// Player re-simulates a replay with play, pause, seek, speed and step controls
+type Player struct {
+	Replay *Replay
+	Game   *game.Game
+	Paused bool
+	Speed  float64 // Playback speed multiplier
+
+	next       int // Index of the next input to apply
+	lastUpdate time.Time
+}
+
+// NewPlayer creates a player positioned at the start of the replay
+func NewPlayer(r *Replay) *Player {
+	p := &Player{
+		Replay: r,
+		Speed:  1,
+	}
+	p.Seek(0)
+	return p
+}
+
+// Update advances playback according to wall-clock time and playback speed
+func (p *Player) Update() bool {
+	if p.Paused || p.Done() {
+		return false
+	}
+
+	now := time.Now()
+	interval := time.Duration(float64(p.Game.TickInterval()) / p.Speed)
+	if now.Sub(p.lastUpdate) < interval {
+		return false
+	}
+
+	p.lastUpdate = now
+	return p.Step()
+}
+
+// Step advances playback by exactly one tick
+func (p *Player) Step() bool {
+	if p.Done() {
+		return false
+	}
+
+	// Apply any direction change or forfeit recorded for this tick
+	for p.next < len(p.Replay.Inputs) && p.Replay.Inputs[p.next].Tick <= p.Game.Tick {
+		in := p.Replay.Inputs[p.next]
+		if in.Forfeit {
+			p.Game.Forfeit(in.Snake)
+		} else {
+			p.Game.Snakes[in.Snake].Direction = in.Direction
+		}
+		p.next++
+	}
+
+	return p.Game.Step()
+}
+
+// Seek re-simulates the replay from the start up to the given tick
+func (p *Player) Seek(tick int) {
+	if tick < 0 {
+		tick = 0
+	}
+
+	p.Game = p.Replay.NewGame()
+	p.next = 0
+	for p.Game.Tick < tick && p.Step() {
+	}
+	p.lastUpdate = time.Now()
+}
+
+// Done reports whether playback has reached the end of the recording
+func (p *Player) Done() bool {
+	return p.Game.IsGameOver() || p.Game.Tick >= p.Replay.Result.Ticks
+}
+
+// TogglePause toggles playback
+func (p *Player) TogglePause() {
+	p.Paused = !p.Paused
+	p.lastUpdate = time.Now()
+}
+
+// SpeedUp doubles the playback speed
+func (p *Player) SpeedUp() {
+	if p.Speed < maxPlaybackSpeed {
+		p.Speed *= 2
+	}
+}
+
+// SlowDown halves the playback speed
+func (p *Player) SlowDown() {
+	if p.Speed > minPlaybackSpeed {
+		p.Speed /= 2
+	}
+}
@@ -0,0 +1,319 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// File layout:
+//
+//	magic   "GSRP"
+//	version uint8
+//	body    gzip stream of uvarint/varint encoded fields:
+//	        seed, date (unix nanos), player, config (YAML),
+//	        difficulty (since version 4),
+//	        snake count (since version 2),
+//	        result (score, length, ticks, state),
+//	        input count, then per input: tick delta,
+//	        snake index (since version 2), direction
+//	        (or 0xff for a forfeit, since version 3)
+const (
+	magic   = "GSRP"
+	Version = 4
+
+	// Extension is the file extension used for replay files
+	Extension = ".gsr"
+
+	maxFieldSize = 1 << 20                    // Guards against corrupt length prefixes
+	maxBodySize  = 8 << 20                    // Most decompressed bytes read, guards against gzip bombs
+	maxSnakes    = 64                         // Most snakes a replay may hold
+	maxInputs    = maxVerifyTicks * maxSnakes // Most inputs a replay may hold
+	forfeitByte  = 0xff                       // Direction byte marking a forfeit input
+)
+
+// ErrBadFormat is returned when a file is not a replay
+var ErrBadFormat = errors.New("not a go-snake replay file")
+
+// Replay holds everything needed to re-simulate a game
+type Replay struct {
+	Version    int
+	Seed       int64
+	Date       time.Time
+	Player     string
+	Config     config.Config
+	Difficulty string          // Difficulty the game was played at, medium before version 4
+	Snakes     int             // Number of snakes in the game
+	Result     game.GameResult // Result of the first snake
+	Inputs     []game.Input
+}
+
+// FromGame captures a replay of the given game
+func FromGame(g *game.Game, player string) *Replay {
+	inputs := make([]game.Input, len(g.Inputs))
+	copy(inputs, g.Inputs)
+
+	return &Replay{
+		Version:    Version,
+		Seed:       g.Seed,
+		Date:       time.Now(),
+		Player:     player,
+		Config:     *g.Config,
+		Difficulty: g.Difficulty,
+		Snakes:     len(g.Snakes),
+		Result:     g.Result(),
+		Inputs:     inputs,
+	}
+}
+
+// NewGame creates a game ready to re-simulate this replay from tick zero
+func (r *Replay) NewGame() *game.Game {
+	cfg := r.Config
+	g := game.NewMultiplayerGame(&cfg, r.Seed, r.Snakes)
+	g.Difficulty = r.Difficulty
+	return g
+}
+
+// Load reads a replay from a file
+func Load(path string) (*Replay, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(bytes.NewReader(data))
+}
+
+// Save writes the replay to a file
+func (r *Replay) Save(path string) error {
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		return err
+	}
+
+	return storage.WriteFileAtomic(path, buf.Bytes(), 0644)
+}
+
+// Encode writes the replay in the binary replay format
+func (r *Replay) Encode(w io.Writer) error {
+	cfgData, err := yaml.Marshal(&r.Config)
+	if err != nil {
+		return err
+	}
+
+	// Header is left uncompressed so the format can be sniffed
+	if _, err := w.Write(append([]byte(magic), Version)); err != nil {
+		return err
+	}
+
+	var b []byte
+	b = binary.AppendVarint(b, r.Seed)
+	b = binary.AppendVarint(b, r.Date.UnixNano())
+	b = appendBytes(b, []byte(r.Player))
+	b = appendBytes(b, cfgData)
+	b = appendBytes(b, []byte(r.Difficulty))
+	b = binary.AppendUvarint(b, uint64(r.Snakes))
+	b = binary.AppendUvarint(b, uint64(r.Result.Score))
+	b = binary.AppendUvarint(b, uint64(r.Result.Length))
+	b = binary.AppendUvarint(b, uint64(r.Result.Ticks))
+	b = binary.AppendUvarint(b, uint64(r.Result.State))
+
+	b = binary.AppendUvarint(b, uint64(len(r.Inputs)))
+	lastTick := 0
+	for _, in := range r.Inputs {
+		b = binary.AppendUvarint(b, uint64(in.Tick-lastTick))
+		b = binary.AppendUvarint(b, uint64(in.Snake))
+		if in.Forfeit {
+			b = append(b, forfeitByte)
+		} else {
+			b = append(b, byte(in.Direction))
+		}
+		lastTick = in.Tick
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Decode reads a replay in the binary replay format
+func Decode(rd io.Reader) (*Replay, error) {
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(rd, header); err != nil {
+		return nil, ErrBadFormat
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, ErrBadFormat
+	}
+
+	r := &Replay{Version: int(header[len(magic)])}
+	if r.Version > Version {
+		return nil, fmt.Errorf("replay version %d is newer than supported version %d", r.Version, Version)
+	}
+
+	zr, err := gzip.NewReader(rd)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	br := bufio.NewReader(io.LimitReader(zr, maxBodySize))
+
+	d := decoder{r: br}
+	r.Seed = d.varint()
+	r.Date = time.Unix(0, d.varint())
+	r.Player = string(d.bytes())
+	cfgData := d.bytes()
+	// Difficulty could not be changed before it was recorded
+	r.Difficulty = game.DifficultyMedium
+	if r.Version >= 4 {
+		r.Difficulty = string(d.bytes())
+	}
+	r.Snakes = 1
+	if r.Version >= 2 {
+		r.Snakes = int(d.uvarint())
+	}
+	r.Result.Score = int(d.uvarint())
+	r.Result.Length = int(d.uvarint())
+	r.Result.Ticks = int(d.uvarint())
+	r.Result.State = game.GameState(d.uvarint())
+
+	count := d.uvarint()
+	if count > maxInputs {
+		return nil, fmt.Errorf("corrupt replay: implausible input count %d", count)
+	}
+	tick := 0
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		tick += int(d.uvarint())
+		snake := 0
+		if r.Version >= 2 {
+			snake = int(d.uvarint())
+		}
+		dir := d.byte()
+		if r.Version >= 3 && dir == forfeitByte {
+			r.Inputs = append(r.Inputs, game.Input{Tick: tick, Snake: snake, Forfeit: true})
+			continue
+		}
+		r.Inputs = append(r.Inputs, game.Input{Tick: tick, Snake: snake, Direction: game.Direction(dir)})
+	}
+
+	if d.err != nil {
+		return nil, fmt.Errorf("corrupt replay: %w", d.err)
+	}
+
+	if err := yaml.Unmarshal(cfgData, &r.Config); err != nil {
+		return nil, fmt.Errorf("corrupt replay config: %w", err)
+	}
+
+	if err := r.check(); err != nil {
+		return nil, fmt.Errorf("corrupt replay: %w", err)
+	}
+
+	return r, nil
+}
+
+// check reports whether the replay can be played back: a board and speed
+// within what a replay may claim, a sane snake count, and valid inputs for those snakes in tick order,
+// at most one per snake per tick
+func (r *Replay) check() error {
+	grid := r.Config.Game.GridSize
+	if grid < minVerifyGrid || grid > maxVerifyGrid {
+		return fmt.Errorf("grid size %d is outside %d to %d", grid, minVerifyGrid, maxVerifyGrid)
+	}
+	if r.Config.Game.Mode == game.ModeRoyale {
+		grid = r.Config.Royale.GridSize
+		if grid < minVerifyGrid || grid > maxVerifyGrid {
+			return fmt.Errorf("royale grid size %d is outside %d to %d", grid, minVerifyGrid, maxVerifyGrid)
+		}
+	}
+	if s := r.Config.Game.InitialSpeed; !(s > 0 && s <= maxVerifySpeed) {
+		return fmt.Errorf("initial speed %v is outside 0 to %d", s, maxVerifySpeed)
+	}
+	if r.Snakes < 1 || r.Snakes > maxSnakes {
+		return fmt.Errorf("implausible snake count %d", r.Snakes)
+	}
+
+	lastTick := make([]int, r.Snakes)
+	for i := range lastTick {
+		lastTick[i] = -1
+	}
+	for i, in := range r.Inputs {
+		if in.Snake < 0 || in.Snake >= r.Snakes {
+			return fmt.Errorf("input %d is for unknown snake %d", i, in.Snake)
+		}
+		if !in.Forfeit && !in.Direction.Valid() {
+			return fmt.Errorf("input %d has invalid direction %d", i, in.Direction)
+		}
+		if in.Tick <= lastTick[in.Snake] || (i > 0 && in.Tick < r.Inputs[i-1].Tick) {
+			return fmt.Errorf("input %d at tick %d is out of order", i, in.Tick)
+		}
+		lastTick[in.Snake] = in.Tick
+	}
+	return nil
+}
+
+// appendBytes appends a length-prefixed byte string
+func appendBytes(b, data []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// decoder reads varint fields, remembering the first error
+type decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	d.err = err
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(d.r)
+	d.err = err
+	return v
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	v, err := d.r.ReadByte()
+	d.err = err
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uvarint()
+	if d.err != nil {
+		return nil
+	}
+	if n > maxFieldSize {
+		d.err = fmt.Errorf("field of %d bytes exceeds limit", n)
+		return nil
+	}
+	buf := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, buf)
+	return buf
+}
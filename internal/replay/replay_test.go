@@ -0,0 +1,118 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// playGame plays a seeded game at a difficulty to its end, turning twice
+// so the replay has inputs to record and then running into the wall
+func playGame(t *testing.T, difficulty string) (*config.Config, *Replay) {
+	t.Helper()
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := game.NewGameWithSeed(game.WithDifficulty(cfg, difficulty), 42)
+	g.Difficulty = difficulty
+	turns := map[int]game.Direction{3: game.Up, 6: game.Left}
+	for g.Step() {
+		if dir, ok := turns[g.Tick]; ok {
+			g.ChangeDirection(dir)
+		}
+		if g.Tick > 10000 {
+			t.Fatal("game did not end")
+		}
+	}
+
+	r := FromGame(g, "tester")
+	r.Date = time.Unix(0, r.Date.UnixNano()) // Drop the monotonic reading, which is not encoded
+	if len(r.Inputs) == 0 {
+		t.Fatal("game recorded no inputs")
+	}
+	return cfg, r
+}
+
+func TestRoundTrip(t *testing.T) {
+	_, r := playGame(t, game.DifficultyHard)
+
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, r) {
+		t.Errorf("decoded %+v\nwant %+v", got, r)
+	}
+}
+
+func TestDecodeRejectsGarbage(t *testing.T) {
+	if _, err := Decode(bytes.NewReader([]byte("not a replay"))); !errors.Is(err, ErrBadFormat) {
+		t.Errorf("got %v, want %v", err, ErrBadFormat)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	for _, difficulty := range game.Difficulties {
+		t.Run(difficulty, func(t *testing.T) {
+			cfg, r := playGame(t, difficulty)
+
+			if err := Verify(r, nil); err != nil {
+				t.Errorf("good run: %v", err)
+			}
+			if err := Verify(r, cfg); err != nil {
+				t.Errorf("good run against the config it was played with: %v", err)
+			}
+
+			other := *cfg
+			other.Game.GridSize++
+			var verr *VerifyError
+			if err := Verify(r, &other); !errors.As(err, &verr) {
+				t.Errorf("run against other rules: got %v, want a verification failure", err)
+			}
+
+			tampered := *r
+			tampered.Result.Score += 10
+			if err := Verify(&tampered, nil); !errors.As(err, &verr) {
+				t.Errorf("tampered score: got %v, want a verification failure", err)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsUnplayableRules(t *testing.T) {
+	_, good := playGame(t, game.DifficultyMedium)
+
+	for _, tc := range []struct {
+		name   string
+		modify func(r *Replay)
+	}{
+		{"grid too large", func(r *Replay) { r.Config.Game.GridSize = 1 << 20 }},
+		{"grid too small", func(r *Replay) { r.Config.Game.GridSize = 1 }},
+		{"royale grid too large", func(r *Replay) {
+			r.Config.Game.Mode = game.ModeRoyale
+			r.Config.Royale.GridSize = 1 << 20
+		}},
+		{"speed too fast", func(r *Replay) { r.Config.Game.InitialSpeed = 1e9 }},
+	} {
+		r := *good
+		tc.modify(&r)
+		var buf bytes.Buffer
+		if err := r.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decode(&buf); err == nil {
+			t.Errorf("%s: decoded without error", tc.name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package replay
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// maxVerifyTicks bounds how long a claimed game may be before it is rejected
+// rather than re-simulated
+const maxVerifyTicks = 1 << 20
+
+// Boards and speeds a replay may claim. Each tick re-indexes every cell, so
+// maxVerifyWork bounds ticks times cells to keep one verification cheap.
+const (
+	minVerifyGrid  = 5
+	maxVerifyGrid  = 128
+	maxVerifySpeed = 100
+	maxVerifyWork  = 1 << 32
+)
+
+// VerifyError describes why a replay failed verification
+type VerifyError struct {
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return "replay verification failed: " + e.Reason
+}
+
+func verifyFailed(format string, args ...interface{}) error {
+	return &VerifyError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Verify re-simulates the replay and checks that the recorded score, length
+// and end state match the rules. If rules is non-nil the replay must also
+// have been played with the same game settings, scaled for the replay's
+// difficulty.
+func Verify(r *Replay, rules *config.Config) error {
+	if rules != nil {
+		want := game.WithDifficulty(rules, r.Difficulty).Game
+		if r.Config.Game != want {
+			return verifyFailed("game settings %+v differ from required %+v", r.Config.Game, want)
+		}
+	}
+	if err := r.check(); err != nil {
+		return verifyFailed("%v", err)
+	}
+
+	grid := r.Config.Game.GridSize
+	if r.Config.Game.Mode == game.ModeRoyale {
+		grid = r.Config.Royale.GridSize
+	}
+	if r.Result.Ticks < 0 || r.Result.Ticks > maxVerifyTicks {
+		return verifyFailed("implausible game length of %d ticks", r.Result.Ticks)
+	}
+	if int64(r.Result.Ticks)*int64(grid*grid) > maxVerifyWork {
+		return verifyFailed("%d ticks on a %d board are too costly to verify", r.Result.Ticks, grid)
+	}
+
+	if n := len(r.Inputs); n > 0 && r.Inputs[n-1].Tick >= r.Result.Ticks {
+		return verifyFailed("input at tick %d is after the game ended at tick %d", r.Inputs[n-1].Tick, r.Result.Ticks)
+	}
+
+	// Re-simulate and compare with the claimed result
+	p := NewPlayer(r)
+	for p.Step() {
+	}
+
+	got := p.Game.Result()
+	if got != r.Result {
+		return verifyFailed("claimed %+v but simulation produced %+v", r.Result, got)
+	}
+	if got.State != game.GameOver {
+		return verifyFailed("game did not reach an end state")
+	}
+
+	return nil
+}
+
+// VerifyHighScore checks that a stored high score is backed by a replay
+// that verifies and produced the same score
+func VerifyHighScore(s *storage.Storage, hs storage.HighScore) error {
+	if hs.Replay == "" {
+		return verifyFailed("no replay recorded")
+	}
+
+	data, err := s.ReadReplay(hs.Replay)
+	if err != nil {
+		return err
+	}
+	r, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if r.Result.Score != hs.Score {
+		return verifyFailed("high score %d does not match replay score %d", hs.Score, r.Result.Score)
+	}
+
+	return Verify(r, nil)
+}
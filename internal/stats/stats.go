@@ -0,0 +1,270 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// Periods the trend can be grouped by
+const (
+	ByDay   = "day"
+	ByWeek  = "week"
+	ByMonth = "month"
+)
+
+// Filter selects games from the history. Empty fields match everything.
+type Filter struct {
+	Profile string
+	Mode    string
+	From    time.Time // First moment included
+	To      time.Time // First moment excluded
+}
+
+// Match reports whether a game passes the filter
+func (f Filter) Match(rec storage.GameRecord) bool {
+	if f.Profile != "" && !strings.EqualFold(rec.Profile, f.Profile) {
+		return false
+	}
+	if f.Mode != "" && !strings.EqualFold(rec.Rules.Mode, f.Mode) {
+		return false
+	}
+	if !f.From.IsZero() && rec.Date.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !rec.Date.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Summary describes the distribution of one measure over the games
+type Summary struct {
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P25    float64 `json:"p25"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+	Best   int     `json:"best"`
+}
+
+// Streaks counts runs of play and of good games
+type Streaks struct {
+	CurrentDays  int `json:"current_days"`  // Days in a row with a game, up to the last game
+	LongestDays  int `json:"longest_days"`  // Most days in a row with a game
+	AboveAverage int `json:"above_average"` // Most games in a row scoring above the mean
+	NewBests     int `json:"new_bests"`     // Games that beat every earlier score
+}
+
+// Period summarizes the games of one day, week or month
+type Period struct {
+	Start time.Time `json:"start"`
+	Games int       `json:"games"`
+	Mean  float64   `json:"mean"`
+	Best  int       `json:"best"`
+}
+
+// Trend tells whether scores are improving
+type Trend struct {
+	PerGame   float64  `json:"per_game"`   // Slope of the best-fit line through the scores
+	FirstHalf float64  `json:"first_half"` // Mean score of the older half of the games
+	LastHalf  float64  `json:"last_half"`  // Mean score of the newer half
+	Periods   []Period `json:"periods"`
+}
+
+// Report summarizes the games that passed a filter
+type Report struct {
+	Games   int       `json:"games"`
+	First   time.Time `json:"first,omitempty"`
+	Last    time.Time `json:"last,omitempty"`
+	Score   Summary   `json:"score"`
+	Length  Summary   `json:"length"`
+	Ticks   Summary   `json:"ticks"`
+	Streaks Streaks   `json:"streaks"`
+	Trend   Trend     `json:"trend"`
+}
+
+// Compute builds a report over the history games matching the filter,
+// grouping the trend by the given period
+func Compute(history []storage.GameRecord, f Filter, by string) (*Report, error) {
+	if by != ByDay && by != ByWeek && by != ByMonth {
+		return nil, fmt.Errorf("unknown period %q (available: %s, %s, %s)", by, ByDay, ByWeek, ByMonth)
+	}
+
+	var games []storage.GameRecord
+	for _, rec := range history {
+		if f.Match(rec) {
+			games = append(games, rec)
+		}
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		return games[i].Date.Before(games[j].Date)
+	})
+
+	r := &Report{Games: len(games)}
+	if len(games) == 0 {
+		return r, nil
+	}
+	r.First = games[0].Date
+	r.Last = games[len(games)-1].Date
+
+	scores := make([]int, len(games))
+	lengths := make([]int, len(games))
+	ticks := make([]int, len(games))
+	for i, g := range games {
+		scores[i] = g.Result.Score
+		lengths[i] = g.Result.Length
+		ticks[i] = g.Result.Ticks
+	}
+	r.Score = summarize(scores)
+	r.Length = summarize(lengths)
+	r.Ticks = summarize(ticks)
+	r.Streaks = streaks(games, r.Score.Mean)
+	r.Trend = trend(games, scores, by)
+
+	return r, nil
+}
+
+// summarize computes the mean, percentiles and best of a measure
+func summarize(values []int) Summary {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	total := 0
+	for _, v := range sorted {
+		total += v
+	}
+
+	return Summary{
+		Mean:   float64(total) / float64(len(sorted)),
+		Median: percentile(sorted, 50),
+		P25:    percentile(sorted, 25),
+		P75:    percentile(sorted, 75),
+		P90:    percentile(sorted, 90),
+		Best:   sorted[len(sorted)-1],
+	}
+}
+
+// percentile interpolates linearly between the closest ranks of sorted
+func percentile(sorted []int, p float64) float64 {
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	frac := pos - float64(lo)
+	return float64(sorted[lo])*(1-frac) + float64(sorted[hi])*frac
+}
+
+// streaks finds runs of days played and of games above the mean score.
+// games must be in date order.
+func streaks(games []storage.GameRecord, mean float64) Streaks {
+	var s Streaks
+
+	days, above, best := 0, 0, -1
+	var lastDay time.Time
+	for _, g := range games {
+		day := dayOf(g.Date)
+		switch {
+		case day.Equal(lastDay):
+		case !lastDay.IsZero() && day.Equal(lastDay.AddDate(0, 0, 1)):
+			days++
+		default:
+			days = 1
+		}
+		lastDay = day
+		if days > s.LongestDays {
+			s.LongestDays = days
+		}
+
+		if float64(g.Result.Score) > mean {
+			above++
+		} else {
+			above = 0
+		}
+		if above > s.AboveAverage {
+			s.AboveAverage = above
+		}
+
+		if g.Result.Score > best {
+			if best >= 0 {
+				s.NewBests++
+			}
+			best = g.Result.Score
+		}
+	}
+	s.CurrentDays = days
+
+	return s
+}
+
+// trend fits a line through the scores and groups them into periods.
+// games must be in date order.
+func trend(games []storage.GameRecord, scores []int, by string) Trend {
+	var t Trend
+
+	// Least squares slope of score against game number
+	n := float64(len(scores))
+	var sx, sy, sxy, sxx float64
+	for i, v := range scores {
+		x, y := float64(i), float64(v)
+		sx += x
+		sy += y
+		sxy += x * y
+		sxx += x * x
+	}
+	if d := n*sxx - sx*sx; d != 0 {
+		t.PerGame = (n*sxy - sx*sy) / d
+	}
+
+	half := len(scores) / 2
+	if half > 0 {
+		t.FirstHalf = mean(scores[:half])
+		t.LastHalf = mean(scores[half:])
+	}
+
+	for _, g := range games {
+		start := periodStart(g.Date, by)
+		if len(t.Periods) == 0 || !t.Periods[len(t.Periods)-1].Start.Equal(start) {
+			t.Periods = append(t.Periods, Period{Start: start})
+		}
+		p := &t.Periods[len(t.Periods)-1]
+		p.Mean = (p.Mean*float64(p.Games) + float64(g.Result.Score)) / float64(p.Games+1)
+		p.Games++
+		if g.Result.Score > p.Best {
+			p.Best = g.Result.Score
+		}
+	}
+
+	return t
+}
+
+// mean averages a non-empty list of values
+func mean(values []int) float64 {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return float64(total) / float64(len(values))
+}
+
+// dayOf returns midnight of the local day t falls on
+func dayOf(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// periodStart returns the start of the day, week (from Monday) or month t
+// falls in
+func periodStart(t time.Time, by string) time.Time {
+	day := dayOf(t)
+	switch by {
+	case ByWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case ByMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	return day
+}
@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+func TestPercentile(t *testing.T) {
+	for _, tc := range []struct {
+		sorted []int
+		p      float64
+		want   float64
+	}{
+		{[]int{7}, 0, 7},
+		{[]int{7}, 90, 7},
+		{[]int{1, 2, 3, 4, 5}, 0, 1},
+		{[]int{1, 2, 3, 4, 5}, 50, 3},
+		{[]int{1, 2, 3, 4, 5}, 100, 5},
+		{[]int{1, 2, 3, 4}, 50, 2.5},
+		{[]int{10, 20}, 25, 12.5},
+		{[]int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100}, 90, 90},
+	} {
+		if got := percentile(tc.sorted, tc.p); got != tc.want {
+			t.Errorf("percentile(%v, %v) = %v, want %v", tc.sorted, tc.p, got, tc.want)
+		}
+	}
+}
+
+// played returns a game record with a score on a day of October 2026
+func played(day, hour, score int) storage.GameRecord {
+	return storage.GameRecord{
+		Date:   time.Date(2026, time.October, day, hour, 0, 0, 0, time.Local),
+		Result: game.GameResult{Score: score},
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		games []storage.GameRecord
+		mean  float64
+		want  Streaks
+	}{
+		{"no games", nil, 0, Streaks{}},
+		{"one game", []storage.GameRecord{played(1, 12, 5)}, 5,
+			Streaks{CurrentDays: 1, LongestDays: 1}},
+		{"several games a day count once", []storage.GameRecord{
+			played(1, 9, 1), played(1, 23, 2), played(2, 0, 3),
+		}, 2, Streaks{CurrentDays: 2, LongestDays: 2, AboveAverage: 1, NewBests: 2}},
+		{"a missed day breaks the run", []storage.GameRecord{
+			played(1, 12, 10), played(2, 12, 10), played(3, 12, 10), played(5, 12, 10), played(6, 12, 10),
+		}, 10, Streaks{CurrentDays: 2, LongestDays: 3}},
+		{"runs above the mean", []storage.GameRecord{
+			played(1, 1, 30), played(1, 2, 40), played(1, 3, 0), played(1, 4, 20), played(1, 5, 50), played(1, 6, 60),
+		}, 25, Streaks{CurrentDays: 1, LongestDays: 1, AboveAverage: 2, NewBests: 3}},
+	} {
+		if got := streaks(tc.games, tc.mean); got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPeriodStartWeeks(t *testing.T) {
+	// 2026-10-12 is a Monday
+	monday := time.Date(2026, time.October, 12, 0, 0, 0, 0, time.Local)
+	for _, tc := range []struct {
+		t    time.Time
+		want time.Time
+	}{
+		{monday, monday},
+		{monday.Add(13 * time.Hour), monday},
+		{time.Date(2026, time.October, 14, 8, 0, 0, 0, time.Local), monday},
+		{time.Date(2026, time.October, 18, 23, 59, 0, 0, time.Local), monday},
+		{time.Date(2026, time.October, 19, 0, 0, 0, 0, time.Local), monday.AddDate(0, 0, 7)},
+		{time.Date(2026, time.October, 11, 23, 59, 0, 0, time.Local), monday.AddDate(0, 0, -7)},
+		// Weeks spanning a month and a year boundary
+		{time.Date(2026, time.November, 1, 12, 0, 0, 0, time.Local), time.Date(2026, time.October, 26, 0, 0, 0, 0, time.Local)},
+		{time.Date(2027, time.January, 2, 12, 0, 0, 0, time.Local), time.Date(2026, time.December, 28, 0, 0, 0, 0, time.Local)},
+	} {
+		if got := periodStart(tc.t, ByWeek); !got.Equal(tc.want) {
+			t.Errorf("week of %v starts %v, want %v", tc.t, got, tc.want)
+		}
+	}
+
+	if got, want := periodStart(monday.AddDate(0, 0, 3), ByMonth), time.Date(2026, time.October, 1, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("month starts %v, want %v", got, want)
+	}
+	if got := periodStart(monday.Add(13*time.Hour), ByDay); !got.Equal(monday) {
+		t.Errorf("day starts %v, want %v", got, monday)
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Backends, selected by the storage.backend config setting
+const (
+	BackendJSON    = "json"    // One JSON file, replaced atomically on every save
+	BackendJournal = "journal" // An append-only journal of saves and games
+	BackendMemory  = "memory"  // Nothing is kept once the program exits
+)
+
+// Backends lists the available backend names
+var Backends = []string{BackendJSON, BackendJournal, BackendMemory}
+
+// Backend persists a document, the game history and replay files. The
+// document is opaque to the backend: Storage keeps its scores, settings
+// and profiles in it and the leaderboard server its entries, so both run
+// on the same backends.
+type Backend interface {
+	// Lock takes a lock shared with every process using the same backend.
+	// It is held from reading the document to writing it back.
+	Lock() (unlock func(), err error)
+
+	// Read returns the document as last written, or an error satisfying
+	// os.IsNotExist if none has been written yet
+	Read() ([]byte, error)
+
+	// Write replaces the document, keeping the previous one as a backup.
+	// Callers hold the lock.
+	Write(doc []byte) error
+
+	// Recover sets aside a document that failed to load and restores the
+	// newest backup valid accepts. It returns where the damaged document
+	// went and which backup was restored, each empty if there was none.
+	// Callers hold the lock.
+	Recover(valid func(doc []byte) bool) (moved, restored string, err error)
+
+	// AppendHistory adds a finished game to the end of the history
+	AppendHistory(rec GameRecord) error
+
+	// History returns every recorded game, oldest first
+	History() ([]GameRecord, error)
+
+	// RenameHistory attributes the games recorded for profile old to name
+	RenameHistory(old, name string) error
+
+	// WriteReplay stores a replay file under a name
+	WriteReplay(name string, data []byte) error
+
+	// ReadReplay returns a stored replay file, or an error satisfying
+	// os.IsNotExist if there is none by that name
+	ReadReplay(name string) ([]byte, error)
+
+	// String describes where the data is kept
+	String() string
+}
+
+// OpenBackend opens a backend of the given kind, an empty kind meaning
+// BackendJSON. path names the JSON document; the journal is kept next to
+// it with a .journal extension, and history and replays in the same
+// directory.
+func OpenBackend(kind, path string) (Backend, error) {
+	switch kind {
+	case BackendJSON, "":
+		return NewJSONBackend(path), nil
+	case BackendJournal:
+		b, err := OpenJournalBackend(strings.TrimSuffix(path, filepath.Ext(path))+JournalExt, path)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	case BackendMemory:
+		return NewMemoryBackend(), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q (available: %s)", kind, strings.Join(Backends, ", "))
+}
+
+// DefaultBackend opens a backend of the given kind in paths.DataDir
+func DefaultBackend(kind string) (Backend, error) {
+	if kind == BackendMemory {
+		return NewMemoryBackend(), nil
+	}
+
+	path, err := findStoragePath()
+	if err != nil {
+		return nil, err
+	}
+	return OpenBackend(kind, path)
+}
+
+// replayDir keeps replay files in a directory
+type replayDir string
+
+// WriteReplay stores a replay file under a name
+func (d replayDir) WriteReplay(name string, data []byte) error {
+	if err := os.MkdirAll(string(d), 0755); err != nil {
+		return err
+	}
+	return WriteFileAtomic(d.path(name), data, 0644)
+}
+
+// ReadReplay returns a stored replay file
+func (d replayDir) ReadReplay(name string) ([]byte, error) {
+	return ioutil.ReadFile(d.path(name))
+}
+
+// path resolves a replay name inside the directory, never outside it
+func (d replayDir) path(name string) string {
+	return filepath.Join(string(d), filepath.Base(name))
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JSONBackend keeps the document in a JSON file that every save replaces
+// atomically, with rotating backups next to it. The history is a JSON
+// lines file and replays are files in a directory, both in the same
+// directory as the document.
+type JSONBackend struct {
+	replayDir
+
+	path    string
+	history string
+}
+
+// NewJSONBackend creates a backend keeping its document at path
+func NewJSONBackend(path string) *JSONBackend {
+	dir := filepath.Dir(path)
+	return &JSONBackend{
+		replayDir: replayDir(filepath.Join(dir, "replays")),
+		path:      path,
+		history:   filepath.Join(dir, HistoryFile),
+	}
+}
+
+// Lock takes an advisory lock on a file next to the document
+func (b *JSONBackend) Lock() (func(), error) {
+	return lockFile(b.path + ".lock")
+}
+
+// Read returns the contents of the document file
+func (b *JSONBackend) Read() ([]byte, error) {
+	return ioutil.ReadFile(b.path)
+}
+
+// Write replaces the document file, rotating the old contents into the
+// backups
+func (b *JSONBackend) Write(doc []byte) error {
+	current, err := ioutil.ReadFile(b.path)
+	switch {
+	case err == nil:
+		if err := rotateBackups(b.path, current); err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	return WriteFileAtomic(b.path, doc, 0644)
+}
+
+// Recover moves the damaged document aside and copies the newest valid
+// backup into its place
+func (b *JSONBackend) Recover(valid func([]byte) bool) (string, string, error) {
+	moved, err := moveAside(b.path)
+	if err != nil {
+		return "", "", err
+	}
+
+	for n := 1; n <= Backups; n++ {
+		doc, err := ioutil.ReadFile(backupPath(b.path, n))
+		if err != nil || !valid(doc) {
+			continue
+		}
+		if err := WriteFileAtomic(b.path, doc, 0644); err != nil {
+			return moved, "", err
+		}
+		return moved, backupPath(b.path, n), nil
+	}
+	return moved, "", nil
+}
+
+// AppendHistory adds a game to the history file. Appends take the
+// document's lock so lines from several running instances never
+// interleave.
+func (b *JSONBackend) AppendHistory(rec GameRecord) error {
+	unlock, err := b.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return appendRecord(b.history, rec)
+}
+
+// History reads the history file
+func (b *JSONBackend) History() ([]GameRecord, error) {
+	var records []GameRecord
+	err := readRecords(b.history, func(line []byte) {
+		var rec GameRecord
+		if err := json.Unmarshal(line, &rec); err == nil {
+			records = append(records, rec)
+		}
+	})
+	return records, err
+}
+
+// RenameHistory rewrites the history file with old's games under name
+func (b *JSONBackend) RenameHistory(old, name string) error {
+	unlock, err := b.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return rewriteRecords(b.history, func(line []byte) []byte {
+		var rec GameRecord
+		if json.Unmarshal(line, &rec) != nil || !renameRecord(&rec, old, name) {
+			return line
+		}
+		if renamed, err := json.Marshal(rec); err == nil {
+			return renamed
+		}
+		return line
+	})
+}
+
+func (b *JSONBackend) String() string {
+	return b.path
+}
+
+// Backup rotation for the storage file. The newest backup always holds
+// the contents before the latest save; older ones are at least an
+// interval apart.
+const (
+	Backups        = 4         // Older versions of the file kept next to it
+	BackupInterval = time.Hour // Minimum age of the newest backup before it is shifted down
+)
+
+// CorruptError reports a storage file that could not be parsed. The file
+// is moved aside rather than overwritten, so it can be inspected or
+// repaired by hand.
+type CorruptError struct {
+	Path     string
+	MovedTo  string // Where the corrupt file now is
+	Restored string // Backup the data was restored from, empty if none was usable
+	Err      error
+}
+
+func (e *CorruptError) Error() string {
+	msg := fmt.Sprintf("%s is corrupt (%v)", e.Path, e.Err)
+	if e.MovedTo != "" {
+		msg += "; moved it to " + e.MovedTo
+	}
+	if e.Restored != "" {
+		msg += " and restored " + e.Restored
+	} else if e.MovedTo != "" {
+		msg += " and started fresh"
+	}
+	return msg
+}
+
+func (e *CorruptError) Unwrap() error {
+	return e.Err
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames
+// it into place, so a crash leaves either the old or the new contents and
+// never a truncated file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := ioutil.TempFile(dir, base+".tmp*")
+	if err != nil {
+		return err
+	}
+	// Clean up unless the rename below succeeds
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Make the rename itself durable where the platform allows it
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// backupPath returns the path of the nth newest backup of path
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// rotateBackups keeps a copy of the current contents of path as the newest
+// backup. Once the newest backup is older than BackupInterval the others
+// are shifted down first, dropping the oldest.
+func rotateBackups(path string, current []byte) error {
+	if info, err := os.Stat(backupPath(path, 1)); err == nil && time.Since(info.ModTime()) >= BackupInterval {
+		os.Remove(backupPath(path, Backups))
+		for n := Backups - 1; n >= 1; n-- {
+			if err := os.Rename(backupPath(path, n), backupPath(path, n+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return WriteFileAtomic(backupPath(path, 1), current, 0644)
+}
+
+// moveAside renames a damaged file out of the way and returns its new path
+func moveAside(path string) (string, error) {
+	moved := path + ".corrupt-" + time.Now().Format("20060102-150405")
+	if err := os.Rename(path, moved); err != nil {
+		return "", err
+	}
+	return moved, nil
+}
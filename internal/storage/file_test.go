@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "doc.json")
+	for _, data := range []string{"first", "second, longer than the first", "3"} {
+		if err := WriteFileAtomic(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("read %q, want %q", got, data)
+		}
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files left in the directory, want only the document", len(entries))
+	}
+	if perm := entries[0].Mode().Perm(); perm != 0600 && runtime.GOOS != "windows" {
+		t.Errorf("mode %v, want %v", perm, os.FileMode(0600))
+	}
+}
+
+func TestRotateBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "doc.json")
+	b := NewJSONBackend(path)
+	backups := func() []string {
+		var docs []string
+		for n := 1; n <= Backups+1; n++ {
+			if doc, err := ioutil.ReadFile(backupPath(path, n)); err == nil {
+				docs = append(docs, string(doc))
+			}
+		}
+		return docs
+	}
+	age := func() {
+		old := time.Now().Add(-2 * BackupInterval)
+		if err := os.Chtimes(backupPath(path, 1), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Saves within an interval only replace the newest backup
+	for i := 1; i <= 3; i++ {
+		if err := b.Write([]byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := backups(); strings.Join(got, ",") != "2" {
+		t.Errorf("backups after quick saves = %v, want [2]", got)
+	}
+
+	// Once the newest backup is old the others shift down, and the oldest
+	// drops off the end
+	for i := 4; i <= 4+Backups; i++ {
+		age()
+		if err := b.Write([]byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := strings.Join(backups(), ","), "7,6,5,4"; got != want {
+		t.Errorf("backups after spaced saves = %v, want %v", got, want)
+	}
+}
+
+func TestCorruptFileRecoveredFromBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "storage.json")
+	s, err := NewStorage(NewJSONBackend(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddHighScore(DefaultPlayer, 10, DefaultRuleset(), "")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s.AddHighScore(DefaultPlayer, 20, DefaultRuleset(), "")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := []byte(`{"version": 5, "profiles": [`)
+	if err := ioutil.WriteFile(path, bad, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewStorage(NewJSONBackend(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := s.Recovered()
+	if corrupt == nil {
+		t.Fatal("corrupt file was not reported")
+	}
+	if corrupt.Restored != backupPath(path, 1) {
+		t.Errorf("restored %q, want the newest backup", corrupt.Restored)
+	}
+	if moved, err := ioutil.ReadFile(corrupt.MovedTo); err != nil || !bytes.Equal(moved, bad) {
+		t.Errorf("corrupt file not kept aside at %q: %v", corrupt.MovedTo, err)
+	}
+
+	// The backup holds the data before the last save
+	scores := s.GetHighScores()
+	if len(scores) != 1 || scores[0].Score != 10 {
+		t.Errorf("high scores after recovery = %+v, want the one from the backup", scores)
+	}
+}
+
+func TestSaveRefusesNewerVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "storage.json")
+	s, err := NewStorage(NewJSONBackend(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A newer build saves while this one is running
+	doc := []byte(`{"version": 99, "profiles": [], "high_scores": [], "future": true}`)
+	if err := ioutil.WriteFile(path, doc, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s.AddHighScore(DefaultPlayer, 10, DefaultRuleset(), "")
+	var newer *VersionError
+	if err := s.Save(); !errors.As(err, &newer) {
+		t.Fatalf("Save error = %v, want a VersionError", err)
+	}
+	if after, _ := ioutil.ReadFile(path); !bytes.Equal(after, doc) {
+		t.Errorf("newer file changed to %s", after)
+	}
+}
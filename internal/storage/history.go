@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+const (
+	HistoryFile = "history.jsonl" // Name of the game history log, next to the storage file
+	maxRecord   = 64 << 20        // Longest line read from a JSON lines file
+)
+
+// GameRecord is one finished game in the history log
+type GameRecord struct {
+	Date    time.Time       `json:"date"`
+	Profile string          `json:"profile"`
+	Rules   Ruleset         `json:"rules"`
+	Result  game.GameResult `json:"result"`
+	Replay  string          `json:"replay,omitempty"` // Replay stored under this name
+}
+
+// AppendHistory adds a finished game to the end of the history log
+func (s *Storage) AppendHistory(rec GameRecord) error {
+	return s.backend.AppendHistory(rec)
+}
+
+// History reads every game in the history log, oldest first. Records that
+// do not parse, such as one cut short by a crash, are skipped.
+func (s *Storage) History() ([]GameRecord, error) {
+	return s.backend.History()
+}
+
+// appendRecord appends v to a JSON lines file and syncs it to disk. A
+// last line cut short by a crash is ended first so the new line stays
+// readable.
+func appendRecord(path string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// renameRecord moves rec to profile name if it is one of old's games,
+// reporting whether it was
+func renameRecord(rec *GameRecord, old, name string) bool {
+	if rec == nil || rec.Profile != old {
+		return false
+	}
+	rec.Profile = name
+	return true
+}
+
+// rewriteRecords replaces a JSON lines file with its lines passed through
+// fn. A line fn leaves alone keeps its exact bytes.
+func rewriteRecords(path string, fn func(line []byte) []byte) error {
+	var out bytes.Buffer
+	err := readRecords(path, func(line []byte) {
+		out.Write(fn(line))
+		out.WriteByte('\n')
+	})
+	if err != nil || out.Len() == 0 {
+		return err
+	}
+	return WriteFileAtomic(path, out.Bytes(), 0644)
+}
+
+// readRecords calls fn with each non-empty line of a JSON lines file. A
+// missing file has no lines.
+func readRecords(path string, fn func(line []byte)) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxRecord)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			fn(scanner.Bytes())
+		}
+	}
+	return scanner.Err()
+}
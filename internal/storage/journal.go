@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Journal layout
+const (
+	JournalExt   = ".journal" // Extension of the journal file
+	JournalSaves = 100        // Saves the journal grows to before it is compacted
+)
+
+// journalEntry is one line of a journal: a saved document or a finished game
+type journalEntry struct {
+	Save json.RawMessage `json:"save,omitempty"`
+	Game *GameRecord     `json:"game,omitempty"`
+}
+
+// journalSave is a saved document and where in the journal it is
+type journalSave struct {
+	line   int
+	offset int64
+	doc    []byte // Only filled in by scan
+}
+
+// journalIndex locates the saves in the journal up to size bytes, so
+// saving and loading read only the lines appended since the last look
+// rather than the whole history. A journal that was replaced or shrank is
+// indexed again from the start.
+type journalIndex struct {
+	file  os.FileInfo
+	size  int64
+	lines int
+	saves []journalSave
+}
+
+// JournalBackend keeps the document and the history in one append-only
+// file, one JSON object per line. Every save is appended, the newest one
+// that parses wins and the earlier ones serve as backups, so a crash can
+// at worst lose the line being written. Once the journal holds
+// JournalSaves saves it is rewritten with the history and only the newest
+// few saves. Replays are files in a directory next to the journal.
+type JournalBackend struct {
+	replayDir
+
+	path string
+
+	mu    sync.Mutex
+	index journalIndex
+}
+
+// OpenJournalBackend opens the journal at path. A journal that does not
+// exist yet starts from the JSON document at legacy and the history next
+// to it, if there are any, so switching backends keeps the data.
+func OpenJournalBackend(path, legacy string) (*JournalBackend, error) {
+	b := &JournalBackend{
+		replayDir: replayDir(filepath.Join(filepath.Dir(path), "replays")),
+		path:      path,
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) || legacy == "" {
+		return b, nil
+	}
+	doc, err := ioutil.ReadFile(legacy)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Starting journal %s from %s", path, legacy)
+	old := NewJSONBackend(legacy)
+	games, err := old.History()
+	if err != nil {
+		return nil, err
+	}
+	var entries []journalEntry
+	for i := range games {
+		entries = append(entries, journalEntry{Game: &games[i]})
+	}
+	entries = append(entries, journalEntry{Save: doc})
+	if err := b.rewrite(entries); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Lock takes an advisory lock on a file next to the journal
+func (b *JournalBackend) Lock() (func(), error) {
+	return lockFile(b.path + ".lock")
+}
+
+// Read returns the newest save in the journal, indented the way Storage
+// writes it
+func (b *JournalBackend) Read() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.update(); err != nil {
+		return nil, err
+	}
+	saves := b.index.saves
+	if len(saves) == 0 {
+		return nil, &os.PathError{Op: "read", Path: b.path, Err: os.ErrNotExist}
+	}
+	doc, err := b.readSave(saves[len(saves)-1])
+	if err != nil {
+		return nil, err
+	}
+	return indent(doc), nil
+}
+
+// Write appends a save, compacting the journal once it holds too many
+func (b *JournalBackend) Write(doc []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := appendRecord(b.path, journalEntry{Save: doc}); err != nil {
+		return err
+	}
+	if err := b.update(); err != nil || len(b.index.saves) <= JournalSaves {
+		return err
+	}
+
+	saves, games, err := b.scan()
+	if err != nil {
+		return err
+	}
+
+	// Keep the history and as many saves as the JSON backend keeps backups
+	var entries []journalEntry
+	for i := range games {
+		entries = append(entries, journalEntry{Game: &games[i]})
+	}
+	for _, s := range saves[len(saves)-Backups-1:] {
+		entries = append(entries, journalEntry{Save: s.doc})
+	}
+	return b.rewrite(entries)
+}
+
+// Recover appends the newest earlier save valid accepts again, making it
+// current. The damaged save stays in the journal.
+func (b *JournalBackend) Recover(valid func([]byte) bool) (string, string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.update(); err != nil {
+		return "", "", err
+	}
+
+	saves := b.index.saves
+	for i := len(saves) - 2; i >= 0; i-- {
+		doc, err := b.readSave(saves[i])
+		if err != nil {
+			return "", "", err
+		}
+		if !valid(indent(doc)) {
+			continue
+		}
+		if err := appendRecord(b.path, journalEntry{Save: doc}); err != nil {
+			return "", "", err
+		}
+		return "", fmt.Sprintf("the save on line %d", saves[i].line), nil
+	}
+	return "", "", nil
+}
+
+// AppendHistory appends a game to the journal under its lock
+func (b *JournalBackend) AppendHistory(rec GameRecord) error {
+	unlock, err := b.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return appendRecord(b.path, journalEntry{Game: &rec})
+}
+
+// History returns the games in the journal
+func (b *JournalBackend) History() ([]GameRecord, error) {
+	_, games, err := b.scan()
+	return games, err
+}
+
+// RenameHistory rewrites the journal with old's games under name. Saves
+// are kept as they are.
+func (b *JournalBackend) RenameHistory(old, name string) error {
+	unlock, err := b.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return rewriteRecords(b.path, func(line []byte) []byte {
+		var e journalEntry
+		if json.Unmarshal(line, &e) != nil || !renameRecord(e.Game, old, name) {
+			return line
+		}
+		if renamed, err := json.Marshal(e); err == nil {
+			return renamed
+		}
+		return line
+	})
+}
+
+func (b *JournalBackend) String() string {
+	return b.path
+}
+
+// scan reads the saves and games in the journal, oldest first, skipping
+// lines that do not parse
+func (b *JournalBackend) scan() ([]journalSave, []GameRecord, error) {
+	var saves []journalSave
+	var games []GameRecord
+	line := 0
+	err := readRecords(b.path, func(raw []byte) {
+		line++
+		var e journalEntry
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return
+		}
+		if e.Save != nil {
+			saves = append(saves, journalSave{line: line, doc: e.Save})
+		}
+		if e.Game != nil {
+			games = append(games, *e.Game)
+		}
+	})
+	return saves, games, err
+}
+
+// update brings the index up to date with the journal, reading only the
+// lines added since it was last updated. A trailing line without a newline
+// is still being written and is left for next time.
+func (b *JournalBackend) update() error {
+	info, err := os.Stat(b.path)
+	if os.IsNotExist(err) {
+		b.index = journalIndex{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if b.index.file == nil || !os.SameFile(b.index.file, info) || info.Size() < b.index.size {
+		b.index = journalIndex{}
+	}
+	b.index.file = info
+	if info.Size() == b.index.size {
+		return nil
+	}
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(b.index.size, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(f)
+	for {
+		raw, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset := b.index.size
+		b.index.size += int64(len(raw))
+
+		raw = bytes.TrimRight(raw, "\r\n")
+		if len(raw) == 0 {
+			continue
+		}
+		b.index.lines++
+		var e journalEntry
+		if json.Unmarshal(raw, &e) == nil && e.Save != nil {
+			b.index.saves = append(b.index.saves, journalSave{line: b.index.lines, offset: offset})
+		}
+	}
+}
+
+// readSave reads an indexed save back from the journal
+func (b *JournalBackend) readSave(s journalSave) ([]byte, error) {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(s.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	raw, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var e journalEntry
+	if err := json.Unmarshal(raw, &e); err != nil || e.Save == nil {
+		return nil, fmt.Errorf("%s changed while reading the save on line %d", b.path, s.line)
+	}
+	return e.Save, nil
+}
+
+// rewrite replaces the journal with the given entries
+func (b *JournalBackend) rewrite(entries []journalEntry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return WriteFileAtomic(b.path, buf.Bytes(), 0644)
+}
+
+// indent lays out a compact document the way json.MarshalIndent does, so
+// it compares equal to what was saved
+func indent(doc []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, doc, "", "  "); err != nil {
+		return doc
+	}
+	return buf.Bytes()
+}
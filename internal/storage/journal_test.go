@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournalSavesAndCompacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "storage"+JournalExt)
+	b, err := OpenJournalBackend(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AppendHistory(GameRecord{Profile: "alice", Replay: "7.gsr"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process appending to the same journal is seen by the next read
+	other, err := OpenJournalBackend(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3*JournalSaves; i++ {
+		w := b
+		if i%2 == 0 {
+			w = other
+		}
+		doc := []byte(fmt.Sprintf(`{"n":%d}`, i))
+		if err := w.Write(doc); err != nil {
+			t.Fatal(err)
+		}
+		got, err := b.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := indent(doc); string(got) != string(want) {
+			t.Fatalf("after save %d read %s, want %s", i, got, want)
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saves := strings.Count(string(data), `"save"`); saves > JournalSaves {
+		t.Errorf("journal holds %d saves, want it compacted to at most %d", saves, JournalSaves)
+	}
+	if games, err := b.History(); err != nil || len(games) != 1 || games[0].Replay != "7.gsr" {
+		t.Errorf("history after compaction = %+v, %v", games, err)
+	}
+}
+
+func TestJournalRecover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := OpenJournalBackend(filepath.Join(dir, "storage"+JournalExt), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range []string{`{"ok":1}`, `{"ok":2}`, `{"bad":3}`} {
+		if err := b.Write([]byte(doc)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, restored, err := b.Recover(func(doc []byte) bool { return strings.Contains(string(doc), "ok") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != "the save on line 2" {
+		t.Errorf("restored %q, want the save on line 2", restored)
+	}
+	got, err := b.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := indent([]byte(`{"ok":2}`)); string(got) != string(want) {
+		t.Errorf("read %s after recovery, want %s", got, want)
+	}
+}
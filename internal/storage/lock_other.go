@@ -0,0 +1,8 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package storage
+
+// lockFile does nothing on platforms without advisory file locks
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"os"
+	"sync"
+)
+
+// MemoryBackend keeps everything in memory, for tests and for sessions
+// that should leave nothing behind
+type MemoryBackend struct {
+	lock sync.Mutex // Taken by Lock
+
+	mu      sync.Mutex // Guards the fields below
+	doc     []byte
+	history []GameRecord
+	replays map[string][]byte
+}
+
+// NewMemoryBackend creates an empty in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{replays: make(map[string][]byte)}
+}
+
+// Lock takes the backend's lock
+func (b *MemoryBackend) Lock() (func(), error) {
+	b.lock.Lock()
+	return b.lock.Unlock, nil
+}
+
+// Read returns the document last written
+func (b *MemoryBackend) Read() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.doc == nil {
+		return nil, &os.PathError{Op: "read", Path: b.String(), Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), b.doc...), nil
+}
+
+// Write replaces the document
+func (b *MemoryBackend) Write(doc []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.doc = append([]byte(nil), doc...)
+	return nil
+}
+
+// Recover drops the document; memory keeps no backups
+func (b *MemoryBackend) Recover(valid func([]byte) bool) (string, string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.doc = nil
+	return "", "", nil
+}
+
+// AppendHistory adds a game to the history
+func (b *MemoryBackend) AppendHistory(rec GameRecord) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.history = append(b.history, rec)
+	return nil
+}
+
+// History returns the games added so far
+func (b *MemoryBackend) History() ([]GameRecord, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]GameRecord(nil), b.history...), nil
+}
+
+// RenameHistory moves old's games to name
+func (b *MemoryBackend) RenameHistory(old, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range b.history {
+		renameRecord(&b.history[i], old, name)
+	}
+	return nil
+}
+
+// WriteReplay stores a replay under a name
+func (b *MemoryBackend) WriteReplay(name string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.replays[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// ReadReplay returns a stored replay
+func (b *MemoryBackend) ReadReplay(name string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.replays[name]
+	if !ok {
+		return nil, &os.PathError{Op: "read", Path: name, Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (b *MemoryBackend) String() string {
+	return "memory"
+}
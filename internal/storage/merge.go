@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Imports merge records into the stored ones. A record counts as a
+// duplicate of one already stored when both have the same date and
+// player, so importing the same data twice adds nothing.
+
+// recordKey identifies a record by when it was made and by whom
+func recordKey(date time.Time, player string) string {
+	return date.UTC().Format(time.RFC3339Nano) + "\x00" + strings.ToLower(player)
+}
+
+// ImportScores adds the high scores not stored yet and returns them. The
+// verified mark of an import is never trusted: a score keeps its replay
+// link only if the replay is stored here, and is verified only if verify
+// re-simulates that replay to the score. Each ruleset is trimmed to TopN
+// afterwards as usual. With dryRun nothing changes or is verified.
+func (s *Storage) ImportScores(scores []HighScore, verify func(HighScore) bool, dryRun bool) []HighScore {
+	s.mu.Lock()
+	seen := make(map[string]bool)
+	for _, hs := range s.data.HighScores {
+		seen[recordKey(hs.Date, hs.Player)] = true
+	}
+	s.mu.Unlock()
+
+	var added []HighScore
+	for _, hs := range scores {
+		key := recordKey(hs.Date, hs.Player)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		hs.Verified = false
+		if hs.Replay != "" {
+			if _, err := s.backend.ReadReplay(hs.Replay); err != nil {
+				hs.Replay = ""
+			} else if verify != nil && !dryRun {
+				hs.Verified = verify(hs)
+			}
+		}
+		added = append(added, hs)
+	}
+	if dryRun || len(added) == 0 {
+		return added
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	top := s.topN()
+	s.change(func(d *GameData) {
+		// Another instance may have imported some of them meanwhile
+		have := make(map[string]bool)
+		for _, hs := range d.HighScores {
+			have[recordKey(hs.Date, hs.Player)] = true
+		}
+		for _, hs := range added {
+			if !have[recordKey(hs.Date, hs.Player)] {
+				d.HighScores = append(d.HighScores, hs)
+			}
+		}
+		rankScores(d, top)
+	})
+	return added
+}
+
+// ImportHistory appends the games not in the history yet, oldest first,
+// and returns them. With dryRun nothing changes.
+func (s *Storage) ImportHistory(records []GameRecord, dryRun bool) ([]GameRecord, error) {
+	history, err := s.backend.History()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, rec := range history {
+		seen[recordKey(rec.Date, rec.Profile)] = true
+	}
+
+	var added []GameRecord
+	for _, rec := range records {
+		key := recordKey(rec.Date, rec.Profile)
+		if !seen[key] {
+			seen[key] = true
+			added = append(added, rec)
+		}
+	}
+	sort.SliceStable(added, func(i, j int) bool {
+		return added[i].Date.Before(added[j].Date)
+	})
+	if dryRun {
+		return added, nil
+	}
+
+	for i, rec := range added {
+		if err := s.backend.AppendHistory(rec); err != nil {
+			return added[:i], err
+		}
+	}
+	return added, nil
+}
+
+// ImportProfiles adds the profiles not stored yet and returns them, along
+// with those skipped because a different profile, one created at another
+// time, has the name or because the name is not valid. With dryRun
+// nothing changes.
+func (s *Storage) ImportProfiles(profiles []Profile, dryRun bool) (added, skipped []Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check the names against the stored profiles and the ones added so far
+	check := GameData{Profiles: append([]Profile(nil), s.data.Profiles...)}
+	for _, p := range profiles {
+		if existing := findProfileFold(&check, p.Name); existing != nil && existing.Created.Equal(p.Created) {
+			continue
+		}
+		name, err := cleanProfileName(&check, p.Name, "")
+		if err != nil {
+			skipped = append(skipped, p)
+			continue
+		}
+		p.Name = name
+		if p.Settings == (Settings{}) {
+			p.Settings = DefaultSettings()
+		}
+		check.Profiles = append(check.Profiles, p)
+		added = append(added, p)
+	}
+	if dryRun || len(added) == 0 {
+		return added, skipped
+	}
+
+	s.change(func(d *GameData) {
+		for _, p := range added {
+			if findProfileFold(d, p.Name) == nil {
+				d.Profiles = append(d.Profiles, p)
+			}
+		}
+	})
+	return added, skipped
+}
+
+// findProfileFold returns the profile in d named name in any case, or nil
+func findProfileFold(d *GameData, name string) *Profile {
+	name = strings.TrimSpace(name)
+	for i := range d.Profiles {
+		if strings.EqualFold(d.Profiles[i].Name, name) {
+			return &d.Profiles[i]
+		}
+	}
+	return nil
+}
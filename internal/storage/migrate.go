@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// SchemaVersion is the version of the storage file written by this build.
+// Files without a version field are version 0.
+const SchemaVersion = 5
+
+// migration upgrades a decoded storage file by one version. Files are
+// migrated as generic JSON so fields can be renamed or restructured.
+type migration func(doc map[string]interface{}) error
+
+// migrations[n] upgrades a version n file to version n+1. Add a step here
+// and bump SchemaVersion whenever the shape of GameData changes.
+var migrations = []migration{
+	migrateV0,
+	migrateV1,
+	migrateV2,
+	migrateV3,
+	migrateV4,
+}
+
+// VersionError reports a storage file written by a newer build, which
+// this one refuses to load or overwrite
+type VersionError struct {
+	Path    string
+	Version int
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s was written by a newer version of go-snake (schema %d, this build supports up to %d)", e.Path, e.Version, SchemaVersion)
+}
+
+// decode parses a storage file of any supported version, upgrading it to
+// SchemaVersion. Files that do not parse or migrate are reported as a
+// *CorruptError, files from a newer build as a *VersionError.
+func decode(path string, raw []byte) (GameData, error) {
+	var data GameData
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return data, &CorruptError{Path: path, Err: err}
+	}
+	if doc == nil {
+		return data, &CorruptError{Path: path, Err: errors.New("file holds no data")}
+	}
+
+	version := 0
+	if v, ok := doc["version"]; ok {
+		f, ok := v.(float64)
+		if !ok || f < 0 || f != math.Trunc(f) {
+			return data, &CorruptError{Path: path, Err: fmt.Errorf("invalid schema version %v", v)}
+		}
+		version = int(f)
+	}
+	if version > SchemaVersion {
+		return data, &VersionError{Path: path, Version: version}
+	}
+
+	// Apply each step in turn so every version has a single upgrade path
+	for ; version < SchemaVersion; version++ {
+		if err := migrations[version](doc); err != nil {
+			return data, &CorruptError{Path: path, Err: fmt.Errorf("upgrading from schema %d: %w", version, err)}
+		}
+		doc["version"] = version + 1
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return data, err
+	}
+	if err := json.Unmarshal(upgraded, &data); err != nil {
+		return data, &CorruptError{Path: path, Err: err}
+	}
+	return data, nil
+}
+
+// migrateV0 upgrades files written before the schema was versioned.
+// Their score list could be null and hand-edited files could leave out
+// settings, which would load as muted volumes and no difficulty.
+func migrateV0(doc map[string]interface{}) error {
+	if doc["high_scores"] == nil {
+		doc["high_scores"] = []interface{}{}
+	}
+	scores, ok := doc["high_scores"].([]interface{})
+	if !ok {
+		return errors.New("high_scores is not a list")
+	}
+	for i, entry := range scores {
+		score, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("high score %d is not an object", i)
+		}
+		if name, _ := score["player"].(string); name == "" {
+			score["player"] = DefaultPlayer
+		}
+	}
+
+	if doc["settings"] == nil {
+		doc["settings"] = map[string]interface{}{}
+	}
+	settings, ok := doc["settings"].(map[string]interface{})
+	if !ok {
+		return errors.New("settings is not an object")
+	}
+	defaults := DefaultSettings()
+	setDefault(settings, "music_volume", defaults.MusicVolume)
+	setDefault(settings, "sfx_volume", defaults.SfxVolume)
+	setDefault(settings, "difficulty", defaults.Difficulty)
+
+	return nil
+}
+
+// migrateV1 moves the settings into a profile named after the default
+// player, whom every score so far was recorded under, and makes it active
+func migrateV1(doc map[string]interface{}) error {
+	settings, _ := doc["settings"].(map[string]interface{})
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	delete(doc, "settings")
+
+	// Seed the stats with the best score the list still holds
+	best := 0.0
+	scores, _ := doc["high_scores"].([]interface{})
+	for _, entry := range scores {
+		score, _ := entry.(map[string]interface{})
+		if score["player"] == DefaultPlayer {
+			if n, _ := score["score"].(float64); n > best {
+				best = n
+			}
+		}
+	}
+
+	doc["profiles"] = []interface{}{
+		map[string]interface{}{
+			"name":     DefaultPlayer,
+			"created":  time.Now(),
+			"settings": settings,
+			"stats":    map[string]interface{}{"best_score": best},
+		},
+	}
+	doc["active_profile"] = DefaultPlayer
+	return nil
+}
+
+// migrateV2 ranks the existing high scores, which were kept in one list,
+// under the default ruleset
+func migrateV2(doc map[string]interface{}) error {
+	raw, err := json.Marshal(DefaultRuleset())
+	if err != nil {
+		return err
+	}
+
+	scores, _ := doc["high_scores"].([]interface{})
+	for i, entry := range scores {
+		score, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("high score %d is not an object", i)
+		}
+		var rules map[string]interface{}
+		if err := json.Unmarshal(raw, &rules); err != nil {
+			return err
+		}
+		score["rules"] = rules
+	}
+	return nil
+}
+
+// migrateV3 marks the start of achievement progress in profiles. Older
+// files have none to convert, but the version bump stops older builds
+// from saving over the progress they do not know about.
+func migrateV3(doc map[string]interface{}) error {
+	return nil
+}
+
+// migrateV4 gives every profile the display and key binding settings,
+// which the settings screen changes
+func migrateV4(doc map[string]interface{}) error {
+	defaults := DefaultSettings()
+	keys := map[string]interface{}{
+		"up":    defaults.Keys.Up,
+		"down":  defaults.Keys.Down,
+		"left":  defaults.Keys.Left,
+		"right": defaults.Keys.Right,
+		"pause": defaults.Keys.Pause,
+	}
+
+	profiles, _ := doc["profiles"].([]interface{})
+	for i, entry := range profiles {
+		profile, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("profile %d is not an object", i)
+		}
+		if profile["settings"] == nil {
+			profile["settings"] = map[string]interface{}{}
+		}
+		settings, ok := profile["settings"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("settings of profile %d are not an object", i)
+		}
+		setDefault(settings, "music_volume", defaults.MusicVolume)
+		setDefault(settings, "sfx_volume", defaults.SfxVolume)
+		setDefault(settings, "difficulty", defaults.Difficulty)
+		setDefault(settings, "theme", defaults.Theme)
+		setDefault(settings, "fullscreen", defaults.Fullscreen)
+		setDefault(settings, "vsync", defaults.Vsync)
+		setDefault(settings, "grid_lines", defaults.GridLines)
+		setDefault(settings, "keys", keys)
+	}
+	return nil
+}
+
+// setDefault fills in a missing or null field of a JSON object
+func setDefault(obj map[string]interface{}, key string, value interface{}) {
+	if obj[key] == nil {
+		obj[key] = value
+	}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixtures holds a storage file as written by each older schema version
+var fixtures = map[int]string{
+	0: `{"high_scores": [{"player": "", "score": 40, "date": "2024-01-02T03:04:05Z"}],
+		"settings": {"music_volume": 0.2}}`,
+	1: `{"version": 1, "high_scores": [{"player": "Player", "score": 40, "date": "2024-01-02T03:04:05Z"}],
+		"settings": {"music_volume": 0.2, "sfx_volume": 0.8, "difficulty": "hard"}}`,
+	2: `{"version": 2, "high_scores": [{"player": "Player", "score": 40, "date": "2024-01-02T03:04:05Z"}],
+		"profiles": [{"name": "Player", "created": "2024-01-01T00:00:00Z",
+			"settings": {"music_volume": 0.2, "sfx_volume": 0.8, "difficulty": "hard"}, "stats": {"best_score": 40}}],
+		"active_profile": "Player"}`,
+	3: `{"version": 3, "high_scores": [],
+		"profiles": [{"name": "Player", "created": "2024-01-01T00:00:00Z",
+			"settings": {"music_volume": 0.2, "sfx_volume": 0.8, "difficulty": "hard"}, "stats": {"best_score": 40}}],
+		"active_profile": "Player"}`,
+	4: `{"version": 4, "high_scores": [],
+		"profiles": [{"name": "Player", "created": "2024-01-01T00:00:00Z",
+			"settings": {"music_volume": 0.2, "sfx_volume": 0.8, "difficulty": "hard"}, "stats": {"best_score": 40},
+			"achievements": {"regular": {"progress": 3, "unlocked": "0001-01-01T00:00:00Z"}}}],
+		"active_profile": "Player"}`,
+}
+
+// parseFixture decodes the fixture of a version as generic JSON
+func parseFixture(t *testing.T, version int) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(fixtures[version]), &doc); err != nil {
+		t.Fatalf("fixture v%d: %v", version, err)
+	}
+	return doc
+}
+
+func TestMigrationSteps(t *testing.T) {
+	tests := []struct {
+		version int
+		check   func(t *testing.T, doc map[string]interface{})
+	}{
+		{0, func(t *testing.T, doc map[string]interface{}) {
+			score := doc["high_scores"].([]interface{})[0].(map[string]interface{})
+			if score["player"] != DefaultPlayer {
+				t.Errorf("player = %v, want %q", score["player"], DefaultPlayer)
+			}
+			settings := doc["settings"].(map[string]interface{})
+			if settings["music_volume"] != 0.2 || settings["difficulty"] != DefaultSettings().Difficulty {
+				t.Errorf("settings = %v, want the volume kept and the difficulty defaulted", settings)
+			}
+		}},
+		{1, func(t *testing.T, doc map[string]interface{}) {
+			if doc["settings"] != nil {
+				t.Error("settings were left at the top level")
+			}
+			profile := doc["profiles"].([]interface{})[0].(map[string]interface{})
+			if profile["name"] != DefaultPlayer || doc["active_profile"] != DefaultPlayer {
+				t.Errorf("profile %v, active %v, want %q", profile["name"], doc["active_profile"], DefaultPlayer)
+			}
+			if best := profile["stats"].(map[string]interface{})["best_score"]; best != 40.0 {
+				t.Errorf("best score = %v, want 40", best)
+			}
+			if d := profile["settings"].(map[string]interface{})["difficulty"]; d != "hard" {
+				t.Errorf("difficulty = %v, want hard", d)
+			}
+		}},
+		{2, func(t *testing.T, doc map[string]interface{}) {
+			score := doc["high_scores"].([]interface{})[0].(map[string]interface{})
+			rules, _ := score["rules"].(map[string]interface{})
+			if rules["mode"] != DefaultRuleset().Mode || rules["board"] != float64(DefaultRuleset().Board) {
+				t.Errorf("rules = %v, want the default ruleset", rules)
+			}
+		}},
+		{3, func(t *testing.T, doc map[string]interface{}) {
+			if len(doc["profiles"].([]interface{})) != 1 {
+				t.Error("profiles changed")
+			}
+		}},
+		{4, func(t *testing.T, doc map[string]interface{}) {
+			settings := doc["profiles"].([]interface{})[0].(map[string]interface{})["settings"].(map[string]interface{})
+			if settings["theme"] != DefaultSettings().Theme || settings["vsync"] != true || settings["grid_lines"] != true {
+				t.Errorf("settings = %v, want display defaults", settings)
+			}
+			if settings["difficulty"] != "hard" {
+				t.Errorf("difficulty = %v, want hard kept", settings["difficulty"])
+			}
+			keys, _ := settings["keys"].(map[string]interface{})
+			if keys["up"] != DefaultKeys().Up || keys["pause"] != DefaultKeys().Pause {
+				t.Errorf("keys = %v, want the default bindings", keys)
+			}
+		}},
+	}
+	if len(tests) != SchemaVersion {
+		t.Fatalf("%d migration steps tested, SchemaVersion is %d", len(tests), SchemaVersion)
+	}
+
+	for _, tt := range tests {
+		doc := parseFixture(t, tt.version)
+		if err := migrations[tt.version](doc); err != nil {
+			t.Fatalf("migrateV%d: %v", tt.version, err)
+		}
+		tt.check(t, doc)
+	}
+}
+
+func TestMigrationChain(t *testing.T) {
+	for version := 0; version < SchemaVersion; version++ {
+		data, err := decode("fixture", []byte(fixtures[version]))
+		if err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+		if len(data.Profiles) != 1 || data.Profiles[0].Name != DefaultPlayer {
+			t.Fatalf("v%d: profiles = %+v, want one %q", version, data.Profiles, DefaultPlayer)
+		}
+
+		p := data.Profiles[0]
+		if p.Settings.MusicVolume != 0.2 {
+			t.Errorf("v%d: music volume = %v, want 0.2", version, p.Settings.MusicVolume)
+		}
+		if p.Settings.Theme != DefaultSettings().Theme || p.Settings.Keys != DefaultKeys() || !p.Settings.Vsync {
+			t.Errorf("v%d: settings = %+v, want display defaults", version, p.Settings)
+		}
+		for _, hs := range data.HighScores {
+			if hs.Player != DefaultPlayer || hs.Rules != DefaultRuleset() {
+				t.Errorf("v%d: high score %+v, want the default player and ruleset", version, hs)
+			}
+		}
+	}
+
+	// Progress stored by the newest migrated version survives
+	data, err := decode("fixture", []byte(fixtures[4]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data.Profiles[0].Achievements["regular"].Progress; got != 3 {
+		t.Errorf("achievement progress = %d, want 3", got)
+	}
+}
+
+func TestNewerVersionRefused(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "storage.json")
+	doc := []byte(`{"version": 99, "profiles": [], "high_scores": [], "future": true}`)
+	if err := ioutil.WriteFile(path, doc, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewStorage(NewJSONBackend(path))
+	var newer *VersionError
+	if !errors.As(err, &newer) || newer.Version != 99 {
+		t.Fatalf("NewStorage error = %v, want a VersionError for schema 99", err)
+	}
+
+	after, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(after, doc) {
+		t.Errorf("file changed to %s", after)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the directory, want only the original", len(entries))
+	}
+}
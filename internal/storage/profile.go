@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxProfileName is the longest profile name accepted, in characters
+const MaxProfileName = 20
+
+// Profile is a named player with their own settings, stats and unlocks
+type Profile struct {
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Settings Settings  `json:"settings"`
+	Stats    Stats     `json:"stats"`
+	Unlocks  []string  `json:"unlocks,omitempty"` // Names from the Unlocks table, in the order earned
+
+	// Progress toward each achievement, keyed by its ID
+	Achievements map[string]AchievementProgress `json:"achievements,omitempty"`
+}
+
+// AchievementProgress is how far a profile has come toward an achievement
+type AchievementProgress struct {
+	Progress int       `json:"progress"`
+	Unlocked time.Time `json:"unlocked"` // Zero until the goal is reached
+}
+
+// Done reports whether the achievement has been unlocked
+func (a AchievementProgress) Done() bool {
+	return !a.Unlocked.IsZero()
+}
+
+// Stats totals a profile's finished local games
+type Stats struct {
+	GamesPlayed  int `json:"games_played"`
+	TotalScore   int `json:"total_score"`
+	BestScore    int `json:"best_score"`
+	LongestSnake int `json:"longest_snake"`
+	TotalTicks   int `json:"total_ticks"` // Ticks survived across all games
+}
+
+// Unlock is a reward a profile earns by reaching a best score
+type Unlock struct {
+	Name  string
+	Score int
+}
+
+// Unlocks lists the rewards in the order they are earned. Levels are
+// named "level:" followed by the level; those not listed are always open.
+var Unlocks = []Unlock{
+	{"level:box", 10},
+	{"level:pillars", 25},
+	{"level:cross", 50},
+}
+
+// Has reports whether the profile has earned the named unlock
+func (p Profile) Has(unlock string) bool {
+	for _, u := range p.Unlocks {
+		if u == unlock {
+			return true
+		}
+	}
+	return false
+}
+
+// Locked returns the best score the profile needs to earn the named
+// unlock, or 0 if it may already use it
+func (p Profile) Locked(unlock string) int {
+	for _, u := range Unlocks {
+		if u.Name == unlock && !p.Has(unlock) && p.Stats.BestScore < u.Score {
+			return u.Score
+		}
+	}
+	return 0
+}
+
+// newProfile creates a profile with the default settings
+func newProfile(name string) Profile {
+	return Profile{
+		Name:     name,
+		Created:  time.Now(),
+		Settings: DefaultSettings(),
+	}
+}
+
+// findProfile returns the named profile in d, or nil
+func findProfile(d *GameData, name string) *Profile {
+	for i := range d.Profiles {
+		if d.Profiles[i].Name == name {
+			return &d.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// ensureProfile returns the named profile in d, adding it if another
+// instance's data does not have it
+func ensureProfile(d *GameData, name string) *Profile {
+	if p := findProfile(d, name); p != nil {
+		return p
+	}
+	d.Profiles = append(d.Profiles, newProfile(name))
+	return &d.Profiles[len(d.Profiles)-1]
+}
+
+// cleanProfileName validates a new profile name against the existing ones
+func cleanProfileName(d *GameData, name, except string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("profile name is empty")
+	}
+	if len([]rune(name)) > MaxProfileName {
+		return "", fmt.Errorf("profile name is longer than %d characters", MaxProfileName)
+	}
+	for _, p := range d.Profiles {
+		if strings.EqualFold(p.Name, name) && p.Name != except {
+			return "", fmt.Errorf("profile %q already exists", p.Name)
+		}
+	}
+	return name, nil
+}
+
+// Profiles returns every profile in the order they were created
+func (s *Storage) Profiles() []Profile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Profile(nil), s.data.Profiles...)
+}
+
+// ActiveProfile returns the profile scores are attributed to
+func (s *Storage) ActiveProfile() Profile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := findProfile(&s.data, s.active); p != nil {
+		active := *p
+		// Hand out a copy of the progress, which later games change
+		if p.Achievements != nil {
+			active.Achievements = make(map[string]AchievementProgress, len(p.Achievements))
+			for id, a := range p.Achievements {
+				active.Achievements[id] = a
+			}
+		}
+		return active
+	}
+	return newProfile(s.active)
+}
+
+// SetActiveProfile switches to an existing profile
+func (s *Storage) SetActiveProfile(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if findProfile(&s.data, name) == nil {
+		return fmt.Errorf("no profile named %q", name)
+	}
+
+	s.active = name
+	s.change(func(d *GameData) {
+		ensureProfile(d, name)
+		d.Active = name
+	})
+	return nil
+}
+
+// CreateProfile adds a profile with the default settings and returns its
+// cleaned-up name
+func (s *Storage) CreateProfile(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, err := cleanProfileName(&s.data, name, "")
+	if err != nil {
+		return "", err
+	}
+
+	profile := newProfile(name)
+	s.change(func(d *GameData) {
+		if findProfile(d, name) == nil {
+			d.Profiles = append(d.Profiles, profile)
+		}
+	})
+	return name, nil
+}
+
+// RenameProfile renames a profile along with the high scores and history
+// attributed to it, and returns the cleaned-up new name. The rename is
+// saved before the history is rewritten, and undone again if the history
+// cannot be, so the two never disagree about the profile's name.
+func (s *Storage) RenameProfile(old, name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if findProfile(&s.data, old) == nil {
+		return "", fmt.Errorf("no profile named %q", old)
+	}
+	name, err := cleanProfileName(&s.data, name, old)
+	if err != nil {
+		return "", err
+	}
+
+	s.renameLocked(old, name)
+	if err := s.saveLocked(); err != nil {
+		// Forget the rename rather than leave it for the next save
+		s.pending = s.pending[:len(s.pending)-1]
+		renameIn(&s.data, name, old)
+		if s.active == name {
+			s.active = old
+		}
+		return "", err
+	}
+
+	if err := s.backend.RenameHistory(old, name); err != nil {
+		s.renameLocked(name, old)
+		if err2 := s.saveLocked(); err2 != nil {
+			return "", fmt.Errorf("%v; undoing the rename failed too: %v", err, err2)
+		}
+		return "", err
+	}
+	return name, nil
+}
+
+// renameLocked renames a profile and its high scores in the data and for
+// the next save. Must be called with the lock held.
+func (s *Storage) renameLocked(old, name string) {
+	if s.active == old {
+		s.active = name
+	}
+	s.change(func(d *GameData) { renameIn(d, old, name) })
+}
+
+// renameIn renames a profile, and the high scores attributed to it, in
+// the data
+func renameIn(d *GameData, old, name string) {
+	if p := findProfile(d, old); p != nil {
+		p.Name = name
+	}
+	if d.Active == old {
+		d.Active = name
+	}
+	for i := range d.HighScores {
+		if d.HighScores[i].Player == old {
+			d.HighScores[i].Player = name
+		}
+	}
+}
+
+// RecordGame adds a finished game to a profile's stats and returns the
+// unlocks it earned
+func (s *Storage) RecordGame(name string, score, length, ticks int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var earned []string
+	s.change(func(d *GameData) {
+		p := ensureProfile(d, name)
+		p.Stats.GamesPlayed++
+		p.Stats.TotalScore += score
+		p.Stats.TotalTicks += ticks
+		if score > p.Stats.BestScore {
+			p.Stats.BestScore = score
+		}
+		if length > p.Stats.LongestSnake {
+			p.Stats.LongestSnake = length
+		}
+
+		earned = nil
+		for _, u := range Unlocks {
+			if p.Stats.BestScore >= u.Score && !p.Has(u.Name) {
+				p.Unlocks = append(p.Unlocks, u.Name)
+				earned = append(earned, u.Name)
+			}
+		}
+	})
+	return earned
+}
+
+// UpdateAchievements lets fn change a profile's achievement progress.
+// fn may run again on the data of another running instance when saving,
+// so it should add to or raise the progress it finds rather than set it.
+func (s *Storage) UpdateAchievements(name string, fn func(progress map[string]AchievementProgress)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.change(func(d *GameData) {
+		p := ensureProfile(d, name)
+		if p.Achievements == nil {
+			p.Achievements = make(map[string]AchievementProgress)
+		}
+		fn(p.Achievements)
+	})
+}
+
+// UnlockLabel turns an unlock name such as "level:box" into text for
+// players, "level box"
+func UnlockLabel(unlock string) string {
+	return strings.Replace(unlock, ":", " ", 1)
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenameProfileKeepsHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	journal, err := OpenJournalBackend(filepath.Join(dir, "journal"+JournalExt), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backends := map[string]Backend{
+		"json":    NewJSONBackend(filepath.Join(dir, "storage.json")),
+		"journal": journal,
+		"memory":  NewMemoryBackend(),
+	}
+
+	for name, b := range backends {
+		t.Run(name, func(t *testing.T) {
+			s, err := NewStorage(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.CreateProfile("Ann"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Save(); err != nil {
+				t.Fatal(err)
+			}
+			for i, profile := range []string{"Ann", DefaultPlayer, "Ann"} {
+				rec := GameRecord{Date: time.Unix(int64(i), 0), Profile: profile, Rules: DefaultRuleset()}
+				if err := s.AppendHistory(rec); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := s.RenameProfile("Ann", "Anna"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			history, err := s.History()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, rec := range history {
+				got = append(got, rec.Profile)
+			}
+			want := []string{"Anna", DefaultPlayer, "Anna"}
+			if len(got) != len(want) {
+				t.Fatalf("history profiles = %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("history profiles = %v, want %v", got, want)
+				}
+			}
+
+			// The stored document agrees on the new name
+			reloaded, err := NewStorage(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			renamed := false
+			for _, p := range reloaded.Profiles() {
+				renamed = renamed || p.Name == "Anna"
+			}
+			if !renamed {
+				t.Errorf("profiles after reloading = %+v, want Anna", reloaded.Profiles())
+			}
+		})
+	}
+}
+
+// failingBackend is a memory backend whose document writes or history
+// rewrites can be made to fail
+type failingBackend struct {
+	*MemoryBackend
+	failWrite, failRename bool
+}
+
+func (b *failingBackend) Write(data []byte) error {
+	if b.failWrite {
+		return errors.New("disk full")
+	}
+	return b.MemoryBackend.Write(data)
+}
+
+func (b *failingBackend) RenameHistory(old, name string) error {
+	if b.failRename {
+		return errors.New("history unavailable")
+	}
+	return b.MemoryBackend.RenameHistory(old, name)
+}
+
+func TestRenameProfileFailures(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		failWrite, failRename bool
+	}{
+		{"save fails", true, false},
+		{"history rewrite fails", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &failingBackend{MemoryBackend: NewMemoryBackend()}
+			s, err := NewStorage(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.CreateProfile("Ann"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetActiveProfile("Ann"); err != nil {
+				t.Fatal(err)
+			}
+			s.AddHighScore("Ann", 10, DefaultRuleset(), "")
+			if err := s.Save(); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.AppendHistory(GameRecord{Date: time.Unix(1, 0), Profile: "Ann", Rules: DefaultRuleset()}); err != nil {
+				t.Fatal(err)
+			}
+
+			b.failWrite, b.failRename = tc.failWrite, tc.failRename
+			if _, err := s.RenameProfile("Ann", "Anna"); err == nil {
+				t.Fatal("rename succeeded")
+			}
+			b.failWrite, b.failRename = false, false
+			if err := s.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Everything still agrees on the old name, in memory and stored
+			reloaded, err := NewStorage(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, st := range []*Storage{s, reloaded} {
+				if active := st.ActiveProfile().Name; active != "Ann" {
+					t.Errorf("active profile %q, want Ann", active)
+				}
+				if hs := st.GetHighScores(); len(hs) != 1 || hs[0].Player != "Ann" {
+					t.Errorf("high scores %+v, want one of Ann's", hs)
+				}
+			}
+			history, err := s.History()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != 1 || history[0].Profile != "Ann" {
+				t.Errorf("history %+v, want one game of Ann's", history)
+			}
+		})
+	}
+}
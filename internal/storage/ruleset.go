@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+)
+
+// DefaultTopN is how many high scores each ruleset keeps when the config
+// does not say
+const DefaultTopN = 10
+
+// Ruleset identifies the rules a game was played under. High scores are
+// only ranked against games with the same ruleset.
+type Ruleset struct {
+	Mode           string  `json:"mode"`
+	Board          int     `json:"board"` // Width and height of the board
+	InitialSpeed   float64 `json:"initial_speed"`
+	SpeedIncrement float64 `json:"speed_increment"`
+	MaxSpeed       float64 `json:"max_speed"`
+	Difficulty     string  `json:"difficulty"`
+	Level          string  `json:"level"`
+}
+
+// RulesetOf returns the ruleset of a game played with cfg at a difficulty
+func RulesetOf(cfg *config.Config, difficulty string) Ruleset {
+	r := Ruleset{
+		Mode:           cfg.Game.Mode,
+		Board:          cfg.Game.GridSize,
+		InitialSpeed:   cfg.Game.InitialSpeed,
+		SpeedIncrement: cfg.Game.SpeedIncrement,
+		MaxSpeed:       cfg.Game.MaxSpeed,
+		Difficulty:     difficulty,
+		Level:          cfg.Game.Level,
+	}
+	if r.Mode == "" {
+		r.Mode = game.ModeClassic
+	}
+	if r.Mode == game.ModeRoyale {
+		r.Board = cfg.Royale.GridSize
+	}
+	if r.Level == "" {
+		r.Level = game.LevelOpen
+	}
+	return r
+}
+
+// DefaultRuleset is the ruleset of the config shipped with the game at
+// the default difficulty. Scores recorded before rulesets existed are
+// ranked under it.
+func DefaultRuleset() Ruleset {
+	cfg, err := config.Default()
+	if err != nil {
+		return Ruleset{Mode: game.ModeClassic, Difficulty: DefaultSettings().Difficulty, Level: game.LevelOpen}
+	}
+	return RulesetOf(cfg, DefaultSettings().Difficulty)
+}
+
+// String describes the ruleset, such as
+// "classic 20x20 open, speed 3+0.3 up to 12, medium"
+func (r Ruleset) String() string {
+	return fmt.Sprintf("%s %dx%d %s, speed %g+%g up to %g, %s",
+		r.Mode, r.Board, r.Board, r.Level, r.InitialSpeed, r.SpeedIncrement, r.MaxSpeed, r.Difficulty)
+}
+
+// HighScoresFor returns the high scores of one ruleset, best first
+func (s *Storage) HighScoresFor(rules Ruleset) []HighScore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var scores []HighScore
+	for _, hs := range s.data.HighScores {
+		if hs.Rules == rules {
+			scores = append(scores, hs)
+		}
+	}
+	return scores
+}
+
+// Rulesets returns the rulesets that have high scores, in order of their
+// best score
+func (s *Storage) Rulesets() []Ruleset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rulesets []Ruleset
+	seen := make(map[Ruleset]bool)
+	for _, hs := range s.data.HighScores {
+		if !seen[hs.Rules] {
+			seen[hs.Rules] = true
+			rulesets = append(rulesets, hs.Rules)
+		}
+	}
+	return rulesets
+}
@@ -1,136 +1,431 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
 	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/paths"
 )
 
+// DefaultPlayer is the name scores are recorded under when no player is set
+const DefaultPlayer = "Player"
+
 // HighScore represents a player's high score
 type HighScore struct {
-	Player string    `json:"player"`
-	Score  int       `json:"score"`
-	Date   time.Time `json:"date"`
+	Player   string    `json:"player"`
+	Score    int       `json:"score"`
+	Date     time.Time `json:"date"`
+	Rules    Ruleset   `json:"rules"`            // Ruleset the score is ranked under
+	Replay   string    `json:"replay,omitempty"` // Replay stored under this name
+	Verified bool      `json:"verified"`         // Backed by a replay that re-simulates to this score
 }
 
-// Settings represents user settings
+// Settings represents a profile's settings
 type Settings struct {
-	MusicVolume float64 `json:"music_volume"`
-	SfxVolume   float64 `json:"sfx_volume"`
-	Difficulty  string  `json:"difficulty"`
+	MusicVolume float64     `json:"music_volume"` // 0 to 1, saved for when the game has audio
+	SfxVolume   float64     `json:"sfx_volume"`   // 0 to 1, saved for when the game has audio
+	Difficulty  string      `json:"difficulty"`   // One of game.Difficulties
+	Theme       string      `json:"theme"`        // Board colors, one of render.Themes
+	Fullscreen  bool        `json:"fullscreen"`
+	Vsync       bool        `json:"vsync"`
+	GridLines   bool        `json:"grid_lines"`
+	Keys        KeyBindings `json:"keys"`
+}
+
+// KeyBindings names the keys that steer and pause the game in the GUI,
+// e.g. "arrowup" or "w"
+type KeyBindings struct {
+	Up    string `json:"up"`
+	Down  string `json:"down"`
+	Left  string `json:"left"`
+	Right string `json:"right"`
+	Pause string `json:"pause"`
+}
+
+// QueuedScore is a finished run waiting to be submitted to the shared leaderboard
+type QueuedScore struct {
+	Player      string    `json:"player"`
+	Score       int       `json:"score"`
+	Length      int       `json:"length"`
+	Ticks       int       `json:"ticks"`
+	Mode        string    `json:"mode"`
+	Board       int       `json:"board"`
+	Difficulty  string    `json:"difficulty"`
+	Date        time.Time `json:"date"`
+	Replay      string    `json:"replay,omitempty"` // Replay stored under this name
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
 }
 
 // GameData represents all persistent game data
 type GameData struct {
-	HighScores []HighScore `json:"high_scores"`
-	Settings   Settings    `json:"settings"`
+	Version    int           `json:"version"` // Schema version, see SchemaVersion
+	Profiles   []Profile     `json:"profiles"`
+	Active     string        `json:"active_profile"` // Profile used last
+	HighScores []HighScore   `json:"high_scores"`
+	Outbox     []QueuedScore `json:"outbox,omitempty"` // Scores not yet accepted by the leaderboard
 }
 
-// Storage handles game data persistence
+// Storage handles game data persistence through a Backend. Saves happen
+// under the backend's lock, and changes are applied on top of whatever
+// another running instance saved in the meantime.
 type Storage struct {
-	filePath string
-	data     GameData
-}
-
-// NewStorage creates a new storage instance
-func NewStorage() (*Storage, error) {
-	storagePath := findStoragePath()
-	storage := &Storage{filePath: storagePath}
-
-	// Try to load existing data, or create default if file doesn't exist
-	if err := storage.Load(); err != nil {
-		// Create default data
-		storage.data = GameData{
-			HighScores: []HighScore{},
-			Settings: Settings{
-				MusicVolume: 0.7,
-				SfxVolume:   0.8,
-				Difficulty:  "medium",
-			},
+	TopN int // High scores kept for each ruleset, DefaultTopN when zero
+
+	backend Backend
+
+	mu        sync.Mutex // Guards data, which the leaderboard sync also updates
+	data      GameData
+	active    string            // Profile this instance plays as
+	disk      []byte            // Document as last read or written
+	pending   []func(*GameData) // Changes made since the last save
+	recovered *CorruptError     // Set when a corrupt document was set aside on load
+}
+
+// NewStorage creates a storage instance on top of a backend
+func NewStorage(b Backend) (*Storage, error) {
+	storage := &Storage{backend: b}
+
+	err := storage.Load()
+	var corrupt *CorruptError
+	switch {
+	case err == nil:
+		return storage, nil
+	case os.IsNotExist(err):
+		storage.data = defaultData()
+	case errors.As(err, &corrupt):
+		// Keep the damaged document for inspection and fall back to the
+		// newest backup that still loads
+		unlock, err := b.Lock()
+		if err != nil {
+			return nil, err
 		}
-		// Save default data
-		if err := storage.Save(); err != nil {
+		corrupt.MovedTo, corrupt.Restored, err = b.Recover(func(doc []byte) bool {
+			_, err := decode(b.String(), doc)
+			return err == nil
+		})
+		unlock()
+		if err != nil {
 			return nil, err
 		}
+		storage.recovered = corrupt
+		if err := storage.Load(); err != nil {
+			storage.data = defaultData()
+		}
+	default:
+		// Never replace a document we could not read
+		return nil, err
 	}
 
+	// Save the fresh or restored data
+	storage.active = activeProfile(&storage.data)
+	if err := storage.Save(); err != nil {
+		return nil, err
+	}
 	return storage, nil
 }
 
-// Load reads data from storage file
+// defaultData is what a new storage file starts with
+func defaultData() GameData {
+	return GameData{
+		Version:    SchemaVersion,
+		Profiles:   []Profile{newProfile(DefaultPlayer)},
+		Active:     DefaultPlayer,
+		HighScores: []HighScore{},
+	}
+}
+
+// DefaultSettings returns the settings of a new profile
+func DefaultSettings() Settings {
+	return Settings{
+		MusicVolume: 0.7,
+		SfxVolume:   0.8,
+		Difficulty:  game.DifficultyMedium,
+		Theme:       "config",
+		Vsync:       true,
+		GridLines:   true,
+		Keys:        DefaultKeys(),
+	}
+}
+
+// DefaultKeys returns the key bindings of a new profile
+func DefaultKeys() KeyBindings {
+	return KeyBindings{Up: "arrowup", Down: "arrowdown", Left: "arrowleft", Right: "arrowright", Pause: "p"}
+}
+
+// activeProfile picks the profile to play as after loading: the one used
+// last, or the first if that one is gone
+func activeProfile(d *GameData) string {
+	if findProfile(d, d.Active) != nil || len(d.Profiles) == 0 {
+		return d.Active
+	}
+	return d.Profiles[0].Name
+}
+
+// Recovered returns the problem found with the stored document when it
+// was opened, or nil if it loaded cleanly
+func (s *Storage) Recovered() *CorruptError {
+	return s.recovered
+}
+
+// Load reads data from the backend, upgrading it from older schema
+// versions. Unparseable documents are reported as a *CorruptError and
+// ones from a newer build as a *VersionError.
 func (s *Storage) Load() error {
-	data, err := ioutil.ReadFile(s.filePath)
+	raw, err := s.backend.Read()
+	if err != nil {
+		return err
+	}
+
+	data, err := decode(s.backend.String(), raw)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(data, &s.data)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data
+	s.disk = raw
+	s.pending = nil
+	s.active = activeProfile(&data)
+	return nil
 }
 
-// Save writes data to storage file
+// Save writes data to the backend. Nothing is written when nothing
+// changed, so backups are not rotated to copies of the current document.
 func (s *Storage) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveLocked()
+}
+
+// saveLocked is Save for callers already holding the lock
+func (s *Storage) saveLocked() error {
+	// Hold the lock from reading the document to replacing it, so two
+	// running instances take turns
+	unlock, err := s.backend.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// If another instance saved since we last looked, start from its data
+	// and apply our changes again
+	current, err := s.backend.Read()
+	switch {
+	case err == nil && bytes.Equal(current, s.disk) && len(s.pending) == 0:
+		return nil
+	case err == nil && !bytes.Equal(current, s.disk):
+		fresh, err := decode(s.backend.String(), current)
+		var newer *VersionError
+		if errors.As(err, &newer) {
+			// A newer build took over the document; leave it alone
+			return err
+		}
+		if err != nil {
+			// Set the damaged document aside and replace it with ours
+			moved, _, err2 := s.backend.Recover(func([]byte) bool { return false })
+			if err2 != nil {
+				return err2
+			}
+			s.recovered = &CorruptError{Path: s.backend.String(), MovedTo: moved, Err: err}
+			break
+		}
+		for _, change := range s.pending {
+			change(&fresh)
+		}
+		s.data = fresh
+	case err != nil && !os.IsNotExist(err):
+		return err
+	}
+
+	s.data.Version = SchemaVersion
 	data, err := json.MarshalIndent(s.data, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(s.filePath, data, 0644)
+	if err := s.backend.Write(data); err != nil {
+		return err
+	}
+
+	s.disk = data
+	s.pending = nil
+	return nil
 }
 
-// AddHighScore adds a new high score and maintains order
-func (s *Storage) AddHighScore(player string, score int) {
+// change applies fn to the data now and remembers it for the next save.
+// Must be called with the lock held.
+func (s *Storage) change(fn func(*GameData)) {
+	fn(&s.data)
+	s.pending = append(s.pending, fn)
+}
+
+// AddHighScore adds a new high score to its ruleset's list and maintains
+// order. replay names the replay file that produced the score, if any.
+func (s *Storage) AddHighScore(player string, score int, rules Ruleset, replay string) {
 	newScore := HighScore{
 		Player: player,
 		Score:  score,
 		Date:   time.Now(),
+		Rules:  rules,
+		Replay: replay,
 	}
 
-	s.data.HighScores = append(s.data.HighScores, newScore)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	top := s.topN()
+	s.change(func(d *GameData) {
+		d.HighScores = append(d.HighScores, newScore)
+		rankScores(d, top)
+	})
+}
+
+// topN returns how many high scores each ruleset keeps
+func (s *Storage) topN() int {
+	if s.TopN <= 0 {
+		return DefaultTopN
+	}
+	return s.TopN
+}
 
-	// Sort high scores in descending order
-	sort.Slice(s.data.HighScores, func(i, j int) bool {
-		return s.data.HighScores[i].Score > s.data.HighScores[j].Score
+// rankScores sorts the high scores best first and keeps only the top
+// scores of each ruleset
+func rankScores(d *GameData, top int) {
+	sort.SliceStable(d.HighScores, func(i, j int) bool {
+		return d.HighScores[i].Score > d.HighScores[j].Score
 	})
 
-	// Keep only top 10 scores
-	if len(s.data.HighScores) > 10 {
-		s.data.HighScores = s.data.HighScores[:10]
+	kept := d.HighScores[:0]
+	counts := make(map[Ruleset]int)
+	for _, hs := range d.HighScores {
+		if counts[hs.Rules] < top {
+			counts[hs.Rules]++
+			kept = append(kept, hs)
+		}
 	}
+	d.HighScores = kept
+}
+
+// SetVerified marks the high score produced by the given replay as verified or not
+func (s *Storage) SetVerified(replay string, verified bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.change(func(d *GameData) {
+		for i := range d.HighScores {
+			if d.HighScores[i].Replay == replay {
+				d.HighScores[i].Verified = verified
+			}
+		}
+	})
 }
 
-// GetHighScores returns all high scores
+// GetHighScores returns the high scores of every ruleset, best first
 func (s *Storage) GetHighScores() []HighScore {
-	return s.data.HighScores
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]HighScore(nil), s.data.HighScores...)
+}
+
+// QueueScore adds a run to the leaderboard outbox
+func (s *Storage) QueueScore(q QueuedScore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.change(func(d *GameData) {
+		d.Outbox = append(d.Outbox, q)
+	})
+}
+
+// GetOutbox returns the runs waiting to be submitted
+func (s *Storage) GetOutbox() []QueuedScore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]QueuedScore(nil), s.data.Outbox...)
+}
+
+// UpdateOutbox replaces the outbox with the result of fn, atomically with
+// respect to other storage calls
+func (s *Storage) UpdateOutbox(fn func([]QueuedScore) []QueuedScore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.change(func(d *GameData) {
+		d.Outbox = fn(d.Outbox)
+	})
 }
 
-// GetSettings returns the current settings
+// GetSettings returns the active profile's settings
 func (s *Storage) GetSettings() Settings {
-	return s.data.Settings
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := findProfile(&s.data, s.active); p != nil {
+		return p.Settings
+	}
+	return DefaultSettings()
 }
 
-// UpdateSettings updates the game settings
+// UpdateSettings updates the active profile's settings
 func (s *Storage) UpdateSettings(settings Settings) {
-	s.data.Settings = settings
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := s.active
+	s.change(func(d *GameData) {
+		ensureProfile(d, name).Settings = settings
+	})
+}
+
+// WriteReplay stores a replay file under a name
+func (s *Storage) WriteReplay(name string, data []byte) error {
+	return s.backend.WriteReplay(name, data)
+}
+
+// ReadReplay returns the replay file stored under a name
+func (s *Storage) ReadReplay(name string) ([]byte, error) {
+	return s.backend.ReadReplay(name)
+}
+
+// ExportDir returns the directory exported GIFs are written to
+func (s *Storage) ExportDir() string {
+	return filepath.Join(paths.DataDir(), "exports")
 }
 
-// findStoragePath locates the storage.json file
-func findStoragePath() string {
-	// Try different common locations
-	locations := []string{
-		filepath.Join("pkg", "storage", "storage.json"),
-		filepath.Join("..", "pkg", "storage", "storage.json"),
-		filepath.Join("..", "..", "pkg", "storage", "storage.json"),
+// FileName is the name of the storage file inside paths.DataDir
+const FileName = "storage.json"
+
+// findStoragePath returns the location of the storage file, first copying
+// the data of older versions, which kept it relative to the working
+// directory
+func findStoragePath() (string, error) {
+	storagePath := filepath.Join(paths.DataDir(), FileName)
+	if _, err := os.Stat(storagePath); !os.IsNotExist(err) {
+		return storagePath, nil
 	}
 
-	for _, loc := range locations {
-		if _, err := os.Stat(loc); err == nil {
-			return loc
-		}
+	legacy := paths.FindLegacy(paths.LegacyDataDirs, FileName)
+	if legacy == "" {
+		return storagePath, os.MkdirAll(filepath.Dir(storagePath), 0755)
 	}
 
-	// Default location if not found
-	return filepath.Join("pkg", "storage", "storage.json")
+	// Replays are linked from the high scores, so they move along
+	log.Printf("Copying game data from %s to %s", filepath.Dir(legacy), filepath.Dir(storagePath))
+	for _, name := range []string{"replays", "exports"} {
+		dir := filepath.Join(filepath.Dir(legacy), name)
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		if err := paths.Copy(dir, filepath.Join(filepath.Dir(storagePath), name)); err != nil {
+			return "", err
+		}
+	}
+	// The storage file goes last so an interrupted copy is retried
+	return storagePath, paths.Copy(legacy, storagePath)
 }
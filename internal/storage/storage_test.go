@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveSkipsUnchanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "storage.json")
+	s, err := NewStorage(NewJSONBackend(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddHighScore(DefaultPlayer, 10, DefaultRuleset(), "")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, _ := ioutil.ReadFile(backupPath(path, 1))
+
+	// Saving again with nothing changed must not rotate the backups
+	for i := 0; i < 3; i++ {
+		if err := s.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if after, _ := ioutil.ReadFile(path); string(after) != string(saved) {
+		t.Error("document rewritten by unchanged saves")
+	}
+	if after, _ := ioutil.ReadFile(backupPath(path, 1)); string(after) != string(backup) {
+		t.Error("backup replaced by unchanged saves")
+	}
+
+	// A change is still written
+	s.AddHighScore(DefaultPlayer, 20, DefaultRuleset(), "")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := ioutil.ReadFile(path); string(after) == string(saved) {
+		t.Error("changed document was not written")
+	}
+}
+
+func TestSettingsPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "storage.json")
+	s, err := NewStorage(NewJSONBackend(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := s.GetSettings()
+	settings.MusicVolume = 0.3
+	settings.SfxVolume = 0
+	settings.Theme = "paper"
+	s.UpdateSettings(settings)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewStorage(NewJSONBackend(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.GetSettings(); got != settings {
+		t.Errorf("reloaded settings %+v, want %+v", got, settings)
+	}
+}
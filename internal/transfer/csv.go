@@ -0,0 +1,275 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// CSV files start with a header naming the columns, which may come in any
+// order. Dates are RFC 3339 with the fraction of a second kept, because
+// duplicates are recognised by their exact date.
+var (
+	scoreColumns = []string{"date", "player", "score", "mode", "board", "initial_speed", "speed_increment", "max_speed", "difficulty", "level", "replay", "verified"}
+	gameColumns  = []string{"date", "profile", "score", "length", "ticks", "mode", "board", "initial_speed", "speed_increment", "max_speed", "difficulty", "level", "replay"}
+
+	profileColumns = []string{"name", "created", "games_played", "total_score", "best_score", "longest_snake", "total_ticks",
+		"music_volume", "sfx_volume", "difficulty", "theme", "fullscreen", "vsync", "grid_lines",
+		"key_up", "key_down", "key_left", "key_right", "key_pause", "unlocks"}
+)
+
+// unlockSeparator joins a profile's unlocks in one CSV field
+const unlockSeparator = ";"
+
+// writeCSV writes the records with a header line
+func writeCSV(w io.Writer, d *Data) error {
+	cw := csv.NewWriter(w)
+
+	switch d.Kind {
+	case Scores:
+		cw.Write(scoreColumns)
+		for _, hs := range d.Scores {
+			r := hs.Rules
+			cw.Write([]string{formatTime(hs.Date), hs.Player, strconv.Itoa(hs.Score),
+				r.Mode, strconv.Itoa(r.Board), formatFloat(r.InitialSpeed), formatFloat(r.SpeedIncrement), formatFloat(r.MaxSpeed), r.Difficulty, r.Level,
+				hs.Replay, strconv.FormatBool(hs.Verified)})
+		}
+	case History:
+		cw.Write(gameColumns)
+		for _, rec := range d.History {
+			r, res := rec.Rules, rec.Result
+			cw.Write([]string{formatTime(rec.Date), rec.Profile, strconv.Itoa(res.Score), strconv.Itoa(res.Length), strconv.Itoa(res.Ticks),
+				r.Mode, strconv.Itoa(r.Board), formatFloat(r.InitialSpeed), formatFloat(r.SpeedIncrement), formatFloat(r.MaxSpeed), r.Difficulty, r.Level,
+				rec.Replay})
+		}
+	case Profiles:
+		cw.Write(profileColumns)
+		for _, p := range d.Profiles {
+			st, set := p.Stats, p.Settings
+			cw.Write([]string{p.Name, formatTime(p.Created),
+				strconv.Itoa(st.GamesPlayed), strconv.Itoa(st.TotalScore), strconv.Itoa(st.BestScore), strconv.Itoa(st.LongestSnake), strconv.Itoa(st.TotalTicks),
+				formatFloat(set.MusicVolume), formatFloat(set.SfxVolume), set.Difficulty, set.Theme,
+				strconv.FormatBool(set.Fullscreen), strconv.FormatBool(set.Vsync), strconv.FormatBool(set.GridLines),
+				set.Keys.Up, set.Keys.Down, set.Keys.Left, set.Keys.Right, set.Keys.Pause, strings.Join(p.Unlocks, unlockSeparator)})
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// readCSV parses records of d.Kind into d
+func readCSV(r io.Reader, d *Data) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The columns a record is recognised by must be there
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	required := map[string][]string{
+		Scores:   {"date", "player"},
+		History:  {"date", "profile"},
+		Profiles: {"name", "created"},
+	}[d.Kind]
+	for _, name := range required {
+		if _, ok := index[name]; !ok {
+			return fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		rec := &row{fields: fields, index: index}
+
+		switch d.Kind {
+		case Scores:
+			d.Scores = append(d.Scores, storage.HighScore{
+				Date:     rec.time("date"),
+				Player:   rec.str("player"),
+				Score:    rec.int("score"),
+				Rules:    rec.rules(),
+				Replay:   rec.str("replay"),
+				Verified: rec.bool("verified"),
+			})
+		case History:
+			d.History = append(d.History, storage.GameRecord{
+				Date:    rec.time("date"),
+				Profile: rec.str("profile"),
+				Rules:   rec.rules(),
+				Result: game.GameResult{
+					Score:  rec.int("score"),
+					Length: rec.int("length"),
+					Ticks:  rec.int("ticks"),
+					State:  game.GameOver,
+				},
+				Replay: rec.str("replay"),
+			})
+		case Profiles:
+			p := storage.Profile{
+				Name:     rec.str("name"),
+				Created:  rec.time("created"),
+				Settings: rec.settings(),
+				Stats: storage.Stats{
+					GamesPlayed:  rec.int("games_played"),
+					TotalScore:   rec.int("total_score"),
+					BestScore:    rec.int("best_score"),
+					LongestSnake: rec.int("longest_snake"),
+					TotalTicks:   rec.int("total_ticks"),
+				},
+			}
+			for _, u := range strings.Split(rec.str("unlocks"), unlockSeparator) {
+				if u = strings.TrimSpace(u); u != "" {
+					p.Unlocks = append(p.Unlocks, u)
+				}
+			}
+			d.Profiles = append(d.Profiles, p)
+		}
+		if rec.err != nil {
+			return fmt.Errorf("line %d: %v", line, rec.err)
+		}
+	}
+}
+
+// row reads the fields of a CSV record by column name. Missing columns
+// and empty fields read as zero values; the first field that does not
+// parse is kept in err.
+type row struct {
+	fields []string
+	index  map[string]int
+	err    error
+}
+
+// str returns a field with surrounding space removed
+func (r *row) str(name string) string {
+	i, ok := r.index[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+// int parses a whole number field
+func (r *row) int(name string) int {
+	s := r.str(name)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	r.fail(name, err)
+	return n
+}
+
+// float parses a decimal number field
+func (r *row) float(name string) float64 {
+	s := r.str(name)
+	if s == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	r.fail(name, err)
+	return f
+}
+
+// bool parses a true or false field
+func (r *row) bool(name string) bool {
+	s := r.str(name)
+	if s == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(s)
+	r.fail(name, err)
+	return b
+}
+
+// time parses an RFC 3339 date field
+func (r *row) time(name string) time.Time {
+	s := r.str(name)
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	r.fail(name, err)
+	return t
+}
+
+// rules reads the ruleset columns shared by scores and games. Files
+// without them are ranked under the default ruleset, like scores from
+// before rulesets were kept.
+func (r *row) rules() storage.Ruleset {
+	if _, ok := r.index["mode"]; !ok {
+		return storage.DefaultRuleset()
+	}
+	return storage.Ruleset{
+		Mode:           r.str("mode"),
+		Board:          r.int("board"),
+		InitialSpeed:   r.float("initial_speed"),
+		SpeedIncrement: r.float("speed_increment"),
+		MaxSpeed:       r.float("max_speed"),
+		Difficulty:     r.str("difficulty"),
+		Level:          r.str("level"),
+	}
+}
+
+// settings reads a profile's settings columns. Settings without a column,
+// as in files from before they were kept, get their default.
+func (r *row) settings() storage.Settings {
+	s := storage.DefaultSettings()
+	for name, field := range map[string]*float64{"music_volume": &s.MusicVolume, "sfx_volume": &s.SfxVolume} {
+		if r.str(name) != "" {
+			*field = r.float(name)
+		}
+	}
+	for name, field := range map[string]*bool{"fullscreen": &s.Fullscreen, "vsync": &s.Vsync, "grid_lines": &s.GridLines} {
+		if r.str(name) != "" {
+			*field = r.bool(name)
+		}
+	}
+	for name, field := range map[string]*string{"difficulty": &s.Difficulty, "theme": &s.Theme,
+		"key_up": &s.Keys.Up, "key_down": &s.Keys.Down, "key_left": &s.Keys.Left, "key_right": &s.Keys.Right, "key_pause": &s.Keys.Pause} {
+		if v := r.str(name); v != "" {
+			*field = v
+		}
+	}
+	return s
+}
+
+// fail records the first parse error along with its column
+func (r *row) fail(name string, err error) {
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("column %s: %v", name, err)
+	}
+}
+
+// formatTime writes a date so it reads back exactly
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// formatFloat writes a number in its shortest exact form
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,227 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// Kinds of data that can be exported and imported
+const (
+	Scores   = "scores"
+	History  = "history"
+	Profiles = "profiles"
+)
+
+// Formats data can be written in
+const (
+	CSV  = "csv"
+	JSON = "json"
+)
+
+// Kinds lists the kinds of data in the order they are described
+var Kinds = []string{Scores, History, Profiles}
+
+// Data holds the records of one kind read from an export
+type Data struct {
+	Kind     string
+	Scores   []storage.HighScore
+	History  []storage.GameRecord
+	Profiles []storage.Profile
+}
+
+// Len returns the number of records
+func (d *Data) Len() int {
+	return len(d.Scores) + len(d.History) + len(d.Profiles)
+}
+
+// Result describes what an import added and left out
+type Result struct {
+	Added      []string // One line per new record
+	Skipped    []string // Records that could not be added, with the reason
+	Duplicates int      // Records already stored
+}
+
+// FormatOf picks the format from a file name's extension, JSON unless it
+// ends in .csv
+func FormatOf(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		return CSV
+	}
+	return JSON
+}
+
+// check rejects unknown kinds and formats
+func check(kind, format string) error {
+	switch kind {
+	case Scores, History, Profiles:
+	default:
+		return fmt.Errorf("unknown kind %q (available: %s)", kind, strings.Join(Kinds, ", "))
+	}
+	if format != CSV && format != JSON {
+		return fmt.Errorf("unknown format %q (available: %s, %s)", format, CSV, JSON)
+	}
+	return nil
+}
+
+// Export writes the stored records of a kind to w
+func Export(w io.Writer, s *storage.Storage, kind, format string) error {
+	if err := check(kind, format); err != nil {
+		return err
+	}
+
+	d := &Data{Kind: kind}
+	switch kind {
+	case Scores:
+		d.Scores = s.GetHighScores()
+	case History:
+		var err error
+		if d.History, err = s.History(); err != nil {
+			return err
+		}
+	case Profiles:
+		d.Profiles = s.Profiles()
+	}
+
+	if format == CSV {
+		return writeCSV(w, d)
+	}
+
+	// Empty lists are written as [] rather than null
+	var v interface{}
+	switch kind {
+	case Scores:
+		if d.Scores == nil {
+			d.Scores = []storage.HighScore{}
+		}
+		v = d.Scores
+	case History:
+		if d.History == nil {
+			d.History = []storage.GameRecord{}
+		}
+		v = d.History
+	case Profiles:
+		if d.Profiles == nil {
+			d.Profiles = []storage.Profile{}
+		}
+		v = d.Profiles
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Read parses records of a kind written by Export, or by hand or a
+// spreadsheet in the same layout
+func Read(r io.Reader, kind, format string) (*Data, error) {
+	if err := check(kind, format); err != nil {
+		return nil, err
+	}
+
+	d := &Data{Kind: kind}
+	if format == CSV {
+		if err := readCSV(r, d); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		dec := json.NewDecoder(r)
+		switch kind {
+		case Scores:
+			err = dec.Decode(&d.Scores)
+		case History:
+			err = dec.Decode(&d.History)
+		case Profiles:
+			err = dec.Decode(&d.Profiles)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d, validate(d)
+}
+
+// validate makes sure every record says when it was made and by whom,
+// which duplicates are recognised by
+func validate(d *Data) error {
+	for i, hs := range d.Scores {
+		if hs.Date.IsZero() || strings.TrimSpace(hs.Player) == "" {
+			return fmt.Errorf("score %d has no date or player", i+1)
+		}
+	}
+	for i, rec := range d.History {
+		if rec.Date.IsZero() || strings.TrimSpace(rec.Profile) == "" {
+			return fmt.Errorf("game %d has no date or profile", i+1)
+		}
+	}
+	for i, p := range d.Profiles {
+		if p.Created.IsZero() || strings.TrimSpace(p.Name) == "" {
+			return fmt.Errorf("profile %d has no name or creation date", i+1)
+		}
+	}
+	return nil
+}
+
+// Import merges the records into storage, skipping the ones already
+// stored, and saves. With dryRun nothing changes and the result tells
+// what would be added.
+func Import(s *storage.Storage, d *Data, dryRun bool) (*Result, error) {
+	res := &Result{}
+
+	switch d.Kind {
+	case Scores:
+		verify := func(hs storage.HighScore) bool {
+			return replay.VerifyHighScore(s, hs) == nil
+		}
+		for _, hs := range s.ImportScores(d.Scores, verify, dryRun) {
+			res.Added = append(res.Added, describeScore(hs))
+		}
+	case History:
+		added, err := s.ImportHistory(d.History, dryRun)
+		for _, rec := range added {
+			res.Added = append(res.Added, describeGame(rec))
+		}
+		if err != nil {
+			return res, err
+		}
+	case Profiles:
+		added, skipped := s.ImportProfiles(d.Profiles, dryRun)
+		for _, p := range added {
+			res.Added = append(res.Added, describeProfile(p))
+		}
+		for _, p := range skipped {
+			res.Skipped = append(res.Skipped, describeProfile(p)+": a different profile has this name, or it is not a valid name")
+		}
+	}
+	res.Duplicates = d.Len() - len(res.Added) - len(res.Skipped)
+
+	// The history is appended as it is imported
+	if dryRun || len(res.Added) == 0 || d.Kind == History {
+		return res, nil
+	}
+	return res, s.Save()
+}
+
+// dateFormat is how record dates are shown in import results
+const dateFormat = "2006-01-02 15:04"
+
+// describeScore formats a high score as one line
+func describeScore(hs storage.HighScore) string {
+	return fmt.Sprintf("%s  %-20s %6d  %s", hs.Date.Local().Format(dateFormat), hs.Player, hs.Score, hs.Rules)
+}
+
+// describeGame formats a history game as one line
+func describeGame(rec storage.GameRecord) string {
+	return fmt.Sprintf("%s  %-20s %6d  length %d  %s", rec.Date.Local().Format(dateFormat), rec.Profile, rec.Result.Score, rec.Result.Length, rec.Rules)
+}
+
+// describeProfile formats a profile as one line
+func describeProfile(p storage.Profile) string {
+	return fmt.Sprintf("%-20s created %s, %d games, best %d", p.Name, p.Created.Local().Format(dateFormat), p.Stats.GamesPlayed, p.Stats.BestScore)
+}
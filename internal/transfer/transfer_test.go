@@ -0,0 +1,88 @@
+package transfer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// at returns a date with a fraction of a second, which must survive a
+// round trip for duplicates to be recognised
+func at(day int) time.Time {
+	return time.Date(2026, time.October, day, 12, 30, 15, 123456789, time.UTC)
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	rules := storage.Ruleset{Mode: game.ModeClassic, Board: 20, InitialSpeed: 5, SpeedIncrement: 0.1, MaxSpeed: 12.5, Difficulty: game.DifficultyHard, Level: "cross"}
+
+	for _, d := range []*Data{
+		{Kind: Scores, Scores: []storage.HighScore{
+			{Player: "alice", Score: 120, Date: at(1), Rules: rules, Replay: "1.gsr", Verified: true},
+			{Player: "bob, the second", Score: 0, Date: at(2), Rules: rules},
+		}},
+		{Kind: History, History: []storage.GameRecord{
+			{Date: at(3), Profile: "alice", Rules: rules, Result: game.GameResult{Score: 40, Length: 7, Ticks: 300, State: game.GameOver}, Replay: "3.gsr"},
+		}},
+		{Kind: Profiles, Profiles: []storage.Profile{{
+			Name:    "alice",
+			Created: at(4),
+			Settings: storage.Settings{MusicVolume: 0.25, SfxVolume: 1, Difficulty: game.DifficultyEasy, Theme: "mono", Vsync: true, GridLines: true,
+				Keys: storage.KeyBindings{Up: "w", Down: "s", Left: "a", Right: "d", Pause: "p"}},
+			Stats:   storage.Stats{GamesPlayed: 3, TotalScore: 90, BestScore: 50, LongestSnake: 12, TotalTicks: 900},
+			Unlocks: []string{"cross", "theme:mono"},
+		}}},
+	} {
+		var buf bytes.Buffer
+		if err := writeCSV(&buf, d); err != nil {
+			t.Fatal(err)
+		}
+		got := &Data{Kind: d.Kind}
+		if err := readCSV(&buf, got); err != nil {
+			t.Fatalf("%s: %v", d.Kind, err)
+		}
+		if !reflect.DeepEqual(got, d) {
+			t.Errorf("%s read back as %+v\nwant %+v", d.Kind, got, d)
+		}
+	}
+}
+
+func TestImportScoresSkipsDuplicates(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		s, err := storage.NewStorage(storage.NewMemoryBackend())
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules := storage.DefaultRuleset()
+		stored := []storage.HighScore{{Player: "alice", Score: 10, Date: at(1), Rules: rules}}
+		if res, err := Import(s, &Data{Kind: Scores, Scores: stored}, false); err != nil || len(res.Added) != 1 {
+			t.Fatalf("importing the first score: %+v, %v", res, err)
+		}
+
+		d := &Data{Kind: Scores, Scores: []storage.HighScore{
+			{Player: "alice", Score: 10, Date: at(1), Rules: rules},                      // Already stored
+			{Player: "ALICE", Score: 99, Date: at(1).In(time.Local), Rules: rules},       // Same run, names and zones differ
+			{Player: "alice", Score: 20, Date: at(1).Add(time.Nanosecond), Rules: rules}, // Another date
+			{Player: "bob", Score: 30, Date: at(1), Rules: rules},                        // Another player
+			{Player: "bob", Score: 30, Date: at(1), Rules: rules},                        // Twice in the import
+		}}
+		res, err := Import(s, d, dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Added) != 2 || res.Duplicates != 3 {
+			t.Errorf("dry run %v: added %d and found %d duplicates, want 2 and 3", dryRun, len(res.Added), res.Duplicates)
+		}
+
+		want := 3
+		if dryRun {
+			want = 1
+		}
+		if n := len(s.GetHighScores()); n != want {
+			t.Errorf("dry run %v: %d scores stored, want %d", dryRun, n, want)
+		}
+	}
+}
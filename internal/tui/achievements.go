@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/achievements"
+)
+
+// toastDuration is how long an unlocked achievement is announced
+const toastDuration = 4 * time.Second
+
+// barWidth is the number of cells in an achievement's progress bar
+const barWidth = 20
+
+// SetAchievements tracks the achievements in local games, announcing the
+// ones reached and listing them all after a game
+func (t *TerminalUI) SetAchievements(defs []achievements.Achievement) {
+	t.achievements = defs
+
+	tracker := achievements.NewTracker(defs, t.storage)
+	tracker.OnUnlock = func(a achievements.Achievement) {
+		t.toast = "Achievement unlocked: " + a.Name + " - " + a.Description
+		t.toastUntil = time.Now().Add(toastDuration)
+	}
+	tracker.Attach(t.game)
+}
+
+// toastText returns the announcement to show, empty once it has expired
+func (t *TerminalUI) toastText() string {
+	if t.toast == "" || time.Now().After(t.toastUntil) {
+		return ""
+	}
+	return "*** " + t.toast + " ***"
+}
+
+// achievementsText lists the achievements with the active profile's
+// progress toward each
+func (t *TerminalUI) achievementsText() string {
+	var sb strings.Builder
+	p := t.storage.ActiveProfile()
+	fmt.Fprintf(&sb, "Achievements of %s - V: Back, Q: Quit\x1b[K\r\n\x1b[K\r\n", p.Name)
+
+	for _, a := range t.achievements {
+		progress := p.Achievements[a.ID]
+		filled := int(a.Fraction(progress) * barWidth)
+		bar := t.glyph(strings.Repeat("#", filled)+strings.Repeat("-", barWidth-filled),
+			strings.Repeat("█", filled)+strings.Repeat("░", barWidth-filled))
+
+		status := fmt.Sprintf("%d/%d", progress.Progress, a.Goal)
+		if progress.Done() {
+			status = "unlocked " + progress.Unlocked.Local().Format("2006-01-02")
+		}
+		fmt.Fprintf(&sb, "%-16s [%s] %-20s %s\x1b[K\r\n", a.Name, bar, status, a.Description)
+	}
+	return sb.String()
+}
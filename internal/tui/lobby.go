@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+)
+
+// inLobby reports whether the player is waiting in a hosted game's lobby
+func (t *TerminalUI) inLobby() bool {
+	return t.remote != nil && t.remote.InLobby()
+}
+
+// handleLobbyKey readies up, picks a color and, for the host, the rules
+func (t *TerminalUI) handleLobbyKey(key Key) {
+	l := t.remote.Lobby()
+	me := l.Players[l.You]
+
+	switch key {
+	case KeyReady:
+		t.remote.SetReady(!me.Ready)
+	case KeyLeft, KeyRight:
+		step := 1
+		if key == KeyLeft {
+			step = -1
+		}
+		t.remote.SetProfile(me.Name, l.NextColor(step, len(render.Palette)))
+	case KeyMode:
+		t.setRules(l, netplay.RuleMode, 1)
+	case KeyLevel:
+		t.setRules(l, netplay.RuleLevel, 1)
+	case KeyFaster:
+		t.setRules(l, netplay.RuleSpeed, 1)
+	case KeySlower:
+		t.setRules(l, netplay.RuleSpeed, -1)
+	}
+}
+
+// setRules changes one of the rules, if the player is the host
+func (t *TerminalUI) setRules(l *netplay.Lobby, field netplay.RuleField, step int) {
+	if l.IsHost() {
+		t.remote.SetRules(l.Rules.Step(field, step))
+	}
+}
+
+// lobbyText lists the seats, the rules and the countdown
+func (t *TerminalUI) lobbyText() string {
+	l := t.remote.Lobby()
+	var sb strings.Builder
+
+	humans := 0
+	for _, p := range l.Players {
+		if !p.Bot {
+			humans++
+		}
+	}
+	fmt.Fprintf(&sb, "Players %d/%d (need %d) - %s\x1b[K\r\n", humans, l.Max, l.Needed, l.Rules)
+	if l.IsHost() {
+		sb.WriteString("You are the host - M: Mode, L: Level, +/-: Speed\x1b[K\r\n")
+	}
+
+	for i, p := range l.Players {
+		state := "waiting"
+		if p.Ready {
+			state = "ready"
+		}
+		var tags []string
+		if i == l.Host {
+			tags = append(tags, "host")
+		}
+		if i == l.You {
+			tags = append(tags, "you")
+		}
+		if p.Bot {
+			tags = append(tags, "bot")
+		}
+
+		swatch := "  "
+		if p.Color > 0 && p.Color <= len(render.Palette) {
+			_, body := render.PaletteColors(p.Color)
+			swatch = t.colorize(body, t.glyph("##", "██"))
+		}
+		fmt.Fprintf(&sb, "%s %-20s %-8s %-7s %s\x1b[K\r\n", swatch, p.Name, render.ColorName(p.Color), state, strings.Join(tags, ", "))
+	}
+
+	if left := t.remote.Countdown(); left > 0 {
+		fmt.Fprintf(&sb, "Starting in %d...\x1b[K\r\n", int(left.Seconds())+1)
+	}
+	return sb.String()
+}
@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+// ShowProfiles opens the profile menu, holding the local game until a
+// profile is picked. New profiles are made with the -profile flag or in
+// the GUI, which has text entry.
+func (t *TerminalUI) ShowProfiles() {
+	t.profiles = true
+	t.profileRow = 0
+
+	active := t.storage.ActiveProfile().Name
+	for i, p := range t.storage.Profiles() {
+		if p.Name == active {
+			t.profileRow = i
+		}
+	}
+}
+
+// handleProfileKey moves the selection and plays as the chosen profile
+func (t *TerminalUI) handleProfileKey(key Key) {
+	profiles := t.storage.Profiles()
+	if len(profiles) == 0 {
+		t.profiles = false
+		return
+	}
+
+	switch key {
+	case KeyUp:
+		t.profileRow = (t.profileRow + len(profiles) - 1) % len(profiles)
+	case KeyDown:
+		t.profileRow = (t.profileRow + 1) % len(profiles)
+	case KeyReady:
+		if t.profileRow < len(profiles) {
+			t.playAs(profiles[t.profileRow].Name)
+		}
+	}
+}
+
+// playAs makes a profile active and starts a fresh game, on the open level
+// if the profile has not unlocked the configured one
+func (t *TerminalUI) playAs(name string) {
+	err := t.storage.SetActiveProfile(name)
+	if err == nil {
+		err = t.storage.Save()
+	}
+	if err != nil {
+		t.notice = err.Error()
+		return
+	}
+
+	t.profiles = false
+	t.notice = ""
+
+	// Play with the profile's theme and difficulty, on the current level
+	settings := t.storage.GetSettings()
+	t.theme = render.NamedTheme(settings.Theme, t.config)
+	t.game.SetDifficulty(t.config, settings.Difficulty)
+	if need := t.storage.ActiveProfile().Locked("level:" + t.game.Level); need > 0 {
+		t.notice = fmt.Sprintf("Level %s unlocks at a best score of %d, playing %s", t.game.Level, need, game.LevelOpen)
+		t.game.Level = game.LevelOpen
+		t.game.Config.Game.Level = game.LevelOpen
+	}
+
+	t.game.Snakes[0].Name = name
+	t.game.Reset()
+	t.archived = false
+}
+
+// profilesText lists the profiles with their stats and unlocks
+func (t *TerminalUI) profilesText() string {
+	var sb strings.Builder
+	sb.WriteString("Choose a profile - Up/Down: Select, Enter: Play, Q: Quit\x1b[K\r\n\x1b[K\r\n")
+
+	for i, p := range t.storage.Profiles() {
+		marker := "  "
+		if i == t.profileRow {
+			marker = "> "
+		}
+		fmt.Fprintf(&sb, "%s%-20s best %5d  games %4d  longest %3d", marker, p.Name, p.Stats.BestScore, p.Stats.GamesPlayed, p.Stats.LongestSnake)
+		if len(p.Unlocks) > 0 {
+			labels := make([]string, len(p.Unlocks))
+			for j, u := range p.Unlocks {
+				labels[j] = storage.UnlockLabel(u)
+			}
+			sb.WriteString("  unlocked: " + strings.Join(labels, ", "))
+		}
+		sb.WriteString("\x1b[K\r\n")
+	}
+
+	fmt.Fprintf(&sb, "\x1b[K\r\n%s\x1b[K\r\n", t.notice)
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// enableRawMode puts the terminal into raw mode and returns a function that
+// restores the previous settings
+func enableRawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+// stty runs stty against the controlling terminal
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package tui
+
+import "errors"
+
+// enableRawMode is not implemented for the Windows console
+func enableRawMode() (func(), error) {
+	return nil, errors.New("terminal front-end is not supported on windows")
+}
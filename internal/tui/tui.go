@@ -0,0 +1,589 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/C0d3-5t3w/go-snake/internal/achievements"
+	"github.com/C0d3-5t3w/go-snake/internal/config"
+	"github.com/C0d3-5t3w/go-snake/internal/game"
+	"github.com/C0d3-5t3w/go-snake/internal/leaderboard"
+	"github.com/C0d3-5t3w/go-snake/internal/netplay"
+	"github.com/C0d3-5t3w/go-snake/internal/render"
+	"github.com/C0d3-5t3w/go-snake/internal/replay"
+	"github.com/C0d3-5t3w/go-snake/internal/storage"
+)
+
+const (
+	frameInterval = time.Second / 30      // Terminal redraw rate
+	escTimeout    = 50 * time.Millisecond // Wait for the rest of an escape sequence
+
+	// ANSI escape sequences
+	escClear      = "\x1b[2J"
+	escHome       = "\x1b[H"
+	escReset      = "\x1b[0m"
+	escHideCursor = "\x1b[?25l"
+	escShowCursor = "\x1b[?25h"
+	escAltScreen  = "\x1b[?1049h"
+	escMainScreen = "\x1b[?1049l"
+)
+
+// Key represents a decoded key press
+type Key int
+
+const (
+	KeyNone Key = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPause
+	KeyRestart
+	KeyInfo
+	KeyReady        // Enter or space, toggles ready in a lobby
+	KeyMode         // Host picks the next mode in a lobby; opens the profiles after a local game
+	KeyLevel        // Host picks the next level in a lobby
+	KeyFaster       // Host raises the speed in a lobby
+	KeySlower       // Host lowers the speed in a lobby
+	KeyAchievements // Opens and closes the achievements after a local game
+	KeyQuit
+)
+
+// TerminalUI renders the game to a terminal using ANSI escape codes
+type TerminalUI struct {
+	game    *game.Game
+	config  *config.Config
+	storage *storage.Storage
+	ascii   bool
+	theme   render.Theme
+	self    int // Index of the player's snake
+
+	remote   *netplay.Client // Non-nil when playing a hosted game
+	netDebug bool            // Whether ping and prediction figures are shown
+	archived bool            // Whether the finished game has been saved
+	notice   string          // One-line message shown under the status
+
+	profiles   bool // Whether the profile menu is shown
+	profileRow int
+
+	leaderboard *leaderboard.Syncer // Shared leaderboard, nil when not configured
+
+	achievements     []achievements.Achievement
+	showAchievements bool      // Whether the achievements screen is shown
+	toast            string    // Achievement announced over the game
+	toastUntil       time.Time // When the announcement goes away
+
+	in  io.Reader
+	out *bufio.Writer
+}
+
+// NewTerminalUI initializes the terminal front-end
+func NewTerminalUI(g *game.Game, cfg *config.Config, s *storage.Storage, ascii bool) (*TerminalUI, error) {
+	t := &TerminalUI{
+		game:    g,
+		config:  cfg,
+		storage: s,
+		ascii:   ascii,
+		theme:   render.NamedTheme(s.GetSettings().Theme, cfg),
+		in:      os.Stdin,
+		out:     bufio.NewWriter(os.Stdout),
+	}
+
+	return t, nil
+}
+
+// NewRemoteTerminalUI initializes the terminal front-end for a hosted game
+func NewRemoteTerminalUI(c *netplay.Client, cfg *config.Config, s *storage.Storage, ascii bool) (*TerminalUI, error) {
+	// Until the match starts an empty board is shown
+	t, err := NewTerminalUI(game.NewGame(cfg), cfg, s, ascii)
+	if err != nil {
+		return nil, err
+	}
+
+	t.game.State = game.Paused
+	t.remote = c
+	return t, nil
+}
+
+// SetLeaderboard submits finished games to a shared leaderboard and shows
+// its top scores next to the local ones
+func (t *TerminalUI) SetLeaderboard(l *leaderboard.Syncer) {
+	t.leaderboard = l
+}
+
+// Run starts the terminal game loop and blocks until the player quits
+func (t *TerminalUI) Run() error {
+	restore, err := enableRawMode()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	t.out.WriteString(escAltScreen + escHideCursor + escClear)
+	defer func() {
+		t.out.WriteString(escReset + escShowCursor + escMainScreen)
+		t.out.Flush()
+	}()
+
+	keys := make(chan Key, 16)
+	go t.readKeys(keys)
+
+	ticker := time.NewTicker(frameInterval)
+	defer ticker.Stop()
+
+	t.draw()
+	for {
+		select {
+		case key := <-keys:
+			if key == KeyQuit {
+				return nil
+			}
+			t.handleKey(key)
+			t.draw()
+		case <-ticker.C:
+			if t.remote != nil {
+				t.syncRemote()
+				t.draw()
+			} else if !t.profiles && t.game.Update() {
+				t.archive()
+				t.draw()
+			}
+		}
+	}
+}
+
+// readKeys decodes raw terminal input into keys. An Escape byte may start
+// an arrow key sequence split over several reads, so it only counts as
+// quitting once escTimeout passes without the rest arriving.
+func (t *TerminalUI) readKeys(keys chan<- Key) {
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, 64)
+			n, err := t.in.Read(buf)
+			if n > 0 {
+				chunks <- buf[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var pending []byte
+	for {
+		var timeout <-chan time.Time
+		if len(pending) > 0 {
+			timeout = time.After(escTimeout)
+		}
+
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				keys <- KeyQuit
+				return
+			}
+			pending = append(pending, chunk...)
+		case <-timeout:
+			// Nothing followed, so a lone Escape was pressed; the start
+			// of a sequence cut short is dropped
+			if len(pending) == 1 {
+				keys <- KeyQuit
+			}
+			pending = nil
+		}
+
+		var decoded []Key
+		decoded, pending = decodeKeys(pending)
+		for _, key := range decoded {
+			keys <- key
+		}
+	}
+}
+
+// decodeKeys maps raw input to every key in it, returning any unfinished
+// escape sequence at the end to be completed by the next read
+func decodeKeys(b []byte) ([]Key, []byte) {
+	var keys []Key
+	for len(b) > 0 {
+		if b[0] != 0x1b {
+			if key := decodeByte(b[0]); key != KeyNone {
+				keys = append(keys, key)
+			}
+			b = b[1:]
+			continue
+		}
+
+		// Arrow keys arrive as ESC [ A..D (or ESC O A..D in application
+		// mode); other sequences may carry parameters before their final
+		// byte, such as ESC [ 1 ; 5 A
+		if len(b) == 1 {
+			return keys, b
+		}
+		if b[1] != '[' && b[1] != 'O' {
+			// Escape followed by a key is Alt held with that key
+			b = b[2:]
+			continue
+		}
+		end := 2
+		for end < len(b) && (b[end] >= '0' && b[end] <= '9' || b[end] == ';') {
+			end++
+		}
+		if end == len(b) {
+			return keys, b
+		}
+		switch b[end] {
+		case 'A':
+			keys = append(keys, KeyUp)
+		case 'B':
+			keys = append(keys, KeyDown)
+		case 'C':
+			keys = append(keys, KeyRight)
+		case 'D':
+			keys = append(keys, KeyLeft)
+		}
+		b = b[end+1:]
+	}
+	return keys, nil
+}
+
+// decodeByte maps a single input byte to a key
+func decodeByte(c byte) Key {
+	switch c {
+	case 'w', 'W':
+		return KeyUp
+	case 's', 'S':
+		return KeyDown
+	case 'a', 'A':
+		return KeyLeft
+	case 'd', 'D':
+		return KeyRight
+	case 'p', 'P':
+		return KeyPause
+	case 'r', 'R':
+		return KeyRestart
+	case 'i', 'I':
+		return KeyInfo
+	case '\r', ' ':
+		return KeyReady
+	case 'm', 'M':
+		return KeyMode
+	case 'l', 'L':
+		return KeyLevel
+	case '+', '=':
+		return KeyFaster
+	case '-':
+		return KeySlower
+	case 'v', 'V':
+		return KeyAchievements
+	case 'q', 'Q', 0x03: // 0x03 is Ctrl-C, which raw mode no longer turns into SIGINT
+		return KeyQuit
+	}
+
+	return KeyNone
+}
+
+// handleKey applies a key press to the game
+func (t *TerminalUI) handleKey(key Key) {
+	// Hosted games only take direction intents, and spectators only
+	// choose which snake to follow
+	if t.remote != nil {
+		if key == KeyInfo {
+			t.netDebug = !t.netDebug
+		} else if t.inLobby() {
+			t.handleLobbyKey(key)
+		} else if t.remote.Spectating() {
+			switch key {
+			case KeyRight, KeyDown:
+				t.remote.CycleFollow(1)
+			case KeyLeft, KeyUp:
+				t.remote.CycleFollow(-1)
+			}
+		} else if dir, ok := keyDirection(key); ok {
+			t.remote.SendDirection(dir)
+		}
+		return
+	}
+
+	if t.profiles {
+		t.handleProfileKey(key)
+		return
+	}
+	if t.showAchievements {
+		if key == KeyAchievements {
+			t.showAchievements = false
+		}
+		return
+	}
+
+	switch key {
+	case KeyPause:
+		t.game.TogglePause()
+	case KeyRestart:
+		if t.game.IsGameOver() {
+			t.game.Reset()
+			t.archived = false
+			t.notice = ""
+		}
+	case KeyMode:
+		if t.game.IsGameOver() {
+			t.ShowProfiles()
+		}
+	case KeyAchievements:
+		if t.game.IsGameOver() && t.achievements != nil {
+			t.showAchievements = true
+		}
+	}
+
+	// Movement controls - only process if playing
+	if t.game.State == game.Playing {
+		if dir, ok := keyDirection(key); ok {
+			t.game.ChangeDirection(dir)
+		}
+	}
+}
+
+// keyDirection maps movement keys to directions
+func keyDirection(key Key) (game.Direction, bool) {
+	switch key {
+	case KeyUp:
+		return game.Up, true
+	case KeyDown:
+		return game.Down, true
+	case KeyLeft:
+		return game.Left, true
+	case KeyRight:
+		return game.Right, true
+	}
+	return 0, false
+}
+
+// syncRemote copies the latest hosted game state for drawing
+func (t *TerminalUI) syncRemote() {
+	g := t.remote.Game()
+	if g != nil {
+		t.game = g
+		t.self = t.remote.Follow()
+	}
+
+	if err := t.remote.Err(); err != nil {
+		t.notice = fmt.Sprintf("Disconnected: %v", err)
+	} else if g == nil && t.remote.Spectating() {
+		t.notice = "Waiting for the match to start"
+	} else if g == nil {
+		t.notice = ""
+	} else if standings := t.remote.Standings(); standings != nil {
+		t.notice = standingsText(standings)
+	} else {
+		t.notice = ""
+	}
+}
+
+// standingsText summarizes the final result of a hosted game
+func standingsText(standings []netplay.Standing) string {
+	parts := make([]string, len(standings))
+	for i, st := range standings {
+		parts[i] = fmt.Sprintf("%s: %d", st.Name, st.Score)
+		if st.Winner {
+			parts[i] += " (winner)"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// archive records the finished game once
+func (t *TerminalUI) archive() {
+	if !t.game.IsGameOver() || t.archived {
+		return
+	}
+
+	t.archived = true
+	r, unlocked, err := replay.Archive(t.storage, t.game, t.storage.ActiveProfile().Name)
+	if err != nil {
+		t.notice = fmt.Sprintf("Failed to save replay: %v", err)
+		return
+	}
+	if len(unlocked) > 0 {
+		labels := make([]string, len(unlocked))
+		for i, u := range unlocked {
+			labels[i] = storage.UnlockLabel(u)
+		}
+		t.notice = "Unlocked " + strings.Join(labels, ", ") + "!"
+	}
+	if t.leaderboard != nil {
+		if err := t.leaderboard.Enqueue(r); err != nil {
+			t.notice = fmt.Sprintf("Failed to queue leaderboard submission: %v", err)
+		}
+	}
+}
+
+// scoresText lists the best local scores for the rules of the game just
+// played and the global scores side by side
+func (t *TerminalUI) scoresText() string {
+	var sb strings.Builder
+	rules := storage.RulesetOf(t.game.Config, t.game.Difficulty)
+	local := t.storage.HighScoresFor(rules)
+
+	var global []leaderboard.Entry
+	globalTitle := ""
+	if t.leaderboard != nil {
+		var err error
+		global, err = t.leaderboard.GlobalTop(rules)
+		globalTitle = "Global"
+		if err != nil && global == nil {
+			globalTitle = "Global (unreachable)"
+		} else if n := t.leaderboard.Pending(); n > 0 {
+			globalTitle = fmt.Sprintf("Global (%d waiting)", n)
+		}
+	}
+
+	fmt.Fprintf(&sb, "Local scores for %s\x1b[K\r\n", rules)
+	fmt.Fprintf(&sb, "%-26s%s\x1b[K\r\n", "Local", globalTitle)
+	for i := 0; i < len(local) || i < len(global); i++ {
+		left, right := "", ""
+		if i < len(local) {
+			left = fmt.Sprintf("%2d. %-12s %5d", i+1, local[i].Player, local[i].Score)
+		}
+		if i < len(global) {
+			right = fmt.Sprintf("%2d. %-12s %5d", i+1, global[i].Player, global[i].Score)
+		}
+		fmt.Fprintf(&sb, "%-26s%s\x1b[K\r\n", left, right)
+	}
+	return sb.String()
+}
+
+// draw renders the board and status lines
+func (t *TerminalUI) draw() {
+	var sb strings.Builder
+	sb.WriteString(escHome)
+
+	if t.profiles {
+		sb.WriteString(t.profilesText() + "\x1b[J")
+		t.out.WriteString(sb.String())
+		t.out.Flush()
+		return
+	}
+	if t.showAchievements {
+		sb.WriteString(t.achievementsText() + "\x1b[J")
+		t.out.WriteString(sb.String())
+		t.out.Flush()
+		return
+	}
+
+	// Index the snakes for quick lookup while scanning the grid
+	type segment struct{ snake, part int }
+	cells := make(map[game.Point2D]segment)
+	for n, snake := range t.game.Snakes {
+		if !snake.Alive && !t.game.IsGameOver() {
+			continue
+		}
+		for i, part := range snake.Body {
+			// The player's own snake wins overlapping cells
+			if prev, ok := cells[part]; !ok || n == t.self && prev.snake != t.self {
+				cells[part] = segment{snake: n, part: i}
+			}
+		}
+	}
+
+	food := make(map[game.Point2D]bool, len(t.game.Food))
+	for _, f := range t.game.Food {
+		food[f] = true
+	}
+
+	gridColor := render.ToRGBA(t.config.Colors.Grid)
+	border := t.colorize(gridColor, t.glyph("+"+strings.Repeat("--", t.game.Grid)+"+", "┌"+strings.Repeat("──", t.game.Grid)+"┐"))
+	sb.WriteString(border + "\r\n")
+
+	for y := 0; y < t.game.Grid; y++ {
+		sb.WriteString(t.colorize(gridColor, t.glyph("|", "│")))
+		for x := 0; x < t.game.Grid; x++ {
+			p := game.Point2D{X: x, Y: y}
+			if seg, ok := cells[p]; ok {
+				head, body := t.theme.ColorsOf(t.game, seg.snake, t.self)
+				if seg.part == 0 {
+					sb.WriteString(t.colorize(head, t.glyph("@@", "██")))
+				} else {
+					sb.WriteString(t.colorize(body, t.glyph("oo", "▓▓")))
+				}
+			} else if t.game.IsWall(p) {
+				sb.WriteString(t.colorize(t.theme.Wall, t.glyph("##", "▒▒")))
+			} else if food[p] {
+				sb.WriteString(t.colorize(t.theme.Food, t.glyph("<>", "◆ ")))
+			} else if t.game.InHazard(p) {
+				sb.WriteString(t.colorize(t.theme.Hazard, t.glyph("::", "░░")))
+			} else {
+				sb.WriteString(t.colorize(t.theme.Background, t.glyph("  ", "  ")))
+			}
+		}
+		sb.WriteString(t.colorize(gridColor, t.glyph("|", "│")) + "\r\n")
+	}
+
+	border = t.colorize(gridColor, t.glyph("+"+strings.Repeat("--", t.game.Grid)+"+", "└"+strings.Repeat("──", t.game.Grid)+"┘"))
+	sb.WriteString(border + "\r\n")
+
+	// Draw score and status
+	score := 0
+	if t.self < len(t.game.Snakes) {
+		score = t.game.Snakes[t.self].Score
+	}
+	statusText := ""
+	switch {
+	case t.inLobby():
+		statusText = "Lobby - Enter/Space: Ready, Left/Right: Color, Q: Quit"
+	case t.remote != nil && t.game.IsGameOver():
+		statusText = "Game Over - Q to Quit"
+	case t.remote != nil && t.remote.Spectating():
+		statusText = fmt.Sprintf("Spectating %s - Arrows: Switch snake, Q: Quit", t.game.Snakes[t.self].Name)
+	case t.remote != nil:
+		statusText = "Online - Arrows/WASD: Move, I: Net info, Q: Quit"
+	case t.game.State == game.Playing:
+		statusText = "Playing - Arrows/WASD: Move, P: Pause, Q: Quit"
+	case t.game.State == game.Paused:
+		statusText = "Paused - Press P to Start, Q to Quit"
+	case t.game.State == game.GameOver:
+		statusText = fmt.Sprintf("Game Over - Score: %d - Press R to Restart, M: Profiles, Q to Quit", score)
+		if t.achievements != nil {
+			statusText = fmt.Sprintf("Game Over - Score: %d - Press R to Restart, M: Profiles, V: Achievements, Q to Quit", score)
+		}
+	}
+	fmt.Fprintf(&sb, "%s\x1b[K\r\n%s\x1b[K\r\n%s\x1b[K\r\n", render.ScoreLine(t.game, t.self), statusText, t.notice)
+	if toast := t.toastText(); toast != "" {
+		fmt.Fprintf(&sb, "%s\x1b[K\r\n", toast)
+	}
+	if t.remote != nil && t.netDebug {
+		fmt.Fprintf(&sb, "%s\x1b[K\r\n", t.remote.Stats())
+	}
+	if t.inLobby() {
+		sb.WriteString(t.lobbyText())
+	}
+
+	// List high scores once a local game has ended
+	if t.remote == nil && t.game.IsGameOver() {
+		sb.WriteString(t.scoresText())
+	}
+	sb.WriteString("\x1b[J")
+
+	t.out.WriteString(sb.String())
+	t.out.Flush()
+}
+
+// glyph picks between the ASCII and Unicode form of a cell
+func (t *TerminalUI) glyph(ascii, unicode string) string {
+	if t.ascii {
+		return ascii
+	}
+	return unicode
+}
+
+// colorize wraps text in a 24-bit ANSI foreground color over the board background
+func (t *TerminalUI) colorize(c color.RGBA, text string) string {
+	bg := t.theme.Background
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm%s%s",
+		c.R, c.G, c.B, bg.R, bg.G, bg.B, text, escReset)
+}
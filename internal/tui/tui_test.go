@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		keys []Key
+		rest string
+	}{
+		{"w", []Key{KeyUp}, ""},
+		{"wasd", []Key{KeyUp, KeyLeft, KeyDown, KeyRight}, ""},
+		{"\x1b[A\x1b[D", []Key{KeyUp, KeyLeft}, ""},
+		{"\x1bOB", []Key{KeyDown}, ""},
+		{"\x1b[1;5C", []Key{KeyRight}, ""},
+		{"p\x1b[", []Key{KeyPause}, "\x1b["},
+		{"\x1b", nil, "\x1b"},
+		{"\x1bq", nil, ""}, // Alt-Q does not quit
+		{"q", []Key{KeyQuit}, ""},
+	} {
+		keys, rest := decodeKeys([]byte(tc.in))
+		if !reflect.DeepEqual(keys, tc.keys) || string(rest) != tc.rest {
+			t.Errorf("decodeKeys(%q) = %v, %q; want %v, %q", tc.in, keys, rest, tc.keys, tc.rest)
+		}
+	}
+}
+
+// chunkReader hands out one chunk per read, pausing before each
+type chunkReader struct {
+	chunks []string
+	pause  chan struct{}
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	<-r.pause
+	if len(r.chunks) == 0 {
+		select {} // Block like a terminal with nothing typed
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestReadKeysJoinsSplitSequences(t *testing.T) {
+	r := &chunkReader{chunks: []string{"\x1b", "[A", "\x1b"}, pause: make(chan struct{})}
+	ui := &TerminalUI{in: r}
+	keys := make(chan Key, 16)
+	go ui.readKeys(keys)
+
+	// The rest of the arrow key arrives well within the escape timeout
+	r.pause <- struct{}{}
+	r.pause <- struct{}{}
+	if key := <-keys; key != KeyUp {
+		t.Fatalf("split arrow key decoded as %v, want %v", key, KeyUp)
+	}
+
+	// A lone Escape quits once nothing follows it
+	r.pause <- struct{}{}
+	if key := <-keys; key != KeyQuit {
+		t.Fatalf("lone escape decoded as %v, want %v", key, KeyQuit)
+	}
+}
@@ -0,0 +1,9 @@
+package config
+
+import _ "embed"
+
+// Default is the config file written on first run when there is none to
+// migrate
+//
+//go:embed config.yaml
+var Default []byte